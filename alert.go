@@ -29,6 +29,24 @@ const (
 	TypeJSON AttrType = "json"
 	// TypeURL means URL of some object
 	TypeURL AttrType = "url"
+	// TypeEmailAddress means email address without display name, e.g. alice@example.com
+	TypeEmailAddress AttrType = "email"
+	// TypeHostName means host name, either single label or FQDN
+	TypeHostName AttrType = "hostname"
+	// TypeProcess means process name or command line
+	TypeProcess AttrType = "process"
+	// TypeCloudResource means ARN or ID of cloud resource, e.g. arn:aws:iam::111122223333:role/admin or i-0123456789abcdef0
+	TypeCloudResource AttrType = "cloudresource"
+	// TypeFilePath means path of a file
+	TypeFilePath AttrType = "filepath"
+	// TypeMACAddr means MAC address
+	TypeMACAddr AttrType = "macaddr"
+	// TypeCIDR means IP address range in CIDR notation
+	TypeCIDR AttrType = "cidr"
+	// TypePort means port number, optionally with protocol, e.g. 443 or 53/udp
+	TypePort AttrType = "port"
+	// TypeCertFingerprint means SHA-1 or SHA-256 fingerprint of certificate in hex, optionally colon separated
+	TypeCertFingerprint AttrType = "certfingerprint"
 )
 
 // AttrContext describes context of the attribute.
@@ -63,6 +81,12 @@ const (
 
 	// CtxAdditionalInfo means the attribute is meta contexts
 	CtxAdditionalInfo AttrContext = "additional"
+
+	// CtxIPv4 means the attribute is IPv4 address.
+	CtxIPv4 AttrContext = "ipv4"
+
+	// CtxIPv6 means the attribute is IPv6 address.
+	CtxIPv6 AttrContext = "ipv6"
 )
 
 // Attribute is element of alert
@@ -78,11 +102,24 @@ type Attribute struct {
 	// Context explains background of the attribute value.
 	Context AttrContexts `json:"context"`
 
+	// InferredContext has contexts in Context that were added by DeepAlert, not by the detector.
+	InferredContext AttrContexts `json:"inferred_context,omitempty"`
+
 	// Timestamp indicates observed time of the attribute.
 	Timestamp *time.Time `json:"timestamp,omitempty"`
+
+	// DerivedFrom refers parent attribute if the attribute is derived from another one, e.g. host of URL.
+	DerivedFrom *AttrRef `json:"derived_from,omitempty"`
 }
 
-// Alert is extranted data from KinesisStream
+// AttrRef refers an attribute by type, key and value.
+type AttrRef struct {
+	Type  AttrType `json:"type"`
+	Key   string   `json:"key"`
+	Value string   `json:"value"`
+}
+
+// Alert is an event of interest reported by a detector. It is received via SQS, SNS, Kinesis Data Streams or S3 objects.
 type Alert struct {
 	Detector    string `json:"detector"`
 	RuleName    string `json:"rule_name"`
@@ -143,9 +180,11 @@ const (
 	maxFieldLen       = 1024
 	maxDescriptionLen = 4096
 	maxBodyLen        = 65536
-	maxAttributes     = 100
 )
 
+// MaxAttributes is maximum number of Alert.Attributes
+const MaxAttributes = 100
+
 // Validate checks own parameters of Alert and returns error if something wrong
 func (x *Alert) Validate() error {
 	if x.Detector == "" {
@@ -169,15 +208,12 @@ func (x *Alert) Validate() error {
 	if len(x.Description) > maxDescriptionLen {
 		return golambda.WrapError(ErrInvalidAlert, "Alert.Description exceeds maximum length")
 	}
-	if len(x.Attributes) > maxAttributes {
+	if len(x.Attributes) > MaxAttributes {
 		return golambda.WrapError(ErrInvalidAlert, "Alert.Attributes exceeds maximum count")
 	}
-	for _, attr := range x.Attributes {
-		if len(attr.Key) > maxFieldLen {
-			return golambda.WrapError(ErrInvalidAlert, "Attribute.Key exceeds maximum length")
-		}
-		if len(attr.Value) > maxFieldLen {
-			return golambda.WrapError(ErrInvalidAlert, "Attribute.Value exceeds maximum length")
+	for i := range x.Attributes {
+		if err := x.Attributes[i].Validate(); err != nil {
+			return golambda.WrapError(err).With("index", i)
 		}
 	}
 	if x.Body != nil {
@@ -0,0 +1,150 @@
+// Package alertmanager receives webhook of Prometheus Alertmanager and converts the alerts to deepalert.Alert.
+package alertmanager
+
+import (
+	"time"
+
+	"github.com/cookpad/deepalert"
+	"github.com/m-mizutani/golambda"
+)
+
+// Webhook is payload of Alertmanager webhook (version 4).
+// See https://prometheus.io/docs/alerting/latest/configuration/#webhook_config
+type Webhook struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+	Status            string            `json:"status"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []*Alert          `json:"alerts"`
+}
+
+// Alert is an alert in Alertmanager webhook.
+type Alert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// AttrMapping converts a label or an annotation to an attribute. One of Label and Annotation is required.
+type AttrMapping struct {
+	Label      string `json:"label,omitempty"`
+	Annotation string `json:"annotation,omitempty"`
+
+	Type deepalert.AttrType `json:"type"`
+	// Key of attribute. Default is name of the label or the annotation.
+	Key     string                  `json:"key,omitempty"`
+	Context []deepalert.AttrContext `json:"context,omitempty"`
+}
+
+// Config is rule to convert Alertmanager alerts.
+type Config struct {
+	// Detector of alerts. Default is "alertmanager".
+	Detector string `json:"detector,omitempty"`
+
+	// RuleIDLabel is label used as RuleID. Default is "alertname".
+	RuleIDLabel string `json:"rule_id_label,omitempty"`
+
+	// RuleNameAnnotation is annotation used as RuleName. Default is "summary".
+	RuleNameAnnotation string `json:"rule_name_annotation,omitempty"`
+
+	// DescriptionAnnotation is annotation used as Description. Default is "description".
+	DescriptionAnnotation string `json:"description_annotation,omitempty"`
+
+	// Attributes is label-to-attribute mapping.
+	Attributes []AttrMapping `json:"attributes,omitempty"`
+
+	// IncludeResolved converts also resolved alerts. Resolved alerts are ignored by default.
+	IncludeResolved bool `json:"include_resolved,omitempty"`
+}
+
+const (
+	defaultDetector              = "alertmanager"
+	defaultRuleIDLabel           = "alertname"
+	defaultRuleNameAnnotation    = "summary"
+	defaultDescriptionAnnotation = "description"
+)
+
+func orDefault(v, d string) string {
+	if v == "" {
+		return d
+	}
+	return v
+}
+
+// Validate checks Config
+func (x *Config) Validate() error {
+	for _, attr := range x.Attributes {
+		if (attr.Label == "") == (attr.Annotation == "") {
+			return golambda.NewError("One of label and annotation is required for attribute mapping").With("attr", attr)
+		}
+		if !deepalert.IsKnownAttrType(attr.Type) {
+			return golambda.NewError("Type of attribute mapping is unknown").With("attr", attr)
+		}
+	}
+	return nil
+}
+
+// Convert converts alerts in the webhook payload to deepalert.Alert. Fingerprint of an alert is used as AlertKey
+// and the alert itself is set to Body.
+func (x *Config) Convert(webhook *Webhook) ([]*deepalert.Alert, error) {
+	var alerts []*deepalert.Alert
+
+	for _, src := range webhook.Alerts {
+		if src.Status == "resolved" && !x.IncludeResolved {
+			continue
+		}
+
+		ruleIDLabel := orDefault(x.RuleIDLabel, defaultRuleIDLabel)
+		alert := &deepalert.Alert{
+			Detector:    orDefault(x.Detector, defaultDetector),
+			RuleID:      src.Labels[ruleIDLabel],
+			RuleName:    src.Annotations[orDefault(x.RuleNameAnnotation, defaultRuleNameAnnotation)],
+			AlertKey:    src.Fingerprint,
+			Description: src.Annotations[orDefault(x.DescriptionAnnotation, defaultDescriptionAnnotation)],
+			Timestamp:   src.StartsAt.UTC(),
+			Body:        src,
+		}
+		if alert.Timestamp.IsZero() {
+			alert.Timestamp = time.Now().UTC()
+		}
+
+		for _, m := range x.Attributes {
+			name, value := m.Label, src.Labels[m.Label]
+			if m.Annotation != "" {
+				name, value = m.Annotation, src.Annotations[m.Annotation]
+			}
+			if value == "" {
+				continue
+			}
+
+			attr := deepalert.Attribute{
+				Type:    m.Type,
+				Key:     orDefault(m.Key, name),
+				Value:   value,
+				Context: m.Context,
+			}
+			// Label value that does not match the type is dropped
+			if err := attr.Validate(); err != nil {
+				Logger.With("attr", attr).With("err", err).Warn("Dropped attribute that does not match the type")
+				continue
+			}
+			alert.AddAttribute(attr)
+		}
+
+		if err := alert.Validate(); err != nil {
+			return nil, golambda.WrapError(err, "Invalid alert").With("fingerprint", src.Fingerprint).With("rule_id_label", ruleIDLabel)
+		}
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, nil
+}
@@ -0,0 +1,163 @@
+package alertmanager_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/alert/alertmanager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const webhookPayload = `{
+  "version": "4",
+  "groupKey": "{}:{alertname=\"UnexpectedEgress\"}",
+  "status": "firing",
+  "receiver": "deepalert",
+  "alerts": [
+    {
+      "status": "firing",
+      "labels": {"alertname": "UnexpectedEgress", "instance_ip": "10.0.0.1", "remote_ip": "198.51.100.1", "severity": "critical"},
+      "annotations": {"summary": "Unexpected egress traffic", "description": "10.0.0.1 sent 10GB to 198.51.100.1"},
+      "startsAt": "2020-01-02T03:04:05Z",
+      "endsAt": "0001-01-01T00:00:00Z",
+      "generatorURL": "http://prometheus/graph",
+      "fingerprint": "c6c8a3b7e1d2f4a5"
+    },
+    {
+      "status": "resolved",
+      "labels": {"alertname": "CryptoMinerCPU", "instance_ip": "10.0.0.2"},
+      "annotations": {},
+      "startsAt": "2020-01-02T03:00:00Z",
+      "endsAt": "2020-01-02T03:10:00Z",
+      "fingerprint": "a1b2c3d4e5f60718"
+    }
+  ]
+}`
+
+var testConfig = alertmanager.Config{
+	Attributes: []alertmanager.AttrMapping{
+		{Label: "instance_ip", Type: deepalert.TypeIPAddr, Context: []deepalert.AttrContext{deepalert.CtxLocal}},
+		{Label: "remote_ip", Type: deepalert.TypeIPAddr, Key: "dst", Context: []deepalert.AttrContext{deepalert.CtxRemote, deepalert.CtxServer}},
+		{Annotation: "user", Type: deepalert.TypeUserName},
+	},
+}
+
+func TestConvert(t *testing.T) {
+	var webhook alertmanager.Webhook
+	require.NoError(t, json.Unmarshal([]byte(webhookPayload), &webhook))
+
+	t.Run("firing alerts are converted", func(t *testing.T) {
+		alerts, err := testConfig.Convert(&webhook)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(alerts))
+
+		a := alerts[0]
+		assert.Equal(t, "alertmanager", a.Detector)
+		assert.Equal(t, "UnexpectedEgress", a.RuleID)
+		assert.Equal(t, "Unexpected egress traffic", a.RuleName)
+		assert.Equal(t, "c6c8a3b7e1d2f4a5", a.AlertKey)
+		assert.Equal(t, "10.0.0.1 sent 10GB to 198.51.100.1", a.Description)
+		assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), a.Timestamp)
+
+		require.Equal(t, 2, len(a.Attributes))
+		assert.Equal(t, "instance_ip", a.Attributes[0].Key)
+		assert.True(t, a.Attributes[0].Match(deepalert.CtxLocal, deepalert.TypeIPAddr))
+		assert.Equal(t, "dst", a.Attributes[1].Key)
+		assert.Equal(t, "198.51.100.1", a.Attributes[1].Value)
+		assert.True(t, a.Attributes[1].Match(deepalert.CtxRemote, deepalert.TypeIPAddr))
+	})
+
+	t.Run("resolved alerts are included by option", func(t *testing.T) {
+		config := testConfig
+		config.IncludeResolved = true
+		config.Detector = "prometheus"
+		alerts, err := config.Convert(&webhook)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(alerts))
+		assert.Equal(t, "prometheus", alerts[1].Detector)
+		assert.Equal(t, "CryptoMinerCPU", alerts[1].RuleID)
+	})
+
+	t.Run("alert without rule ID label is invalid", func(t *testing.T) {
+		config := testConfig
+		config.RuleIDLabel = "rule"
+		_, err := config.Convert(&webhook)
+		assert.ErrorIs(t, err, deepalert.ErrInvalidAlert)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		config := alertmanager.Config{Attributes: []alertmanager.AttrMapping{{Label: "a", Annotation: "b", Type: deepalert.TypeIPAddr}}}
+		assert.Error(t, config.Validate())
+		config = alertmanager.Config{Attributes: []alertmanager.AttrMapping{{Label: "a"}}}
+		assert.Error(t, config.Validate())
+	})
+}
+
+type dummySender struct {
+	alerts []*deepalert.Alert
+	err    error
+}
+
+func (x *dummySender) Send(alerts ...*deepalert.Alert) error {
+	if x.err != nil {
+		return x.err
+	}
+	x.alerts = append(x.alerts, alerts...)
+	return nil
+}
+
+func TestHandler(t *testing.T) {
+	post := func(t *testing.T, sender *dummySender, token, auth, body string) *httptest.ResponseRecorder {
+		h, err := alertmanager.NewHandler(testConfig, sender, token)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("alerts are sent", func(t *testing.T) {
+		sender := &dummySender{}
+		w := post(t, sender, "secret", "Bearer secret", webhookPayload)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"sent":1}`, w.Body.String())
+		require.Equal(t, 1, len(sender.alerts))
+		assert.Equal(t, "c6c8a3b7e1d2f4a5", sender.alerts[0].AlertKey)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		sender := &dummySender{}
+		w := post(t, sender, "secret", "Bearer wrong", webhookPayload)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, 0, len(sender.alerts))
+	})
+
+	t.Run("invalid payload", func(t *testing.T) {
+		w := post(t, &dummySender{}, "", "", `{"alerts":`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("send failure is server error to be retried", func(t *testing.T) {
+		w := post(t, &dummySender{err: errors.New("unavailable")}, "", "", webhookPayload)
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		h, err := alertmanager.NewHandler(testConfig, &dummySender{}, "")
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
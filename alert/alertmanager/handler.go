@@ -0,0 +1,100 @@
+package alertmanager
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/cookpad/deepalert"
+	"github.com/m-mizutani/golambda"
+)
+
+// Logger is github.com/m-mizutani/golambda logger and exported to be controlled from external module.
+var Logger = golambda.Logger
+
+// Sender sends converted alerts. *alert.Client satisfies Sender.
+type Sender interface {
+	Send(alerts ...*deepalert.Alert) error
+}
+
+// maxPayloadSize is limit of webhook payload.
+const maxPayloadSize = 4 * 1024 * 1024
+
+// Handler is http.Handler to receive Alertmanager webhook.
+type Handler struct {
+	config Config
+	sender Sender
+	token  string
+}
+
+// NewHandler creates Handler. If token is not empty, the request must have "Authorization: Bearer <token>" header
+// that can be configured by http_config of webhook_config in Alertmanager.
+func NewHandler(config Config, sender Sender, token string) (*Handler, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if sender == nil {
+		return nil, golambda.NewError("Sender is required")
+	}
+
+	return &Handler{config: config, sender: sender, token: token}, nil
+}
+
+type response struct {
+	Sent  int    `json:"sent"`
+	Error string `json:"error,omitempty"`
+}
+
+func writeResponse(w http.ResponseWriter, code int, resp response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		Logger.With("err", err).Error("Fail to write response")
+	}
+}
+
+// ServeHTTP converts and sends alerts. It responds 4xx for invalid request that Alertmanager does not retry,
+// and 5xx if sending alerts failed so that Alertmanager retries the notification.
+func (x *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeResponse(w, http.StatusMethodNotAllowed, response{Error: "only POST is allowed"})
+		return
+	}
+
+	if x.token != "" {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(x.token)) != 1 {
+			writeResponse(w, http.StatusUnauthorized, response{Error: "invalid token"})
+			return
+		}
+	}
+
+	var webhook Webhook
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPayloadSize)).Decode(&webhook); err != nil {
+		writeResponse(w, http.StatusBadRequest, response{Error: "invalid payload: " + err.Error()})
+		return
+	}
+
+	alerts, err := x.config.Convert(&webhook)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, deepalert.ErrInvalidAlert) {
+			code = http.StatusBadRequest
+		}
+		writeResponse(w, code, response{Error: err.Error()})
+		return
+	}
+
+	if len(alerts) > 0 {
+		if err := x.sender.Send(alerts...); err != nil {
+			Logger.With("err", err).With("groupKey", webhook.GroupKey).Error("Fail to send alerts")
+			writeResponse(w, http.StatusBadGateway, response{Error: "failed to send alerts"})
+			return
+		}
+	}
+
+	Logger.With("groupKey", webhook.GroupKey).With("sent", len(alerts)).Info("Received Alertmanager webhook")
+	writeResponse(w, http.StatusOK, response{Sent: len(alerts)})
+}
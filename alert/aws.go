@@ -0,0 +1,163 @@
+package alert
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/m-mizutani/golambda"
+)
+
+// SQSClient is interface of AWS SDK SQS. Need to have only SendMessageBatch()
+type SQSClient interface {
+	SendMessageBatch(*sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error)
+}
+
+// SQSClientFactory is constructor of SQSClient with region
+type SQSClientFactory func(region string) (SQSClient, error)
+
+// SNSClient is interface of AWS SDK SNS. Need to have only PublishBatch()
+type SNSClient interface {
+	PublishBatch(*sns.PublishBatchInput) (*sns.PublishBatchOutput, error)
+}
+
+// SNSClientFactory is constructor of SNSClient with region
+type SNSClientFactory func(region string) (SNSClient, error)
+
+func newAwsSQSClient(region string) (SQSClient, error) {
+	ssn, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+	if err != nil {
+		return nil, err
+	}
+	return sqs.New(ssn), nil
+}
+
+func newAwsSNSClient(region string) (SNSClient, error) {
+	ssn, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+	if err != nil {
+		return nil, err
+	}
+	return sns.New(ssn), nil
+}
+
+// Both of SendMessageBatch and PublishBatch accept up to 10 entries
+const maxBatchEntries = 10
+
+// Sample: https://sqs.ap-northeast-1.amazonaws.com/123456789xxx/some-queue-name
+var regexSqsURL = regexp.MustCompile(`^https://sqs\.([a-z0-9-]+)\.amazonaws\.com/`)
+
+type sqsTransport struct {
+	queueURL string
+	client   SQSClient
+}
+
+func newSQSTransport(queueURL string, newSQS SQSClientFactory) (*sqsTransport, error) {
+	m := regexSqsURL.FindStringSubmatch(queueURL)
+	if len(m) != 2 {
+		return nil, golambda.NewError("Invalid SQS queue URL").With("url", queueURL)
+	}
+
+	if newSQS == nil {
+		newSQS = newAwsSQSClient
+	}
+	client, err := newSQS(m[1])
+	if err != nil {
+		return nil, golambda.WrapError(err, "Failed to create SQS client").With("url", queueURL)
+	}
+
+	return &sqsTransport{queueURL: queueURL, client: client}, nil
+}
+
+func (x *sqsTransport) maxBatchSize() int { return maxBatchEntries }
+
+func (x *sqsTransport) send(msgs []*message) *sendResult {
+	input := &sqs.SendMessageBatchInput{QueueUrl: aws.String(x.queueURL)}
+	for _, msg := range msgs {
+		input.Entries = append(input.Entries, &sqs.SendMessageBatchRequestEntry{
+			Id:          aws.String(msg.id),
+			MessageBody: aws.String(msg.body),
+		})
+	}
+
+	result := &sendResult{retryable: map[string]error{}, permanent: map[string]error{}}
+	resp, err := x.client.SendMessageBatch(input)
+	if err != nil {
+		for _, msg := range msgs {
+			result.retryable[msg.id] = golambda.WrapError(err, "Failed SendMessageBatch").With("url", x.queueURL)
+		}
+		return result
+	}
+
+	for _, entry := range resp.Failed {
+		err := golambda.NewError("Failed to send alert to SQS").
+			With("code", aws.StringValue(entry.Code)).
+			With("message", aws.StringValue(entry.Message))
+		if aws.BoolValue(entry.SenderFault) {
+			result.permanent[aws.StringValue(entry.Id)] = err
+		} else {
+			result.retryable[aws.StringValue(entry.Id)] = err
+		}
+	}
+
+	return result
+}
+
+type snsTransport struct {
+	topicARN string
+	client   SNSClient
+}
+
+func newSNSTransport(topicARN string, newSNS SNSClientFactory) (*snsTransport, error) {
+	// topicARN sample: arn:aws:sns:us-east-1:111122223333:my-topic
+	arnParts := strings.Split(topicARN, ":")
+	if len(arnParts) != 6 || arnParts[2] != "sns" {
+		return nil, golambda.NewError("Invalid SNS topic ARN").With("arn", topicARN)
+	}
+
+	if newSNS == nil {
+		newSNS = newAwsSNSClient
+	}
+	client, err := newSNS(arnParts[3])
+	if err != nil {
+		return nil, golambda.WrapError(err, "Failed to create SNS client").With("arn", topicARN)
+	}
+
+	return &snsTransport{topicARN: topicARN, client: client}, nil
+}
+
+func (x *snsTransport) maxBatchSize() int { return maxBatchEntries }
+
+func (x *snsTransport) send(msgs []*message) *sendResult {
+	input := &sns.PublishBatchInput{TopicArn: aws.String(x.topicARN)}
+	for _, msg := range msgs {
+		input.PublishBatchRequestEntries = append(input.PublishBatchRequestEntries, &sns.PublishBatchRequestEntry{
+			Id:      aws.String(msg.id),
+			Message: aws.String(msg.body),
+		})
+	}
+
+	result := &sendResult{retryable: map[string]error{}, permanent: map[string]error{}}
+	resp, err := x.client.PublishBatch(input)
+	if err != nil {
+		for _, msg := range msgs {
+			result.retryable[msg.id] = golambda.WrapError(err, "Failed PublishBatch").With("arn", x.topicARN)
+		}
+		return result
+	}
+
+	for _, entry := range resp.Failed {
+		err := golambda.NewError("Failed to publish alert to SNS").
+			With("code", aws.StringValue(entry.Code)).
+			With("message", aws.StringValue(entry.Message))
+		if aws.BoolValue(entry.SenderFault) {
+			result.permanent[aws.StringValue(entry.Id)] = err
+		} else {
+			result.retryable[aws.StringValue(entry.Id)] = err
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,183 @@
+package alert
+
+import (
+	"time"
+
+	"github.com/cookpad/deepalert"
+)
+
+// NewAttribute creates deepalert.Attribute with type, key, value and contexts.
+func NewAttribute(attrType deepalert.AttrType, key, value string, contexts ...deepalert.AttrContext) deepalert.Attribute {
+	return deepalert.Attribute{
+		Type:    attrType,
+		Key:     key,
+		Value:   value,
+		Context: contexts,
+	}
+}
+
+// IPAddr creates an attribute of IP address
+func IPAddr(key, value string, contexts ...deepalert.AttrContext) deepalert.Attribute {
+	return NewAttribute(deepalert.TypeIPAddr, key, value, contexts...)
+}
+
+// Domain creates an attribute of domain name
+func Domain(key, value string, contexts ...deepalert.AttrContext) deepalert.Attribute {
+	return NewAttribute(deepalert.TypeDomainName, key, value, contexts...)
+}
+
+// User creates an attribute of user name
+func User(key, value string, contexts ...deepalert.AttrContext) deepalert.Attribute {
+	return NewAttribute(deepalert.TypeUserName, key, value, contexts...)
+}
+
+// URL creates an attribute of URL
+func URL(key, value string, contexts ...deepalert.AttrContext) deepalert.Attribute {
+	return NewAttribute(deepalert.TypeURL, key, value, contexts...)
+}
+
+// FileHash creates an attribute of hash value of a file
+func FileHash(key, value string, contexts ...deepalert.AttrContext) deepalert.Attribute {
+	return NewAttribute(deepalert.TypeFileHashValue, key, value, contexts...)
+}
+
+// Email creates an attribute of email address
+func Email(key, value string, contexts ...deepalert.AttrContext) deepalert.Attribute {
+	return NewAttribute(deepalert.TypeEmailAddress, key, value, contexts...)
+}
+
+// HostName creates an attribute of host name
+func HostName(key, value string, contexts ...deepalert.AttrContext) deepalert.Attribute {
+	return NewAttribute(deepalert.TypeHostName, key, value, contexts...)
+}
+
+// Process creates an attribute of process name or command line
+func Process(key, value string, contexts ...deepalert.AttrContext) deepalert.Attribute {
+	return NewAttribute(deepalert.TypeProcess, key, value, contexts...)
+}
+
+// CloudResource creates an attribute of ARN or ID of cloud resource
+func CloudResource(key, value string, contexts ...deepalert.AttrContext) deepalert.Attribute {
+	return NewAttribute(deepalert.TypeCloudResource, key, value, contexts...)
+}
+
+// FilePath creates an attribute of file path
+func FilePath(key, value string, contexts ...deepalert.AttrContext) deepalert.Attribute {
+	return NewAttribute(deepalert.TypeFilePath, key, value, contexts...)
+}
+
+// MACAddr creates an attribute of MAC address
+func MACAddr(key, value string, contexts ...deepalert.AttrContext) deepalert.Attribute {
+	return NewAttribute(deepalert.TypeMACAddr, key, value, contexts...)
+}
+
+// CIDR creates an attribute of IP address range
+func CIDR(key, value string, contexts ...deepalert.AttrContext) deepalert.Attribute {
+	return NewAttribute(deepalert.TypeCIDR, key, value, contexts...)
+}
+
+// Port creates an attribute of port number
+func Port(key, value string, contexts ...deepalert.AttrContext) deepalert.Attribute {
+	return NewAttribute(deepalert.TypePort, key, value, contexts...)
+}
+
+// CertFingerprint creates an attribute of certificate fingerprint
+func CertFingerprint(key, value string, contexts ...deepalert.AttrContext) deepalert.Attribute {
+	return NewAttribute(deepalert.TypeCertFingerprint, key, value, contexts...)
+}
+
+// Builder builds deepalert.Alert by method chain.
+//
+//	a, err := alert.NewBuilder("my-ids", "port-scan").
+//		RuleName("Port scan detected").
+//		AlertKey(srcAddr).
+//		IPAddr("src", srcAddr, deepalert.CtxRemote, deepalert.CtxSubject).
+//		IPAddr("dst", dstAddr, deepalert.CtxLocal, deepalert.CtxObject).
+//		Build()
+type Builder struct {
+	alert deepalert.Alert
+}
+
+// NewBuilder creates Builder with required fields of deepalert.Alert. Timestamp is set to current time.
+func NewBuilder(detector, ruleID string) *Builder {
+	return &Builder{
+		alert: deepalert.Alert{
+			Detector:  detector,
+			RuleID:    ruleID,
+			Timestamp: time.Now().UTC(),
+		},
+	}
+}
+
+// RuleName sets human readable rule name
+func (x *Builder) RuleName(name string) *Builder {
+	x.alert.RuleName = name
+	return x
+}
+
+// AlertKey sets key to aggregate alerts into one report
+func (x *Builder) AlertKey(key string) *Builder {
+	x.alert.AlertKey = key
+	return x
+}
+
+// Description sets description of the alert
+func (x *Builder) Description(desc string) *Builder {
+	x.alert.Description = desc
+	return x
+}
+
+// Timestamp sets detected time of the alert
+func (x *Builder) Timestamp(ts time.Time) *Builder {
+	x.alert.Timestamp = ts
+	return x
+}
+
+// Body sets original data of the alert
+func (x *Builder) Body(body interface{}) *Builder {
+	x.alert.Body = body
+	return x
+}
+
+// Attr appends attributes
+func (x *Builder) Attr(attrs ...deepalert.Attribute) *Builder {
+	x.alert.AddAttributes(attrs)
+	return x
+}
+
+// IPAddr appends an attribute of IP address
+func (x *Builder) IPAddr(key, value string, contexts ...deepalert.AttrContext) *Builder {
+	return x.Attr(IPAddr(key, value, contexts...))
+}
+
+// Domain appends an attribute of domain name
+func (x *Builder) Domain(key, value string, contexts ...deepalert.AttrContext) *Builder {
+	return x.Attr(Domain(key, value, contexts...))
+}
+
+// User appends an attribute of user name
+func (x *Builder) User(key, value string, contexts ...deepalert.AttrContext) *Builder {
+	return x.Attr(User(key, value, contexts...))
+}
+
+// URL appends an attribute of URL
+func (x *Builder) URL(key, value string, contexts ...deepalert.AttrContext) *Builder {
+	return x.Attr(URL(key, value, contexts...))
+}
+
+// FileHash appends an attribute of hash value of a file
+func (x *Builder) FileHash(key, value string, contexts ...deepalert.AttrContext) *Builder {
+	return x.Attr(FileHash(key, value, contexts...))
+}
+
+// Build validates and returns a copy of the alert
+func (x *Builder) Build() (*deepalert.Alert, error) {
+	alert := x.alert
+	if len(x.alert.Attributes) > 0 {
+		alert.Attributes = append([]deepalert.Attribute{}, x.alert.Attributes...)
+	}
+	if err := alert.Validate(); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
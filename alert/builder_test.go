@@ -0,0 +1,51 @@
+package alert_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/alert"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder(t *testing.T) {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	builder := alert.NewBuilder("my-ids", "port-scan").
+		RuleName("Port scan").
+		AlertKey("10.0.0.1").
+		Description("port scan detected").
+		Timestamp(ts).
+		Body(map[string]interface{}{"ports": 1024}).
+		IPAddr("src", "192.0.2.1", deepalert.CtxRemote, deepalert.CtxSubject).
+		Domain("domain", "example.com").
+		User("user", "blue", deepalert.CtxLocal).
+		URL("url", "https://example.com/path").
+		FileHash("sha256", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", deepalert.CtxFile)
+
+	a, err := builder.Build()
+	require.NoError(t, err)
+	assert.Equal(t, "my-ids", a.Detector)
+	assert.Equal(t, "port-scan", a.RuleID)
+	assert.Equal(t, "Port scan", a.RuleName)
+	assert.Equal(t, "10.0.0.1", a.AlertKey)
+	assert.Equal(t, ts, a.Timestamp)
+	require.Equal(t, 5, len(a.Attributes))
+	assert.True(t, a.Attributes[0].Match(deepalert.CtxRemote, deepalert.TypeIPAddr))
+	assert.Equal(t, deepalert.TypeDomainName, a.Attributes[1].Type)
+	assert.True(t, a.Attributes[2].Match(deepalert.CtxLocal, deepalert.TypeUserName))
+	assert.Equal(t, deepalert.TypeURL, a.Attributes[3].Type)
+	assert.True(t, a.Attributes[4].Match(deepalert.CtxFile, deepalert.TypeFileHashValue))
+
+	t.Run("built alert is not changed by builder", func(t *testing.T) {
+		builder.IPAddr("dst", "10.0.0.2")
+		assert.Equal(t, 5, len(a.Attributes))
+	})
+
+	t.Run("invalid alert", func(t *testing.T) {
+		_, err := alert.NewBuilder("my-ids", "").Build()
+		require.Error(t, err)
+		assert.ErrorIs(t, err, deepalert.ErrInvalidAlert)
+	})
+}
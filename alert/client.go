@@ -0,0 +1,234 @@
+// Package alert provides a client for detectors to submit deepalert.Alert to DeepAlert and converters from other finding formats.
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cookpad/deepalert"
+	"github.com/m-mizutani/golambda"
+)
+
+// Logger is github.com/m-mizutani/golambda logger and exported to be controlled from external module.
+var Logger = golambda.Logger
+
+// ErrSendAlert means some alerts could not be sent even after retries.
+var ErrSendAlert = golambda.NewError("Failed to send alert")
+
+// LocalHandler is a callback to receive alerts instead of AWS services. It's for local runtime and testing.
+type LocalHandler func(alert *deepalert.Alert) error
+
+// Arguments is parameters to create Client. One of QueueURL, TopicARN and LocalHandler is required.
+type Arguments struct {
+	// QueueURL is URL of alertQueue of DeepAlert stack. (Optional)
+	QueueURL string
+
+	// TopicARN is ARN of SNS topic that is given as alertTopicARN of DeepAlert stack. (Optional)
+	TopicARN string
+
+	// LocalHandler receives alerts directly without AWS services. (Optional)
+	LocalHandler LocalHandler
+
+	// MaxAttempts is maximum number of sending an alert including retries. Default is 5. (Optional)
+	MaxAttempts int
+
+	// BaseDelay is wait time before the first retry. The wait time is doubled for each retry. Default is 200ms. (Optional)
+	BaseDelay time.Duration
+
+	// MaxDelay is upper limit of wait time between retries. Default is 10s. (Optional)
+	MaxDelay time.Duration
+
+	// NewSQS is constructor of SQSClient. If NewSQS is nil, use AWS SDK. (Optional)
+	NewSQS SQSClientFactory
+
+	// NewSNS is constructor of SNSClient. If NewSNS is nil, use AWS SDK. (Optional)
+	NewSNS SNSClientFactory
+
+	// Sleep is used to wait between retries. It's replaceable for testing. (Optional)
+	Sleep func(d time.Duration)
+}
+
+const (
+	defaultMaxAttempts = 5
+	defaultBaseDelay   = 200 * time.Millisecond
+	defaultMaxDelay    = 10 * time.Second
+)
+
+// Client validates and sends alerts to DeepAlert with batching and retry.
+type Client struct {
+	transport   transport
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	sleep       func(d time.Duration)
+}
+
+// message is an alert to be sent. ID is unique in a batch.
+type message struct {
+	id    string
+	alert *deepalert.Alert
+	body  string
+}
+
+// sendResult has IDs of messages that are failed and should be retried or not.
+type sendResult struct {
+	retryable map[string]error
+	permanent map[string]error
+}
+
+type transport interface {
+	maxBatchSize() int
+	send(msgs []*message) *sendResult
+}
+
+// New creates Client with Arguments
+func New(args Arguments) (*Client, error) {
+	client := &Client{
+		maxAttempts: args.MaxAttempts,
+		baseDelay:   args.BaseDelay,
+		maxDelay:    args.MaxDelay,
+		sleep:       args.Sleep,
+	}
+	if client.maxAttempts <= 0 {
+		client.maxAttempts = defaultMaxAttempts
+	}
+	if client.baseDelay <= 0 {
+		client.baseDelay = defaultBaseDelay
+	}
+	if client.maxDelay <= 0 {
+		client.maxDelay = defaultMaxDelay
+	}
+	if client.sleep == nil {
+		client.sleep = time.Sleep
+	}
+
+	switch {
+	case args.QueueURL != "":
+		t, err := newSQSTransport(args.QueueURL, args.NewSQS)
+		if err != nil {
+			return nil, err
+		}
+		client.transport = t
+
+	case args.TopicARN != "":
+		t, err := newSNSTransport(args.TopicARN, args.NewSNS)
+		if err != nil {
+			return nil, err
+		}
+		client.transport = t
+
+	case args.LocalHandler != nil:
+		client.transport = &localTransport{handler: args.LocalHandler}
+
+	default:
+		return nil, golambda.NewError("One of QueueURL, TopicARN and LocalHandler is required")
+	}
+
+	return client, nil
+}
+
+// Send validates all alerts by Alert.Validate and sends them. No alert is sent if any alert is invalid.
+// If some alerts can not be sent after retries, Send returns ErrSendAlert with indexes of the alerts.
+func (x *Client) Send(alerts ...*deepalert.Alert) error {
+	var pending []*message
+	for i, alert := range alerts {
+		if alert == nil {
+			return golambda.WrapError(deepalert.ErrInvalidAlert, "Alert is nil").With("index", i)
+		}
+		if err := alert.Validate(); err != nil {
+			return golambda.WrapError(err, "Invalid alert").With("index", i)
+		}
+
+		raw, err := json.Marshal(alert)
+		if err != nil {
+			return golambda.WrapError(err, "Fail to marshal alert").With("index", i)
+		}
+		pending = append(pending, &message{id: fmt.Sprintf("%d", i), alert: alert, body: string(raw)})
+	}
+
+	failed := map[string]error{}
+	for attempt := 0; len(pending) > 0; attempt++ {
+		if attempt > 0 {
+			x.sleep(x.backoff(attempt))
+		}
+
+		var retry []*message
+		for _, batch := range splitBatch(pending, x.transport.maxBatchSize()) {
+			result := x.transport.send(batch)
+			for _, msg := range batch {
+				if err, ok := result.permanent[msg.id]; ok {
+					failed[msg.id] = err
+				} else if err, ok := result.retryable[msg.id]; ok {
+					if attempt+1 < x.maxAttempts {
+						retry = append(retry, msg)
+					} else {
+						failed[msg.id] = err
+					}
+				}
+			}
+		}
+
+		if len(retry) > 0 {
+			Logger.With("count", len(retry)).With("attempt", attempt+1).Warn("Retrying to send alerts")
+		}
+		pending = retry
+	}
+
+	if len(failed) > 0 {
+		return golambda.WrapError(ErrSendAlert, fmt.Sprintf("%d of %d alert(s) were not sent", len(failed), len(alerts))).
+			With("failed", failed)
+	}
+
+	return nil
+}
+
+func (x *Client) backoff(attempt int) time.Duration {
+	delay := x.baseDelay
+	for i := 1; i < attempt && delay < x.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > x.maxDelay {
+		delay = x.maxDelay
+	}
+	return delay
+}
+
+// SQS and SNS accept up to 256KiB for a batch request in total
+const maxBatchPayload = 256 * 1024
+
+func splitBatch(msgs []*message, maxSize int) [][]*message {
+	var batches [][]*message
+	var current []*message
+	var payload int
+
+	for _, msg := range msgs {
+		if len(current) > 0 && (len(current) >= maxSize || payload+len(msg.body) > maxBatchPayload) {
+			batches = append(batches, current)
+			current, payload = nil, 0
+		}
+		current = append(current, msg)
+		payload += len(msg.body)
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+
+	return batches
+}
+
+type localTransport struct {
+	handler LocalHandler
+}
+
+func (x *localTransport) maxBatchSize() int { return 1 }
+
+func (x *localTransport) send(msgs []*message) *sendResult {
+	result := &sendResult{retryable: map[string]error{}}
+	for _, msg := range msgs {
+		if err := x.handler(msg.alert); err != nil {
+			result.retryable[msg.id] = err
+		}
+	}
+	return result
+}
@@ -0,0 +1,224 @@
+package alert_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/alert"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dummySQSClient fails entries listed in failures at first try
+type dummySQSClient struct {
+	inputs   []*sqs.SendMessageBatchInput
+	failures map[string]bool // ID -> SenderFault
+	apiErr   int
+}
+
+func (x *dummySQSClient) SendMessageBatch(input *sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error) {
+	if x.apiErr > 0 {
+		x.apiErr--
+		return nil, errors.New("service unavailable")
+	}
+
+	x.inputs = append(x.inputs, input)
+	output := &sqs.SendMessageBatchOutput{}
+	for _, entry := range input.Entries {
+		id := aws.StringValue(entry.Id)
+		if senderFault, ok := x.failures[id]; ok {
+			output.Failed = append(output.Failed, &sqs.BatchResultErrorEntry{
+				Id:          entry.Id,
+				Code:        aws.String("InternalError"),
+				SenderFault: aws.Bool(senderFault),
+			})
+			if !senderFault {
+				delete(x.failures, id)
+			}
+			continue
+		}
+		output.Successful = append(output.Successful, &sqs.SendMessageBatchResultEntry{Id: entry.Id})
+	}
+	return output, nil
+}
+
+type dummySNSClient struct {
+	inputs []*sns.PublishBatchInput
+}
+
+func (x *dummySNSClient) PublishBatch(input *sns.PublishBatchInput) (*sns.PublishBatchOutput, error) {
+	x.inputs = append(x.inputs, input)
+	return &sns.PublishBatchOutput{}, nil
+}
+
+func newAlerts(n int) []*deepalert.Alert {
+	var alerts []*deepalert.Alert
+	for i := 0; i < n; i++ {
+		alerts = append(alerts, &deepalert.Alert{
+			Detector: "blue",
+			RuleID:   "r1",
+			AlertKey: fmt.Sprintf("k%d", i),
+		})
+	}
+	return alerts
+}
+
+func TestClientSQS(t *testing.T) {
+	queueURL := "https://sqs.ap-northeast-1.amazonaws.com/111122223333/alertQueue"
+	setup := func(client *dummySQSClient) (*alert.Client, *[]time.Duration) {
+		var sleeps []time.Duration
+		c, err := alert.New(alert.Arguments{
+			QueueURL:  queueURL,
+			BaseDelay: time.Second,
+			MaxDelay:  3 * time.Second,
+			NewSQS: func(region string) (alert.SQSClient, error) {
+				assert.Equal(t, "ap-northeast-1", region)
+				return client, nil
+			},
+			Sleep: func(d time.Duration) { sleeps = append(sleeps, d) },
+		})
+		require.NoError(t, err)
+		return c, &sleeps
+	}
+
+	t.Run("alerts are sent in batches of 10", func(t *testing.T) {
+		client := &dummySQSClient{}
+		c, sleeps := setup(client)
+
+		require.NoError(t, c.Send(newAlerts(23)...))
+		require.Equal(t, 3, len(client.inputs))
+		assert.Equal(t, 10, len(client.inputs[0].Entries))
+		assert.Equal(t, 10, len(client.inputs[1].Entries))
+		assert.Equal(t, 3, len(client.inputs[2].Entries))
+		assert.Equal(t, queueURL, aws.StringValue(client.inputs[0].QueueUrl))
+		assert.Equal(t, 0, len(*sleeps))
+
+		var sent deepalert.Alert
+		require.NoError(t, json.Unmarshal([]byte(*client.inputs[2].Entries[2].MessageBody), &sent))
+		assert.Equal(t, "k22", sent.AlertKey)
+	})
+
+	t.Run("batch is split by payload size", func(t *testing.T) {
+		client := &dummySQSClient{}
+		c, _ := setup(client)
+
+		alerts := newAlerts(3)
+		for _, a := range alerts {
+			a.Description = strings.Repeat("x", 4000)
+			a.Body = strings.Repeat("y", 60000)
+		}
+		require.NoError(t, c.Send(alerts...))
+		assert.Equal(t, 1, len(client.inputs))
+
+		alerts = append(alerts, newAlerts(2)...)
+		alerts[3].Body = strings.Repeat("z", 65000)
+		alerts[4].Body = strings.Repeat("z", 65000)
+		client.inputs = nil
+		require.NoError(t, c.Send(alerts...))
+		assert.Equal(t, 2, len(client.inputs))
+	})
+
+	t.Run("failed entries are retried with backoff", func(t *testing.T) {
+		client := &dummySQSClient{apiErr: 2, failures: map[string]bool{"1": false}}
+		c, sleeps := setup(client)
+
+		require.NoError(t, c.Send(newAlerts(3)...))
+		require.Equal(t, 2, len(client.inputs))
+		assert.Equal(t, 3, len(client.inputs[0].Entries))
+		require.Equal(t, 1, len(client.inputs[1].Entries))
+		assert.Equal(t, "1", aws.StringValue(client.inputs[1].Entries[0].Id))
+		assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}, *sleeps)
+	})
+
+	t.Run("sender fault is not retried", func(t *testing.T) {
+		client := &dummySQSClient{failures: map[string]bool{"0": true}}
+		c, sleeps := setup(client)
+
+		err := c.Send(newAlerts(2)...)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, alert.ErrSendAlert)
+		assert.Equal(t, 1, len(client.inputs))
+		assert.Equal(t, 0, len(*sleeps))
+	})
+
+	t.Run("give up after max attempts", func(t *testing.T) {
+		client := &dummySQSClient{apiErr: 10}
+		c, sleeps := setup(client)
+
+		err := c.Send(newAlerts(1)...)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, alert.ErrSendAlert)
+		assert.Equal(t, 4, len(*sleeps))
+	})
+
+	t.Run("invalid alert prevents sending all alerts", func(t *testing.T) {
+		client := &dummySQSClient{}
+		c, _ := setup(client)
+
+		alerts := newAlerts(3)
+		alerts[2].RuleID = ""
+		err := c.Send(alerts...)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, deepalert.ErrInvalidAlert)
+		assert.Equal(t, 0, len(client.inputs))
+	})
+}
+
+func TestClientSNS(t *testing.T) {
+	client := &dummySNSClient{}
+	c, err := alert.New(alert.Arguments{
+		TopicARN: "arn:aws:sns:us-east-1:111122223333:alertTopic",
+		NewSNS: func(region string) (alert.SNSClient, error) {
+			assert.Equal(t, "us-east-1", region)
+			return client, nil
+		},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, c.Send(newAlerts(12)...))
+	require.Equal(t, 2, len(client.inputs))
+	assert.Equal(t, "arn:aws:sns:us-east-1:111122223333:alertTopic", aws.StringValue(client.inputs[0].TopicArn))
+	assert.Equal(t, 10, len(client.inputs[0].PublishBatchRequestEntries))
+	assert.Equal(t, 2, len(client.inputs[1].PublishBatchRequestEntries))
+}
+
+func TestClientLocal(t *testing.T) {
+	var received []*deepalert.Alert
+	failed := false
+	c, err := alert.New(alert.Arguments{
+		LocalHandler: func(a *deepalert.Alert) error {
+			if a.AlertKey == "k1" && !failed {
+				failed = true
+				return errors.New("temporary")
+			}
+			received = append(received, a)
+			return nil
+		},
+		Sleep: func(time.Duration) {},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, c.Send(newAlerts(2)...))
+	require.Equal(t, 2, len(received))
+	assert.Equal(t, "k0", received[0].AlertKey)
+	assert.Equal(t, "k1", received[1].AlertKey)
+}
+
+func TestNewClient(t *testing.T) {
+	_, err := alert.New(alert.Arguments{})
+	assert.Error(t, err)
+
+	_, err = alert.New(alert.Arguments{QueueURL: "https://example.com/queue"})
+	assert.Error(t, err)
+
+	_, err = alert.New(alert.Arguments{TopicARN: "arn:aws:sqs:us-east-1:111122223333:queue"})
+	assert.Error(t, err)
+}
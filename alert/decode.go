@@ -0,0 +1,130 @@
+package alert
+
+import (
+	"encoding/json"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cookpad/deepalert"
+	"github.com/m-mizutani/golambda"
+)
+
+const (
+	maxDescriptionLen = 4096
+	maxBodyLen        = 65536
+)
+
+// eventBridgeEvent is envelope of events delivered by Amazon EventBridge.
+type eventBridgeEvent struct {
+	Version    string          `json:"version"`
+	DetailType string          `json:"detail-type"`
+	Source     string          `json:"source"`
+	Detail     json.RawMessage `json:"detail"`
+}
+
+// Decode converts JSON data to alerts. Following formats are detected automatically.
+//
+//   - GuardDuty finding
+//   - Security Hub finding (ASFF), or a set of them as {"Findings": [...]}
+//   - Any of above wrapped by EventBridge event. Security Hub event can have multiple findings.
+//   - deepalert.Alert
+func Decode(data []byte) ([]*deepalert.Alert, error) {
+	var event eventBridgeEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, golambda.WrapError(err, "Fail to unmarshal alert data").With("data", string(data))
+	}
+
+	if event.DetailType != "" && len(event.Detail) > 0 {
+		return Decode(event.Detail)
+	}
+
+	var findings struct {
+		UpperCase []json.RawMessage `json:"Findings"`
+		LowerCase []json.RawMessage `json:"findings"`
+	}
+	if err := json.Unmarshal(data, &findings); err == nil && (len(findings.UpperCase) > 0 || len(findings.LowerCase) > 0) {
+		var alerts []*deepalert.Alert
+		for _, finding := range append(findings.UpperCase, findings.LowerCase...) {
+			alert, err := FromSecurityHub(finding)
+			if err != nil {
+				return nil, err
+			}
+			alerts = append(alerts, alert)
+		}
+		return alerts, nil
+	}
+
+	switch {
+	case isGuardDutyFinding(data):
+		alert, err := FromGuardDuty(data)
+		if err != nil {
+			return nil, err
+		}
+		return []*deepalert.Alert{alert}, nil
+
+	case isSecurityHubFinding(data):
+		alert, err := FromSecurityHub(data)
+		if err != nil {
+			return nil, err
+		}
+		return []*deepalert.Alert{alert}, nil
+	}
+
+	var alert deepalert.Alert
+	if err := json.Unmarshal(data, &alert); err != nil {
+		return nil, golambda.WrapError(err, "Fail to unmarshal alert").With("data", string(data))
+	}
+	return []*deepalert.Alert{&alert}, nil
+}
+
+// addAttr appends the attribute if it has valid value and is not duplicated. A value that does not match
+// the type (e.g. host name in IP address field) is dropped with warning instead of rejecting the whole alert.
+func addAttr(alert *deepalert.Alert, attr deepalert.Attribute) {
+	if attr.Value == "" {
+		return
+	}
+	if err := attr.Validate(); err != nil {
+		Logger.With("attr", attr).With("err", err).Warn("Dropped attribute that does not match the type")
+		return
+	}
+	for _, a := range alert.Attributes {
+		if a.Key == attr.Key && a.Value == attr.Value {
+			return
+		}
+	}
+	alert.AddAttribute(attr)
+}
+
+// parseFindingTime returns the first valid timestamp in candidates. Current time is used if none is valid.
+func parseFindingTime(candidates ...string) time.Time {
+	for _, s := range candidates {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Now().UTC()
+}
+
+// findingBody returns the original finding as alert body. It's omitted if it exceeds limit of deepalert.Alert.Body.
+func findingBody(data []byte) interface{} {
+	if len(data) > maxBodyLen {
+		Logger.With("size", len(data)).Warn("Finding is too large to be alert body")
+		return nil
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil
+	}
+	return body
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,144 @@
+package alert
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/cookpad/deepalert"
+	"github.com/m-mizutani/golambda"
+)
+
+// DetectorGuardDuty is Detector of alerts converted from GuardDuty findings.
+const DetectorGuardDuty = "guardduty"
+
+// GuardDutyFinding is a part of Amazon GuardDuty finding format that is required to build an alert.
+// See https://docs.aws.amazon.com/guardduty/latest/ug/guardduty_findings.html
+type GuardDutyFinding struct {
+	SchemaVersion string  `json:"schemaVersion"`
+	AccountID     string  `json:"accountId"`
+	Region        string  `json:"region"`
+	ID            string  `json:"id"`
+	Arn           string  `json:"arn"`
+	Type          string  `json:"type"`
+	Title         string  `json:"title"`
+	Description   string  `json:"description"`
+	Severity      float64 `json:"severity"`
+	CreatedAt     string  `json:"createdAt"`
+	UpdatedAt     string  `json:"updatedAt"`
+
+	Resource struct {
+		ResourceType     string `json:"resourceType"`
+		AccessKeyDetails *struct {
+			AccessKeyID string `json:"accessKeyId"`
+			PrincipalID string `json:"principalId"`
+			UserName    string `json:"userName"`
+			UserType    string `json:"userType"`
+		} `json:"accessKeyDetails"`
+		InstanceDetails *struct {
+			InstanceID        string `json:"instanceId"`
+			NetworkInterfaces []struct {
+				PrivateIPAddress string `json:"privateIpAddress"`
+				PublicIP         string `json:"publicIp"`
+			} `json:"networkInterfaces"`
+		} `json:"instanceDetails"`
+	} `json:"resource"`
+
+	Service struct {
+		ServiceName   string `json:"serviceName"`
+		EventLastSeen string `json:"eventLastSeen"`
+		Action        struct {
+			ActionType              string `json:"actionType"`
+			NetworkConnectionAction *struct {
+				ConnectionDirection string                   `json:"connectionDirection"`
+				RemoteIPDetails     *guardDutyRemoteIPDetail `json:"remoteIpDetails"`
+			} `json:"networkConnectionAction"`
+			AwsAPICallAction *struct {
+				API             string                   `json:"api"`
+				ServiceName     string                   `json:"serviceName"`
+				RemoteIPDetails *guardDutyRemoteIPDetail `json:"remoteIpDetails"`
+			} `json:"awsApiCallAction"`
+			DNSRequestAction *struct {
+				Domain string `json:"domain"`
+			} `json:"dnsRequestAction"`
+			PortProbeAction *struct {
+				PortProbeDetails []struct {
+					RemoteIPDetails *guardDutyRemoteIPDetail `json:"remoteIpDetails"`
+				} `json:"portProbeDetails"`
+			} `json:"portProbeAction"`
+		} `json:"action"`
+	} `json:"service"`
+}
+
+type guardDutyRemoteIPDetail struct {
+	IPAddressV4 string `json:"ipAddressV4"`
+}
+
+func isGuardDutyFinding(data []byte) bool {
+	var v struct {
+		SchemaVersion string `json:"schemaVersion"`
+		Service       struct {
+			ServiceName string `json:"serviceName"`
+		} `json:"service"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return false
+	}
+	return v.SchemaVersion != "" && v.Service.ServiceName == "guardduty"
+}
+
+// FromGuardDuty converts a GuardDuty finding in JSON to deepalert.Alert. AlertKey is ID of the finding
+// because GuardDuty updates the same finding when the activity is observed again.
+func FromGuardDuty(data []byte) (*deepalert.Alert, error) {
+	var finding GuardDutyFinding
+	if err := json.Unmarshal(data, &finding); err != nil {
+		return nil, golambda.WrapError(err, "Fail to unmarshal GuardDuty finding")
+	}
+	if finding.ID == "" || finding.Type == "" {
+		return nil, golambda.WrapError(deepalert.ErrInvalidAlert, "GuardDuty finding requires id and type")
+	}
+
+	alert := &deepalert.Alert{
+		Detector:    DetectorGuardDuty,
+		RuleID:      finding.Type,
+		RuleName:    finding.Title,
+		AlertKey:    finding.ID,
+		Description: truncate(finding.Description, maxDescriptionLen),
+		Timestamp:   parseFindingTime(finding.Service.EventLastSeen, finding.UpdatedAt, finding.CreatedAt),
+		Body:        findingBody(data),
+	}
+
+	action := finding.Service.Action
+	if act := action.NetworkConnectionAction; act != nil && act.RemoteIPDetails != nil {
+		// Remote host is the client of INBOUND connection and the server of OUTBOUND connection
+		ctx := deepalert.CtxServer
+		if strings.EqualFold(act.ConnectionDirection, "INBOUND") {
+			ctx = deepalert.CtxClient
+		}
+		addAttr(alert, IPAddr("remote_ip", act.RemoteIPDetails.IPAddressV4, deepalert.CtxRemote, ctx))
+	}
+	if act := action.AwsAPICallAction; act != nil && act.RemoteIPDetails != nil {
+		addAttr(alert, IPAddr("remote_ip", act.RemoteIPDetails.IPAddressV4, deepalert.CtxRemote, deepalert.CtxClient))
+	}
+	if act := action.PortProbeAction; act != nil {
+		for _, probe := range act.PortProbeDetails {
+			if probe.RemoteIPDetails != nil {
+				addAttr(alert, IPAddr("remote_ip", probe.RemoteIPDetails.IPAddressV4, deepalert.CtxRemote, deepalert.CtxClient))
+			}
+		}
+	}
+	if act := action.DNSRequestAction; act != nil {
+		addAttr(alert, Domain("domain", act.Domain, deepalert.CtxRemote, deepalert.CtxServer))
+	}
+
+	if inst := finding.Resource.InstanceDetails; inst != nil {
+		for _, nic := range inst.NetworkInterfaces {
+			addAttr(alert, IPAddr("instance_private_ip", nic.PrivateIPAddress, deepalert.CtxLocal))
+			addAttr(alert, IPAddr("instance_public_ip", nic.PublicIP, deepalert.CtxLocal))
+		}
+	}
+	if key := finding.Resource.AccessKeyDetails; key != nil {
+		addAttr(alert, User("iam_principal", key.UserName, deepalert.CtxLocal, deepalert.CtxSubject))
+	}
+
+	return alert, nil
+}
@@ -0,0 +1,316 @@
+// Package ioc extracts indicators of compromise (IP addresses, domain names, URLs, email addresses and file
+// hashes) from free text and JSON data, including defanged forms such as hxxp://example[.]com.
+package ioc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cookpad/deepalert"
+	"github.com/m-mizutani/golambda"
+	"golang.org/x/net/publicsuffix"
+)
+
+// KeyPrefix is prefix of Attribute.Key of extracted attributes. The key shows source of the indicator,
+// e.g. "ioc:description" or "ioc:body.detail.remote_ip".
+const KeyPrefix = "ioc:"
+
+// maxKeyLen is max bytes of Attribute.Key of extracted attributes
+const maxKeyLen = 256
+
+// Config is configuration of Extractor.
+type Config struct {
+	// Allowlist has indicators that should not be extracted. An IP address or CIDR matches IP addresses
+	// and hosts of URL, a domain name matches the domain and its subdomains (also in URL and email address),
+	// and other value matches exactly (e.g. hash value).
+	Allowlist []string `json:"allowlist,omitempty"`
+
+	// MaxAttributes is maximum number of attributes added to an alert. 0 means no limit other than
+	// deepalert.MaxAttributes.
+	MaxAttributes int `json:"max_attributes,omitempty"`
+}
+
+// Extractor extracts indicators by Config.
+type Extractor struct {
+	maxAttributes int
+	allowNets     []*net.IPNet
+	allowDomains  []string
+	allowValues   map[string]bool
+}
+
+// Indicator is an extracted indicator.
+type Indicator struct {
+	Type  deepalert.AttrType
+	Value string
+}
+
+// New creates Extractor.
+func New(config Config) (*Extractor, error) {
+	if config.MaxAttributes < 0 {
+		return nil, golambda.NewError("MaxAttributes must not be negative").With("max_attributes", config.MaxAttributes)
+	}
+
+	x := &Extractor{
+		maxAttributes: config.MaxAttributes,
+		allowValues:   map[string]bool{},
+	}
+	for _, entry := range config.Allowlist {
+		entry = strings.TrimSpace(entry)
+		if _, ipnet, err := net.ParseCIDR(entry); err == nil {
+			x.allowNets = append(x.allowNets, ipnet)
+		} else if ip := net.ParseIP(entry); ip != nil {
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			x.allowNets = append(x.allowNets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		} else if domain := (deepalert.Attribute{Type: deepalert.TypeDomainName, Value: entry}); strings.Contains(entry, ".") && domain.Validate() == nil {
+			x.allowDomains = append(x.allowDomains, deepalert.NormalizeAttrValue(deepalert.TypeDomainName, entry))
+		} else if entry != "" {
+			x.allowValues[strings.ToLower(entry)] = true
+		}
+	}
+
+	return x, nil
+}
+
+// LoadConfig parses JSON configuration and creates Extractor.
+func LoadConfig(data []byte) (*Extractor, error) {
+	var config Config
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, golambda.WrapError(err, "Invalid IOC extraction config")
+	}
+	return New(config)
+}
+
+var (
+	textScheme  = regexp.MustCompile(`(?i)\bh(xx|\*\*|tt)p(s?)(\[:\]|:)(//|\[//\])`)
+	textRefang  = strings.NewReplacer("[.]", ".", "(.)", ".", "{.}", ".", "[dot]", ".", "(dot)", ".", "[://]", "://", "[@]", "@")
+	urlPattern  = regexp.MustCompile(`(?i)\b(?:https?|ftp)://[^\s"'<>\x60]+`)
+	mailPattern = regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}\b`)
+	ipv4Pattern = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	// ipv6Pattern matches the whole word with colons (e.g. "ActiveRecord::Base") not to pick hex digits in it
+	ipv6Pattern = regexp.MustCompile(`(?i)[0-9a-z_]*(?::[0-9a-z_]*){2,}`)
+	hashPattern = regexp.MustCompile(`(?i)\b(?:[0-9a-f]{128}|[0-9a-f]{64}|[0-9a-f]{40}|[0-9a-f]{32})\b`)
+	domPattern  = regexp.MustCompile(`(?i)\b(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}\b`)
+)
+
+func refangText(text string) string {
+	text = textRefang.Replace(text)
+	return textScheme.ReplaceAllStringFunc(text, func(s string) string {
+		return "http" + strings.ToLower(textScheme.FindStringSubmatch(s)[2]) + "://"
+	})
+}
+
+// Extract returns indicators in text without duplication. URLs and email addresses are extracted as is, and
+// domain names and IP addresses inside them are not extracted separately.
+func (x *Extractor) Extract(text string) []Indicator {
+	text = refangText(text)
+	var found []Indicator
+	seen := map[Indicator]bool{}
+	// add returns true if value is a valid indicator even if it's duplicated or allowed
+	add := func(attrType deepalert.AttrType, value string) bool {
+		attr := deepalert.Attribute{Type: attrType, Key: "ioc", Value: value}
+		if attr.Validate() != nil {
+			return false
+		}
+		ind := Indicator{Type: attrType, Value: deepalert.NormalizeAttrValue(attrType, value)}
+		if !seen[ind] && !x.allowed(ind) {
+			seen[ind] = true
+			found = append(found, ind)
+		}
+		return true
+	}
+	// mask removes accepted strings from text not to extract their parts again. Rejected strings are kept for
+	// other patterns.
+	mask := func(pattern *regexp.Regexp, accept func(s string) bool) {
+		text = pattern.ReplaceAllStringFunc(text, func(s string) string {
+			if !accept(s) {
+				return s
+			}
+			return strings.Repeat(" ", len(s))
+		})
+	}
+
+	mask(urlPattern, func(s string) bool { return add(deepalert.TypeURL, strings.TrimRight(s, ".,;:!?)]}")) })
+	mask(mailPattern, func(s string) bool { return add(deepalert.TypeEmailAddress, s) })
+	mask(ipv4Pattern, func(s string) bool { return add(deepalert.TypeIPAddr, s) })
+	mask(ipv6Pattern, func(s string) bool {
+		// "::" alone is unspecified address and usually a separator in text
+		if ip := net.ParseIP(s); ip == nil || ip.To4() != nil || ip.IsUnspecified() {
+			return false
+		}
+		return add(deepalert.TypeIPAddr, s)
+	})
+	mask(hashPattern, func(s string) bool { return add(deepalert.TypeFileHashValue, s) })
+	mask(domPattern, func(s string) bool { return isPublicDomain(s) && add(deepalert.TypeDomainName, s) })
+
+	return found
+}
+
+// isPublicDomain returns true if domain has registrable part under ICANN managed public suffix. It excludes
+// file names such as "main.go" and "index.html".
+func isPublicDomain(domain string) bool {
+	domain = strings.ToLower(domain)
+	suffix, icann := publicsuffix.PublicSuffix(domain)
+	if !icann || suffix == domain {
+		return false
+	}
+	_, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	return err == nil
+}
+
+func (x *Extractor) allowed(ind Indicator) bool {
+	if x.allowValues[strings.ToLower(ind.Value)] {
+		return true
+	}
+
+	host := ind.Value
+	switch ind.Type {
+	case deepalert.TypeURL:
+		host = urlHost(ind.Value)
+	case deepalert.TypeEmailAddress:
+		host = ind.Value[strings.LastIndexByte(ind.Value, '@')+1:]
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		for _, ipnet := range x.allowNets {
+			if ipnet.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, domain := range x.allowDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func urlHost(value string) string {
+	rest := value[strings.Index(value, "://")+3:]
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		rest = rest[:i]
+	}
+	if i := strings.LastIndexByte(rest, '@'); i >= 0 {
+		rest = rest[i+1:]
+	}
+	if h, _, err := net.SplitHostPort(rest); err == nil {
+		return h
+	}
+	return strings.Trim(rest, "[]")
+}
+
+// ExtractJSON returns indicators in string values of JSON data with path of the value, e.g. "detail.hosts[0]".
+// Paths are sorted to make result stable.
+func (x *Extractor) ExtractJSON(data interface{}) map[string][]Indicator {
+	results := map[string][]Indicator{}
+	var walk func(path string, v interface{})
+	walk = func(path string, v interface{}) {
+		switch value := v.(type) {
+		case map[string]interface{}:
+			for key, child := range value {
+				walk(joinPath(path, key), child)
+			}
+		case []interface{}:
+			for i, child := range value {
+				walk(fmt.Sprintf("%s[%d]", path, i), child)
+			}
+		case string:
+			if found := x.Extract(value); len(found) > 0 {
+				results[path] = append(results[path], found...)
+			}
+		}
+	}
+	walk("", data)
+	return results
+}
+
+// truncateKey cuts key to limit bytes at a rune boundary not to make invalid UTF-8 key.
+func truncateKey(key string, limit int) string {
+	if len(key) <= limit {
+		return key
+	}
+	for limit > 0 && !utf8.RuneStart(key[limit]) {
+		limit--
+	}
+	return key[:limit]
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// Enrich adds indicators in Description and Body of the alert as attributes with CtxAdditionalInfo. Indicators
+// that the alert already has (by type and normalized value) are not added. It returns number of added
+// attributes.
+func (x *Extractor) Enrich(alert *deepalert.Alert) int {
+	existing := map[Indicator]bool{}
+	for _, attr := range alert.Attributes {
+		existing[Indicator{Type: attr.Type, Value: deepalert.NormalizeAttrValue(attr.Type, attr.Value)}] = true
+	}
+
+	added := 0
+	add := func(key string, indicators []Indicator) bool {
+		for _, ind := range indicators {
+			if len(alert.Attributes) >= deepalert.MaxAttributes || (x.maxAttributes > 0 && added >= x.maxAttributes) {
+				return false
+			}
+			if existing[ind] {
+				continue
+			}
+			existing[ind] = true
+			alert.AddAttribute(deepalert.Attribute{
+				Type:    ind.Type,
+				Key:     key,
+				Value:   ind.Value,
+				Context: deepalert.AttrContexts{deepalert.CtxAdditionalInfo},
+			})
+			added++
+		}
+		return true
+	}
+
+	if !add(KeyPrefix+"description", x.Extract(alert.Description)) || alert.Body == nil {
+		return added
+	}
+
+	// Body is converted to generic JSON value because it may be a struct
+	raw, err := json.Marshal(alert.Body)
+	if err != nil {
+		return added
+	}
+	var body interface{}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return added
+	}
+
+	results := x.ExtractJSON(body)
+	paths := make([]string, 0, len(results))
+	for path := range results {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		key := KeyPrefix + joinPath("body", path)
+		key = truncateKey(key, maxKeyLen)
+		if !add(key, results[path]) {
+			break
+		}
+	}
+
+	return added
+}
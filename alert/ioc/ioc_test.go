@@ -0,0 +1,157 @@
+package ioc_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/alert/ioc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtract(t *testing.T) {
+	extractor, err := ioc.New(ioc.Config{})
+	require.NoError(t, err)
+
+	text := `Connection from 192.0.2.1 to hxxps://malware[.]example[.]com/payload.exe, ` +
+		`reported by admin[@]corp.example.org. C2 is evil[.]test.net and 2001:db8::1 at 12:30:45. ` +
+		`File d41d8cd98f00b204e9800998ecf8427e was written to main.go and index.html (again 192.0.2.1).`
+
+	found := extractor.Extract(text)
+	assert.Equal(t, []ioc.Indicator{
+		{Type: deepalert.TypeURL, Value: "https://malware.example.com/payload.exe"},
+		{Type: deepalert.TypeEmailAddress, Value: "admin@corp.example.org"},
+		{Type: deepalert.TypeIPAddr, Value: "192.0.2.1"},
+		{Type: deepalert.TypeIPAddr, Value: "2001:db8::1"},
+		{Type: deepalert.TypeFileHashValue, Value: "d41d8cd98f00b204e9800998ecf8427e"},
+		{Type: deepalert.TypeDomainName, Value: "evil.test.net"},
+	}, found)
+}
+
+func TestExtractIPv6(t *testing.T) {
+	extractor, err := ioc.New(ioc.Config{})
+	require.NoError(t, err)
+
+	t.Run("scope operators in code are not IPv6 address", func(t *testing.T) {
+		for _, text := range []string{
+			"ActiveRecord::Base at std::vector",
+			"raised in Foo::Bar::Baz#call",
+			"std::map<int, std::string> and ::Kernel.puts",
+			"separated by :: in the list",
+			"MAC is 00:1a:2b:3c:4d:5e",
+		} {
+			assert.Empty(t, extractor.Extract(text), text)
+		}
+	})
+
+	t.Run("IPv6 address next to punctuation", func(t *testing.T) {
+		found := extractor.Extract("peer (fe80::1), dst=2001:DB8:0:0:0:0:0:2.")
+		assert.Equal(t, []ioc.Indicator{
+			{Type: deepalert.TypeIPAddr, Value: "fe80::1"},
+			{Type: deepalert.TypeIPAddr, Value: "2001:db8::2"},
+		}, found)
+	})
+
+	t.Run("rejected candidate does not hide other indicators", func(t *testing.T) {
+		found := extractor.Extract("Foo::evil.example.com::Bar")
+		assert.Equal(t, []ioc.Indicator{{Type: deepalert.TypeDomainName, Value: "evil.example.com"}}, found)
+	})
+}
+
+func TestAllowlist(t *testing.T) {
+	extractor, err := ioc.New(ioc.Config{
+		Allowlist: []string{"example.com", "10.0.0.0/8", "192.0.2.1", "D41D8CD98F00B204E9800998ECF8427E"},
+	})
+	require.NoError(t, err)
+
+	found := extractor.Extract(`https://www.example.com/ alice@example.com sub.example.com notexample.com ` +
+		`10.1.2.3 192.0.2.1 192.0.2.2 http://10.0.0.1:8080/ d41d8cd98f00b204e9800998ecf8427e`)
+	assert.Equal(t, []ioc.Indicator{
+		{Type: deepalert.TypeIPAddr, Value: "192.0.2.2"},
+		{Type: deepalert.TypeDomainName, Value: "notexample.com"},
+	}, found)
+}
+
+func TestEnrich(t *testing.T) {
+	alert := &deepalert.Alert{
+		Detector:    "blue",
+		RuleID:      "r1",
+		Description: "Access to 198.51.100.1",
+		Attributes: []deepalert.Attribute{
+			{Type: deepalert.TypeIPAddr, Key: "dst", Value: "198.51.100.1", Context: deepalert.AttrContexts{deepalert.CtxRemote}},
+		},
+		Body: map[string]interface{}{
+			"detail": map[string]interface{}{
+				"hosts": []interface{}{"www.example.net", "hxxp://203.0.113.5/x"},
+				"count": 3,
+			},
+			"user": "alice@example.org",
+		},
+	}
+
+	t.Run("add indicators with source key", func(t *testing.T) {
+		a := *alert
+		a.Attributes = append([]deepalert.Attribute{}, alert.Attributes...)
+		extractor, err := ioc.New(ioc.Config{})
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, extractor.Enrich(&a))
+		require.NoError(t, a.Validate())
+		assert.Equal(t, 0, len(a.FindAttributes("ioc:description")))
+
+		hosts := a.FindAttributes("ioc:body.detail.hosts[0]")
+		require.Equal(t, 1, len(hosts))
+		assert.Equal(t, "www.example.net", hosts[0].Value)
+		assert.Equal(t, deepalert.AttrContexts{deepalert.CtxAdditionalInfo}, hosts[0].Context)
+		assert.Equal(t, "http://203.0.113.5/x", a.FindAttributes("ioc:body.detail.hosts[1]")[0].Value)
+		assert.Equal(t, deepalert.TypeEmailAddress, a.FindAttributes("ioc:body.user")[0].Type)
+	})
+
+	t.Run("max attributes", func(t *testing.T) {
+		a := *alert
+		a.Attributes = append([]deepalert.Attribute{}, alert.Attributes...)
+		extractor, err := ioc.New(ioc.Config{MaxAttributes: 2})
+		require.NoError(t, err)
+		assert.Equal(t, 2, extractor.Enrich(&a))
+		assert.Equal(t, 3, len(a.Attributes))
+	})
+
+	t.Run("alert attribute limit", func(t *testing.T) {
+		a := deepalert.Alert{Detector: "blue", RuleID: "r1"}
+		var addrs []string
+		for i := 0; i < 120; i++ {
+			addrs = append(addrs, fmt.Sprintf("10.0.%d.1", i))
+		}
+		a.Description = strings.Join(addrs, " ")
+		extractor, err := ioc.New(ioc.Config{})
+		require.NoError(t, err)
+		assert.Equal(t, deepalert.MaxAttributes, extractor.Enrich(&a))
+		assert.NoError(t, a.Validate())
+	})
+
+	t.Run("long key is truncated at rune boundary", func(t *testing.T) {
+		a := deepalert.Alert{
+			Detector: "blue",
+			RuleID:   "r1",
+			Body:     map[string]interface{}{strings.Repeat("ア", 100): "198.51.100.1"},
+		}
+		extractor, err := ioc.New(ioc.Config{})
+		require.NoError(t, err)
+		require.Equal(t, 1, extractor.Enrich(&a))
+
+		key := a.Attributes[0].Key
+		assert.LessOrEqual(t, len(key), 256)
+		assert.True(t, utf8.ValidString(key))
+		assert.True(t, strings.HasPrefix(key, "ioc:body.アア"))
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := ioc.LoadConfig([]byte(`{"unknown":1}`))
+		assert.Error(t, err)
+		_, err = ioc.New(ioc.Config{MaxAttributes: -1})
+		assert.Error(t, err)
+	})
+}
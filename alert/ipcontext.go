@@ -0,0 +1,94 @@
+package alert
+
+import (
+	"net"
+	"strings"
+
+	"github.com/cookpad/deepalert"
+	"github.com/m-mizutani/golambda"
+)
+
+// IPContextTagger adds contexts to IP address attributes that detector did not give. An address in
+// organization CIDRs, private (RFC1918 and IPv6 ULA), loopback or link-local range is tagged with
+// deepalert.CtxLocal and others are tagged with deepalert.CtxRemote. IP version is tagged with
+// deepalert.CtxIPv4 or deepalert.CtxIPv6. Added contexts are also recorded in InferredContext.
+type IPContextTagger struct {
+	orgNets []*net.IPNet
+}
+
+// NewIPContextTagger creates IPContextTagger with CIDRs of your organization (e.g. public address ranges of
+// your office and cloud accounts).
+func NewIPContextTagger(orgCIDRs ...string) (*IPContextTagger, error) {
+	x := &IPContextTagger{}
+	for _, cidr := range orgCIDRs {
+		_, ipnet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, golambda.WrapError(err, "Invalid organization CIDR").With("cidr", cidr)
+		}
+		x.orgNets = append(x.orgNets, ipnet)
+	}
+	return x, nil
+}
+
+func (x *IPContextTagger) isLocal(ip net.IP) bool {
+	if ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+		return true
+	}
+	for _, ipnet := range x.orgNets {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Tag adds inferred contexts to the attribute if it's IP address, and returns true if any context is added.
+// Local or remote context is not added if the attribute already has either of them.
+func (x *IPContextTagger) Tag(attr *deepalert.Attribute) bool {
+	if attr.Type != deepalert.TypeIPAddr {
+		return false
+	}
+	value := deepalert.NormalizeAttrValue(attr.Type, attr.Value)
+	if i := strings.IndexByte(value, '%'); i > 0 {
+		value = value[:i]
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return false
+	}
+
+	var inferred deepalert.AttrContexts
+	if !attr.Context.Have(deepalert.CtxLocal) && !attr.Context.Have(deepalert.CtxRemote) {
+		if x.isLocal(ip) {
+			inferred = append(inferred, deepalert.CtxLocal)
+		} else {
+			inferred = append(inferred, deepalert.CtxRemote)
+		}
+	}
+	if !attr.Context.Have(deepalert.CtxIPv4) && !attr.Context.Have(deepalert.CtxIPv6) {
+		if ip.To4() != nil {
+			inferred = append(inferred, deepalert.CtxIPv4)
+		} else {
+			inferred = append(inferred, deepalert.CtxIPv6)
+		}
+	}
+
+	if len(inferred) == 0 {
+		return false
+	}
+	// Context may share underlying array with other attributes
+	attr.Context = append(append(deepalert.AttrContexts{}, attr.Context...), inferred...)
+	attr.InferredContext = append(append(deepalert.AttrContexts{}, attr.InferredContext...), inferred...)
+	return true
+}
+
+// TagAlert runs Tag for all attributes of the alert and returns number of tagged attributes.
+func (x *IPContextTagger) TagAlert(alert *deepalert.Alert) int {
+	n := 0
+	for i := range alert.Attributes {
+		if x.Tag(&alert.Attributes[i]) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,59 @@
+package alert_test
+
+import (
+	"testing"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/alert"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPContextTagger(t *testing.T) {
+	tagger, err := alert.NewIPContextTagger("203.0.113.0/24", "2001:db8::/32")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		value    string
+		given    deepalert.AttrContexts
+		expected deepalert.AttrContexts
+		inferred deepalert.AttrContexts
+	}{
+		{"10.1.2.3", nil, deepalert.AttrContexts{deepalert.CtxLocal, deepalert.CtxIPv4}, deepalert.AttrContexts{deepalert.CtxLocal, deepalert.CtxIPv4}},
+		{"127.0.0.1", nil, deepalert.AttrContexts{deepalert.CtxLocal, deepalert.CtxIPv4}, deepalert.AttrContexts{deepalert.CtxLocal, deepalert.CtxIPv4}},
+		{"169.254.169.254", nil, deepalert.AttrContexts{deepalert.CtxLocal, deepalert.CtxIPv4}, deepalert.AttrContexts{deepalert.CtxLocal, deepalert.CtxIPv4}},
+		{"fe80::1", nil, deepalert.AttrContexts{deepalert.CtxLocal, deepalert.CtxIPv6}, deepalert.AttrContexts{deepalert.CtxLocal, deepalert.CtxIPv6}},
+		{"203.0.113.10", nil, deepalert.AttrContexts{deepalert.CtxLocal, deepalert.CtxIPv4}, deepalert.AttrContexts{deepalert.CtxLocal, deepalert.CtxIPv4}},
+		{"2001:db8::10", nil, deepalert.AttrContexts{deepalert.CtxLocal, deepalert.CtxIPv6}, deepalert.AttrContexts{deepalert.CtxLocal, deepalert.CtxIPv6}},
+		{"198.51.100.1", nil, deepalert.AttrContexts{deepalert.CtxRemote, deepalert.CtxIPv4}, deepalert.AttrContexts{deepalert.CtxRemote, deepalert.CtxIPv4}},
+		{"::ffff:198.51.100.1", nil, deepalert.AttrContexts{deepalert.CtxRemote, deepalert.CtxIPv4}, deepalert.AttrContexts{deepalert.CtxRemote, deepalert.CtxIPv4}},
+		// Context given by detector is never overridden
+		{"10.1.2.3", deepalert.AttrContexts{deepalert.CtxRemote, deepalert.CtxClient},
+			deepalert.AttrContexts{deepalert.CtxRemote, deepalert.CtxClient, deepalert.CtxIPv4}, deepalert.AttrContexts{deepalert.CtxIPv4}},
+		{"10.1.2.3", deepalert.AttrContexts{deepalert.CtxLocal, deepalert.CtxIPv4}, deepalert.AttrContexts{deepalert.CtxLocal, deepalert.CtxIPv4}, nil},
+	}
+
+	for _, tc := range testCases {
+		attr := deepalert.Attribute{Type: deepalert.TypeIPAddr, Key: "addr", Value: tc.value, Context: tc.given}
+		tagged := tagger.Tag(&attr)
+		assert.Equal(t, tc.inferred != nil, tagged, tc.value)
+		assert.Equal(t, tc.expected, attr.Context, tc.value)
+		assert.Equal(t, tc.inferred, attr.InferredContext, tc.value)
+	}
+
+	t.Run("other types and invalid values are not tagged", func(t *testing.T) {
+		a := &deepalert.Alert{Attributes: []deepalert.Attribute{
+			{Type: deepalert.TypeDomainName, Key: "d", Value: "example.com"},
+			{Type: deepalert.TypeIPAddr, Key: "ip", Value: "not-ip"},
+			{Type: deepalert.TypeIPAddr, Key: "ip", Value: "192.0.2.1"},
+		}}
+		assert.Equal(t, 1, tagger.TagAlert(a))
+		assert.Nil(t, a.Attributes[0].Context)
+		assert.Nil(t, a.Attributes[1].Context)
+	})
+
+	t.Run("invalid CIDR", func(t *testing.T) {
+		_, err := alert.NewIPContextTagger("10.0.0.0/33")
+		assert.Error(t, err)
+	})
+}
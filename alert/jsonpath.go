@@ -0,0 +1,138 @@
+package alert
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/m-mizutani/golambda"
+)
+
+// jsonPath is a subset of JSONPath. Supported syntax is root ($), child (.name, ['name']), index ([0]) and wildcard (.* and [*]).
+type jsonPath []pathToken
+
+type pathToken struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func parseJSONPath(s string) (jsonPath, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "$") {
+		return nil, golambda.NewError("JSONPath must start with $").With("path", s)
+	}
+
+	var path jsonPath
+	for i := 1; i < len(s); {
+		switch s[i] {
+		case '.':
+			i++
+			end := i
+			for end < len(s) && s[end] != '.' && s[end] != '[' {
+				end++
+			}
+			name := s[i:end]
+			switch name {
+			case "":
+				return nil, golambda.NewError("Empty name in JSONPath").With("path", s)
+			case "*":
+				path = append(path, pathToken{wildcard: true})
+			default:
+				path = append(path, pathToken{key: name})
+			}
+			i = end
+
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, golambda.NewError("Unclosed bracket in JSONPath").With("path", s)
+			}
+			inner := s[i+1 : i+end]
+			i += end + 1
+
+			switch {
+			case inner == "*":
+				path = append(path, pathToken{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				path = append(path, pathToken{key: inner[1 : len(inner)-1]})
+			default:
+				idx, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, golambda.NewError("Invalid index in JSONPath").With("path", s).With("index", inner)
+				}
+				path = append(path, pathToken{index: idx, isIndex: true})
+			}
+
+		default:
+			return nil, golambda.NewError("Invalid character in JSONPath").With("path", s).With("pos", i)
+		}
+	}
+
+	return path, nil
+}
+
+// eval returns all values matched with the path.
+func (x jsonPath) eval(root interface{}) []interface{} {
+	values := []interface{}{root}
+	for _, token := range x {
+		var next []interface{}
+		for _, v := range values {
+			switch obj := v.(type) {
+			case map[string]interface{}:
+				if token.wildcard {
+					for _, child := range obj {
+						next = append(next, child)
+					}
+				} else if child, ok := obj[token.key]; ok && !token.isIndex {
+					next = append(next, child)
+				}
+
+			case []interface{}:
+				if token.wildcard {
+					next = append(next, obj...)
+				} else if token.isIndex {
+					idx := token.index
+					if idx < 0 {
+						idx += len(obj)
+					}
+					if 0 <= idx && idx < len(obj) {
+						next = append(next, obj[idx])
+					}
+				}
+			}
+		}
+		values = next
+	}
+
+	var results []interface{}
+	for _, v := range values {
+		if v != nil {
+			results = append(results, v)
+		}
+	}
+	return results
+}
+
+// stringify converts a JSON value to string. Object and array are encoded to JSON.
+func stringify(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case bool:
+		return strconv.FormatBool(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return ""
+		}
+		return string(raw)
+	}
+}
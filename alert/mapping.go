@@ -0,0 +1,317 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cookpad/deepalert"
+	"github.com/m-mizutani/golambda"
+)
+
+// ErrNoMapping means no mapping matches with the data.
+var ErrNoMapping = golambda.NewError("No mapping matches with data")
+
+// Mapping is declarative rule to convert arbitrary JSON data to deepalert.Alert.
+// Detector, RuleID, RuleName, AlertKey and Description are templates that can have JSONPath placeholders
+// like "{{ $.rule }}". Supported JSONPath syntax is $, .name, ['name'], [0], .* and [*].
+//
+//	{
+//	  "name": "falco",
+//	  "route": {"path": "$.source", "value": "syscall"},
+//	  "detector": "falco",
+//	  "rule_id": "{{ $.rule }}",
+//	  "alert_key": "{{ $.output_fields['k8s.pod.name'] }}",
+//	  "timestamp": {"path": "$.time"},
+//	  "attributes": [
+//	    {"type": "username", "key": "user", "path": "$.output_fields['user.name']", "context": ["local", "subject"]}
+//	  ]
+//	}
+type Mapping struct {
+	// Name identifies the mapping
+	Name string `json:"name"`
+
+	// Route decides if the mapping is used for the data. Nil Route matches any data.
+	Route *Route `json:"route,omitempty"`
+
+	Detector    string `json:"detector"`
+	RuleID      string `json:"rule_id"`
+	RuleName    string `json:"rule_name,omitempty"`
+	AlertKey    string `json:"alert_key,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// Timestamp of alert. Current time is used if not available.
+	Timestamp *TimestampMapping `json:"timestamp,omitempty"`
+
+	Attributes []AttrMapping `json:"attributes,omitempty"`
+
+	// Body is JSONPath of alert body. Default is whole data.
+	Body string `json:"body,omitempty"`
+}
+
+// Route matches data that has Value at Path. If Value is empty, existence of a value at Path is checked.
+type Route struct {
+	Path  string `json:"path"`
+	Value string `json:"value,omitempty"`
+}
+
+// TimestampMapping extracts time from data.
+type TimestampMapping struct {
+	// Path is JSONPath of timestamp value
+	Path string `json:"path"`
+
+	// Format is one of "rfc3339" (default), "unix" (seconds), "unixms" (milliseconds) or layout of Go time package.
+	Format string `json:"format,omitempty"`
+}
+
+// AttrMapping extracts attributes. An attribute is created for each value matched with Path.
+type AttrMapping struct {
+	Type    deepalert.AttrType      `json:"type"`
+	Key     string                  `json:"key"`
+	Path    string                  `json:"path"`
+	Context []deepalert.AttrContext `json:"context,omitempty"`
+}
+
+// Mapper converts JSON data to deepalert.Alert by the first matched Mapping.
+type Mapper struct {
+	mappings []*compiledMapping
+}
+
+type compiledMapping struct {
+	name        string
+	routePath   jsonPath
+	routeValue  string
+	detector    *fieldTemplate
+	ruleID      *fieldTemplate
+	ruleName    *fieldTemplate
+	alertKey    *fieldTemplate
+	description *fieldTemplate
+	timePath    jsonPath
+	timeFormat  string
+	attributes  []compiledAttr
+	body        jsonPath
+}
+
+type compiledAttr struct {
+	attrType deepalert.AttrType
+	key      string
+	path     jsonPath
+	context  deepalert.AttrContexts
+}
+
+// NewMapper compiles mappings and returns Mapper. Mappings are evaluated in the order.
+func NewMapper(mappings ...Mapping) (*Mapper, error) {
+	mapper := &Mapper{}
+	for _, m := range mappings {
+		compiled, err := compileMapping(m)
+		if err != nil {
+			return nil, golambda.WrapError(err, "Invalid mapping").With("name", m.Name)
+		}
+		mapper.mappings = append(mapper.mappings, compiled)
+	}
+	return mapper, nil
+}
+
+// LoadMapper parses mapping configuration in JSON, {"mappings": [...]}, and returns Mapper.
+func LoadMapper(data []byte) (*Mapper, error) {
+	var config struct {
+		Mappings []Mapping `json:"mappings"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, golambda.WrapError(err, "Fail to parse mapping configuration")
+	}
+
+	return NewMapper(config.Mappings...)
+}
+
+func compileMapping(m Mapping) (*compiledMapping, error) {
+	var err error
+	compiled := &compiledMapping{name: m.Name}
+
+	if m.Route != nil {
+		if compiled.routePath, err = parseJSONPath(m.Route.Path); err != nil {
+			return nil, err
+		}
+		compiled.routeValue = m.Route.Value
+	}
+
+	templates := []struct {
+		dst *(*fieldTemplate)
+		src string
+	}{
+		{&compiled.detector, m.Detector},
+		{&compiled.ruleID, m.RuleID},
+		{&compiled.ruleName, m.RuleName},
+		{&compiled.alertKey, m.AlertKey},
+		{&compiled.description, m.Description},
+	}
+	for _, t := range templates {
+		if *t.dst, err = parseTemplate(t.src); err != nil {
+			return nil, err
+		}
+	}
+
+	if m.Timestamp != nil {
+		if compiled.timePath, err = parseJSONPath(m.Timestamp.Path); err != nil {
+			return nil, err
+		}
+		compiled.timeFormat = m.Timestamp.Format
+	}
+
+	for _, attr := range m.Attributes {
+		if attr.Type == "" || attr.Key == "" {
+			return nil, golambda.NewError("Attribute mapping requires type and key").With("attr", attr)
+		}
+		if !deepalert.IsKnownAttrType(attr.Type) {
+			return nil, golambda.NewError("Unknown attribute type in mapping").With("attr", attr)
+		}
+		path, err := parseJSONPath(attr.Path)
+		if err != nil {
+			return nil, err
+		}
+		compiled.attributes = append(compiled.attributes, compiledAttr{
+			attrType: attr.Type,
+			key:      attr.Key,
+			path:     path,
+			context:  attr.Context,
+		})
+	}
+
+	if m.Body != "" {
+		if compiled.body, err = parseJSONPath(m.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	return compiled, nil
+}
+
+// Map converts JSON data to deepalert.Alert and returns it with name of the used mapping.
+// ErrNoMapping is returned if no mapping matches with the data.
+func (x *Mapper) Map(data []byte) (*deepalert.Alert, string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var root interface{}
+	if err := decoder.Decode(&root); err != nil {
+		return nil, "", golambda.WrapError(err, "Fail to unmarshal data").With("data", string(data))
+	}
+
+	for _, m := range x.mappings {
+		if !m.match(root) {
+			continue
+		}
+
+		alert, err := m.convert(root, data)
+		if err != nil {
+			return nil, m.name, err
+		}
+		return alert, m.name, nil
+	}
+
+	return nil, "", ErrNoMapping
+}
+
+// Decode converts data by Map and falls back to Decode function if no mapping matches. Mapper can be nil.
+// Name of the used mapping is empty if the data is converted by Decode function.
+func (x *Mapper) Decode(data []byte) ([]*deepalert.Alert, string, error) {
+	if x != nil {
+		alert, name, err := x.Map(data)
+		if err == nil {
+			return []*deepalert.Alert{alert}, name, nil
+		}
+		if !errors.Is(err, ErrNoMapping) {
+			return nil, name, err
+		}
+	}
+
+	alerts, err := Decode(data)
+	return alerts, "", err
+}
+
+func (x *compiledMapping) match(root interface{}) bool {
+	if x.routePath == nil {
+		return true
+	}
+	for _, v := range x.routePath.eval(root) {
+		if x.routeValue == "" || stringify(v) == x.routeValue {
+			return true
+		}
+	}
+	return false
+}
+
+func (x *compiledMapping) convert(root interface{}, data []byte) (*deepalert.Alert, error) {
+	alert := &deepalert.Alert{
+		Detector:    x.detector.render(root),
+		RuleID:      x.ruleID.render(root),
+		RuleName:    x.ruleName.render(root),
+		AlertKey:    x.alertKey.render(root),
+		Description: truncate(x.description.render(root), maxDescriptionLen),
+		Timestamp:   time.Now().UTC(),
+	}
+
+	if x.timePath != nil {
+		if values := x.timePath.eval(root); len(values) > 0 {
+			ts, err := parseTimestamp(values[0], x.timeFormat)
+			if err != nil {
+				return nil, golambda.WrapError(deepalert.ErrInvalidAlert, "Fail to parse timestamp:", err).With("mapping", x.name)
+			}
+			alert.Timestamp = ts
+		}
+	}
+
+	for _, attr := range x.attributes {
+		for _, v := range attr.path.eval(root) {
+			addAttr(alert, deepalert.Attribute{
+				Type:    attr.attrType,
+				Key:     attr.key,
+				Value:   stringify(v),
+				Context: attr.context,
+			})
+		}
+	}
+
+	if x.body == nil {
+		alert.Body = findingBody(data)
+	} else if values := x.body.eval(root); len(values) > 0 {
+		if raw, err := json.Marshal(values[0]); err == nil {
+			alert.Body = findingBody(raw)
+		}
+	}
+
+	if err := alert.Validate(); err != nil {
+		return nil, golambda.WrapError(err, "Mapped alert is invalid").With("mapping", x.name)
+	}
+
+	return alert, nil
+}
+
+func parseTimestamp(v interface{}, format string) (time.Time, error) {
+	s := stringify(v)
+	switch format = strings.ToLower(format); format {
+	case "", "rfc3339":
+		t, err := time.Parse(time.RFC3339Nano, s)
+		return t.UTC(), err
+
+	case "unix", "unixms":
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if format == "unixms" {
+			n /= 1000
+		}
+		sec, frac := math.Modf(n)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+
+	default:
+		t, err := time.Parse(format, s)
+		return t.UTC(), err
+	}
+}
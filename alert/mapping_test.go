@@ -0,0 +1,147 @@
+package alert_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/alert"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const falcoEvent = `{
+  "source": "syscall",
+  "rule": "Terminal shell in container",
+  "priority": "Notice",
+  "time": "2020-01-02T03:04:05.123456789Z",
+  "output": "A shell was spawned in a container",
+  "output_fields": {
+    "k8s.pod.name": "web-1",
+    "user.name": "root",
+    "fd.rip": ["192.0.2.1", "192.0.2.2"],
+    "proc.pid": 1234
+  }
+}`
+
+const mappingConfig = `{
+  "mappings": [
+    {
+      "name": "falco",
+      "route": {"path": "$.source", "value": "syscall"},
+      "detector": "falco",
+      "rule_id": "{{ $.rule }}",
+      "rule_name": "{{ $.rule }} ({{ $.priority }})",
+      "alert_key": "{{ $.output_fields['k8s.pod.name'] }}",
+      "description": "{{ $.output }}",
+      "timestamp": {"path": "$.time"},
+      "attributes": [
+        {"type": "username", "key": "user", "path": "$.output_fields['user.name']", "context": ["local", "subject"]},
+        {"type": "ipaddr", "key": "remote", "path": "$.output_fields['fd.rip'][*]", "context": ["remote"]},
+        {"type": "json", "key": "pid", "path": "$.output_fields['proc.pid']"}
+      ],
+      "body": "$.output_fields"
+    },
+    {
+      "name": "okta",
+      "route": {"path": "$.eventType"},
+      "detector": "okta",
+      "rule_id": "{{ $.eventType }}",
+      "alert_key": "{{ $.actor.alternateId }}",
+      "timestamp": {"path": "$.published", "format": "unixms"},
+      "attributes": [
+        {"type": "ipaddr", "key": "client", "path": "$.client.ipAddress", "context": ["remote", "client"]}
+      ]
+    }
+  ]
+}`
+
+func TestMapper(t *testing.T) {
+	mapper, err := alert.LoadMapper([]byte(mappingConfig))
+	require.NoError(t, err)
+
+	t.Run("falco event", func(t *testing.T) {
+		a, name, err := mapper.Map([]byte(falcoEvent))
+		require.NoError(t, err)
+		assert.Equal(t, "falco", name)
+		assert.Equal(t, "falco", a.Detector)
+		assert.Equal(t, "Terminal shell in container", a.RuleID)
+		assert.Equal(t, "Terminal shell in container (Notice)", a.RuleName)
+		assert.Equal(t, "web-1", a.AlertKey)
+		assert.Equal(t, "A shell was spawned in a container", a.Description)
+		assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 123456789, time.UTC), a.Timestamp)
+
+		require.Equal(t, 4, len(a.Attributes))
+		assert.Equal(t, deepalert.Attribute{
+			Type: deepalert.TypeUserName, Key: "user", Value: "root",
+			Context: deepalert.AttrContexts{deepalert.CtxLocal, deepalert.CtxSubject},
+		}, a.Attributes[0])
+		assert.Equal(t, "192.0.2.1", a.Attributes[1].Value)
+		assert.Equal(t, "192.0.2.2", a.Attributes[2].Value)
+		assert.Equal(t, "1234", a.Attributes[3].Value)
+
+		body, ok := a.Body.(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, "web-1", body["k8s.pod.name"])
+	})
+
+	t.Run("route by existence of field", func(t *testing.T) {
+		a, name, err := mapper.Map([]byte(`{"eventType":"user.session.start","published":1577934245500,"actor":{"alternateId":"blue@example.com"},"client":{"ipAddress":"198.51.100.1"}}`))
+		require.NoError(t, err)
+		assert.Equal(t, "okta", name)
+		assert.Equal(t, "user.session.start", a.RuleID)
+		assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 500000000, time.UTC), a.Timestamp)
+		require.Equal(t, 1, len(a.Attributes))
+		assert.Equal(t, "198.51.100.1", a.Attributes[0].Value)
+	})
+
+	t.Run("no mapping", func(t *testing.T) {
+		_, _, err := mapper.Map([]byte(`{"source":"k8s_audit"}`))
+		assert.ErrorIs(t, err, alert.ErrNoMapping)
+	})
+
+	t.Run("invalid mapped alert", func(t *testing.T) {
+		_, name, err := mapper.Map([]byte(`{"source":"syscall"}`))
+		assert.Equal(t, "falco", name)
+		assert.ErrorIs(t, err, deepalert.ErrInvalidAlert)
+	})
+
+	t.Run("invalid timestamp", func(t *testing.T) {
+		_, _, err := mapper.Map([]byte(`{"source":"syscall","rule":"r1","time":"yesterday"}`))
+		assert.ErrorIs(t, err, deepalert.ErrInvalidAlert)
+	})
+
+	t.Run("fall back to Decode", func(t *testing.T) {
+		alerts, name, err := mapper.Decode([]byte(`{"detector":"blue","rule_id":"r1"}`))
+		require.NoError(t, err)
+		assert.Equal(t, "", name)
+		require.Equal(t, 1, len(alerts))
+		assert.Equal(t, "blue", alerts[0].Detector)
+
+		var nilMapper *alert.Mapper
+		alerts, _, err = nilMapper.Decode([]byte(`{"detector":"blue","rule_id":"r1"}`))
+		require.NoError(t, err)
+		require.Equal(t, 1, len(alerts))
+	})
+}
+
+func TestMapperConfig(t *testing.T) {
+	testCases := map[string]alert.Mapping{
+		"path without $":        {Name: "a", RuleID: "{{ rule }}"},
+		"unclosed placeholder":  {Name: "a", RuleID: "{{ $.rule"},
+		"unclosed bracket":      {Name: "a", RuleID: "{{ $.a['b' }}"},
+		"invalid index":         {Name: "a", RuleID: "{{ $.a[x] }}"},
+		"attribute without key": {Name: "a", Attributes: []alert.AttrMapping{{Type: deepalert.TypeIPAddr, Path: "$.a"}}},
+		"invalid route":         {Name: "a", Route: &alert.Route{Path: "a.b"}},
+	}
+
+	for title, mapping := range testCases {
+		t.Run(title, func(t *testing.T) {
+			_, err := alert.NewMapper(mapping)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := alert.LoadMapper([]byte(`{"mappings":[{"name":"a","unknown":1}]}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,127 @@
+package alert
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/cookpad/deepalert"
+	"github.com/m-mizutani/golambda"
+)
+
+// DetectorSecurityHub is Detector of alerts converted from Security Hub findings.
+const DetectorSecurityHub = "securityhub"
+
+// SecurityHubFinding is a part of AWS Security Finding Format (ASFF) that is required to build an alert.
+// See https://docs.aws.amazon.com/securityhub/latest/userguide/securityhub-findings-format.html
+type SecurityHubFinding struct {
+	SchemaVersion  string   `json:"SchemaVersion"`
+	ID             string   `json:"Id"`
+	ProductArn     string   `json:"ProductArn"`
+	ProductName    string   `json:"ProductName"`
+	GeneratorID    string   `json:"GeneratorId"`
+	AwsAccountID   string   `json:"AwsAccountId"`
+	Types          []string `json:"Types"`
+	Title          string   `json:"Title"`
+	Description    string   `json:"Description"`
+	CreatedAt      string   `json:"CreatedAt"`
+	UpdatedAt      string   `json:"UpdatedAt"`
+	LastObservedAt string   `json:"LastObservedAt"`
+
+	Network *struct {
+		Direction         string `json:"Direction"`
+		SourceIPV4        string `json:"SourceIpV4"`
+		SourceDomain      string `json:"SourceDomain"`
+		DestinationIPV4   string `json:"DestinationIpV4"`
+		DestinationDomain string `json:"DestinationDomain"`
+	} `json:"Network"`
+
+	Resources []struct {
+		Type    string `json:"Type"`
+		ID      string `json:"Id"`
+		Details struct {
+			AwsEc2Instance *struct {
+				IPV4Addresses []string `json:"IpV4Addresses"`
+			} `json:"AwsEc2Instance"`
+			AwsIamAccessKey *struct {
+				PrincipalName string `json:"PrincipalName"`
+				UserName      string `json:"UserName"`
+			} `json:"AwsIamAccessKey"`
+			AwsIamUser *struct {
+				UserName string `json:"UserName"`
+			} `json:"AwsIamUser"`
+			AwsIamRole *struct {
+				RoleName string `json:"RoleName"`
+			} `json:"AwsIamRole"`
+		} `json:"Details"`
+	} `json:"Resources"`
+}
+
+func isSecurityHubFinding(data []byte) bool {
+	var v struct {
+		SchemaVersion string `json:"SchemaVersion"`
+		ProductArn    string `json:"ProductArn"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return false
+	}
+	return v.SchemaVersion != "" && v.ProductArn != ""
+}
+
+// FromSecurityHub converts a finding of AWS Security Finding Format (ASFF) in JSON to deepalert.Alert.
+// RuleID is GeneratorId and AlertKey is Id of the finding.
+func FromSecurityHub(data []byte) (*deepalert.Alert, error) {
+	var finding SecurityHubFinding
+	if err := json.Unmarshal(data, &finding); err != nil {
+		return nil, golambda.WrapError(err, "Fail to unmarshal Security Hub finding")
+	}
+	if finding.ID == "" || finding.GeneratorID == "" {
+		return nil, golambda.WrapError(deepalert.ErrInvalidAlert, "Security Hub finding requires Id and GeneratorId")
+	}
+
+	alert := &deepalert.Alert{
+		Detector:    DetectorSecurityHub,
+		RuleID:      finding.GeneratorID,
+		RuleName:    finding.Title,
+		AlertKey:    finding.ID,
+		Description: truncate(finding.Description, maxDescriptionLen),
+		Timestamp:   parseFindingTime(finding.LastObservedAt, finding.UpdatedAt, finding.CreatedAt),
+		Body:        findingBody(data),
+	}
+
+	if nw := finding.Network; nw != nil {
+		// Direction is from viewpoint of the resource. IN means the source is remote host.
+		remoteIP, remoteDomain, localIP := nw.DestinationIPV4, nw.DestinationDomain, nw.SourceIPV4
+		remoteCtx, localCtx := deepalert.CtxServer, deepalert.CtxClient
+		if strings.EqualFold(nw.Direction, "IN") {
+			remoteIP, remoteDomain, localIP = nw.SourceIPV4, nw.SourceDomain, nw.DestinationIPV4
+			remoteCtx, localCtx = deepalert.CtxClient, deepalert.CtxServer
+		}
+		addAttr(alert, IPAddr("remote_ip", remoteIP, deepalert.CtxRemote, remoteCtx))
+		addAttr(alert, Domain("remote_domain", remoteDomain, deepalert.CtxRemote, remoteCtx))
+		addAttr(alert, IPAddr("local_ip", localIP, deepalert.CtxLocal, localCtx))
+	}
+
+	for _, resource := range finding.Resources {
+		details := resource.Details
+		if inst := details.AwsEc2Instance; inst != nil {
+			for _, addr := range inst.IPV4Addresses {
+				addAttr(alert, IPAddr("instance_ip", addr, deepalert.CtxLocal))
+			}
+		}
+		if key := details.AwsIamAccessKey; key != nil {
+			name := key.PrincipalName
+			if name == "" {
+				name = key.UserName
+			}
+			addAttr(alert, User("iam_principal", name, deepalert.CtxLocal, deepalert.CtxSubject))
+		}
+		if user := details.AwsIamUser; user != nil {
+			addAttr(alert, User("iam_principal", user.UserName, deepalert.CtxLocal, deepalert.CtxSubject))
+		}
+		if role := details.AwsIamRole; role != nil {
+			addAttr(alert, User("iam_principal", role.RoleName, deepalert.CtxLocal, deepalert.CtxSubject))
+		}
+	}
+
+	return alert, nil
+}
@@ -0,0 +1,154 @@
+package syslog
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/m-mizutani/golambda"
+)
+
+// splitHeader splits s by unescaped '|' into n fields and the rest. Escaped '|' and '\' in header are unescaped.
+func splitHeader(s string, n int) ([]string, string, bool) {
+	var fields []string
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s) && (s[i+1] == '|' || s[i+1] == '\\'):
+			b.WriteByte(s[i+1])
+			i++
+		case c == '|':
+			fields = append(fields, b.String())
+			b.Reset()
+			if len(fields) == n {
+				return fields, s[i+1:], true
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return fields, b.String(), false
+}
+
+// parseCEF parses "Version|Device Vendor|Device Product|Device Version|Signature ID|Name|Severity|Extension"
+func parseCEF(s string) (*Record, error) {
+	header, ext, ok := splitHeader(s, 7)
+	if !ok {
+		return nil, golambda.WrapError(ErrMalformedRecord, "CEF header must have 7 fields").With("record", s)
+	}
+
+	return &Record{
+		Format:        formatCEF,
+		Version:       header[0],
+		DeviceVendor:  header[1],
+		DeviceProduct: header[2],
+		DeviceVersion: header[3],
+		EventID:       header[4],
+		Name:          header[5],
+		Severity:      header[6],
+		Extension:     parseCEFExtension(ext),
+	}, nil
+}
+
+// parseCEFExtension parses space separated key=value pairs. Value can have spaces, and '=', '\' and newline are escaped.
+func parseCEFExtension(s string) map[string]string {
+	ext := map[string]string{}
+	s = strings.TrimSpace(s)
+
+	// Find start positions of keys. A key follows beginning of string or a space and is followed by unescaped '='.
+	type keyPos struct {
+		key        string
+		start, end int // start of key and end of '='
+	}
+	var keys []keyPos
+	for i := 0; i < len(s); i++ {
+		if s[i] != '=' || (i > 0 && s[i-1] == '\\') {
+			continue
+		}
+		j := i
+		for j > 0 && isKeyChar(s[j-1]) {
+			j--
+		}
+		if j == i || (j > 0 && s[j-1] != ' ') {
+			continue
+		}
+		keys = append(keys, keyPos{key: s[j:i], start: j, end: i + 1})
+	}
+
+	for idx, k := range keys {
+		end := len(s)
+		if idx+1 < len(keys) {
+			end = keys[idx+1].start
+		}
+		ext[k.key] = unescapeCEFValue(strings.TrimRight(s[k.end:end], " "))
+	}
+
+	return ext
+}
+
+func isKeyChar(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || c == '_' || c == '.' || c == '-'
+}
+
+func unescapeCEFValue(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	return strings.NewReplacer(`\=`, "=", `\\`, `\`, `\n`, "\n", `\r`, "\r").Replace(s)
+}
+
+// parseLEEF parses LEEF 1.0 "1.0|Vendor|Product|Version|EventID|Extension" and
+// LEEF 2.0 "2.0|Vendor|Product|Version|EventID|DelimiterCharacter|Extension".
+// Delimiter of LEEF 1.0 is tab. Delimiter of LEEF 2.0 is a character or hex like "x5E" or "0x5E".
+func parseLEEF(s string) (*Record, error) {
+	header, ext, ok := splitHeader(s, 5)
+	if !ok {
+		return nil, golambda.WrapError(ErrMalformedRecord, "LEEF header must have 5 fields").With("record", s)
+	}
+
+	delimiter := "\t"
+	if strings.HasPrefix(header[0], "2") {
+		pos := strings.IndexByte(ext, '|')
+		if pos < 0 {
+			return nil, golambda.WrapError(ErrMalformedRecord, "LEEF 2.0 header must have delimiter field").With("record", s)
+		}
+		if d := parseLEEFDelimiter(ext[:pos]); d != "" {
+			delimiter = d
+		}
+		ext = ext[pos+1:]
+	}
+
+	record := &Record{
+		Format:        formatLEEF,
+		Version:       header[0],
+		DeviceVendor:  header[1],
+		DeviceProduct: header[2],
+		DeviceVersion: header[3],
+		EventID:       header[4],
+		Extension:     map[string]string{},
+	}
+
+	for _, pair := range strings.Split(ext, delimiter) {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			continue
+		}
+		record.Extension[strings.TrimSpace(kv[0])] = kv[1]
+	}
+	record.Severity = record.Extension["sev"]
+
+	return record, nil
+}
+
+func parseLEEFDelimiter(s string) string {
+	lower := strings.ToLower(s)
+	if strings.HasPrefix(lower, "0x") || (strings.HasPrefix(lower, "x") && len(s) > 1) {
+		hex := strings.TrimPrefix(strings.TrimPrefix(lower, "0"), "x")
+		if n, err := strconv.ParseUint(hex, 16, 8); err == nil {
+			return string(rune(n))
+		}
+	}
+	if s == `\t` {
+		return "\t"
+	}
+	return s
+}
@@ -0,0 +1,197 @@
+// Package syslog converts CEF (Common Event Format) and LEEF (Log Event Extended Format) records in syslog to deepalert.Alert.
+package syslog
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cookpad/deepalert"
+	"github.com/m-mizutani/golambda"
+)
+
+// Logger is github.com/m-mizutani/golambda logger and exported to be controlled from external module.
+var Logger = golambda.Logger
+
+var (
+	// ErrUnsupportedFormat means the line has neither CEF nor LEEF record.
+	ErrUnsupportedFormat = golambda.NewError("Neither CEF nor LEEF record")
+
+	// ErrMalformedRecord means the record does not have required header fields.
+	ErrMalformedRecord = golambda.NewError("Malformed CEF or LEEF record")
+)
+
+// Record is a parsed CEF or LEEF record.
+type Record struct {
+	Format        string            `json:"format"`
+	Version       string            `json:"version"`
+	DeviceVendor  string            `json:"device_vendor"`
+	DeviceProduct string            `json:"device_product"`
+	DeviceVersion string            `json:"device_version"`
+	EventID       string            `json:"event_id"`
+	Name          string            `json:"name,omitempty"`
+	Severity      string            `json:"severity,omitempty"`
+	Extension     map[string]string `json:"extension"`
+}
+
+const (
+	formatCEF  = "CEF"
+	formatLEEF = "LEEF"
+)
+
+// attrField describes how an extension field is converted to an attribute.
+type attrField struct {
+	attrType deepalert.AttrType
+	contexts []deepalert.AttrContext
+}
+
+// attrFields is mapping of standard field names of CEF and LEEF. Source of an event is the client and subject,
+// and destination is the server and object.
+var attrFields = map[string]attrField{
+	// CEF
+	"src":         {deepalert.TypeIPAddr, []deepalert.AttrContext{deepalert.CtxSubject, deepalert.CtxClient}},
+	"dst":         {deepalert.TypeIPAddr, []deepalert.AttrContext{deepalert.CtxObject, deepalert.CtxServer}},
+	"shost":       {deepalert.TypeDomainName, []deepalert.AttrContext{deepalert.CtxSubject, deepalert.CtxClient}},
+	"dhost":       {deepalert.TypeDomainName, []deepalert.AttrContext{deepalert.CtxObject, deepalert.CtxServer}},
+	"suser":       {deepalert.TypeUserName, []deepalert.AttrContext{deepalert.CtxSubject}},
+	"duser":       {deepalert.TypeUserName, []deepalert.AttrContext{deepalert.CtxObject}},
+	"request":     {deepalert.TypeURL, []deepalert.AttrContext{deepalert.CtxObject}},
+	"fileHash":    {deepalert.TypeFileHashValue, []deepalert.AttrContext{deepalert.CtxFile}},
+	"oldFileHash": {deepalert.TypeFileHashValue, []deepalert.AttrContext{deepalert.CtxFile}},
+
+	// LEEF
+	"usrName":       {deepalert.TypeUserName, []deepalert.AttrContext{deepalert.CtxSubject}},
+	"identSrc":      {deepalert.TypeIPAddr, []deepalert.AttrContext{deepalert.CtxSubject, deepalert.CtxClient}},
+	"identHostName": {deepalert.TypeDomainName, []deepalert.AttrContext{deepalert.CtxSubject, deepalert.CtxClient}},
+	"url":           {deepalert.TypeURL, []deepalert.AttrContext{deepalert.CtxObject}},
+}
+
+// attrFieldOrder fixes order of attributes in alert.
+var attrFieldOrder = []string{
+	"src", "identSrc", "shost", "identHostName", "suser", "usrName",
+	"dst", "dhost", "duser", "request", "url", "fileHash", "oldFileHash",
+}
+
+// Parse finds CEF or LEEF record in a syslog line and parses it. Syslog header before the record is ignored.
+func Parse(line string) (*Record, error) {
+	cef := strings.Index(line, "CEF:")
+	leef := strings.Index(line, "LEEF:")
+
+	switch {
+	case cef >= 0 && (leef < 0 || cef < leef):
+		return parseCEF(line[cef+len("CEF:"):])
+	case leef >= 0:
+		return parseLEEF(line[leef+len("LEEF:"):])
+	default:
+		return nil, golambda.WrapError(ErrUnsupportedFormat, "CEF: or LEEF: is not found").With("line", line)
+	}
+}
+
+// ParseAlert parses a syslog line and converts the record to deepalert.Alert. Empty line returns nil alert without error.
+func ParseAlert(line string) (*deepalert.Alert, error) {
+	if strings.TrimSpace(line) == "" {
+		return nil, nil
+	}
+
+	record, err := Parse(line)
+	if err != nil {
+		return nil, err
+	}
+
+	return record.Alert(), nil
+}
+
+// Alert converts the record to deepalert.Alert. Detector is "<vendor>/<product>", RuleID is signature ID (CEF) or
+// event ID (LEEF) and AlertKey is source address of the event.
+func (x *Record) Alert() *deepalert.Alert {
+	alert := &deepalert.Alert{
+		Detector:    x.DeviceVendor + "/" + x.DeviceProduct,
+		RuleID:      x.EventID,
+		RuleName:    x.Name,
+		AlertKey:    x.Extension["src"],
+		Description: x.Extension["msg"],
+		Timestamp:   x.timestamp(),
+		Body:        x,
+	}
+	if alert.RuleName == "" {
+		alert.RuleName = x.Extension["cat"]
+	}
+
+	for _, key := range attrFieldOrder {
+		value := x.Extension[key]
+		if value == "" {
+			continue
+		}
+		field := attrFields[key]
+		attr := deepalert.Attribute{
+			Type:    field.attrType,
+			Key:     key,
+			Value:   value,
+			Context: append(deepalert.AttrContexts{}, field.contexts...),
+		}
+		// Devices may put a host name into src or dst, and such value is dropped
+		if err := attr.Validate(); err != nil {
+			Logger.With("attr", attr).With("err", err).Warn("Dropped attribute that does not match the type")
+			continue
+		}
+		alert.AddAttribute(attr)
+	}
+
+	return alert
+}
+
+// cefTimeLayouts are formats of rt and end fields of CEF other than milliseconds since epoch.
+var cefTimeLayouts = []string{
+	"Jan 02 2006 15:04:05.000 MST",
+	"Jan 02 2006 15:04:05.000",
+	"Jan 02 2006 15:04:05 MST",
+	"Jan 02 2006 15:04:05",
+	time.RFC3339Nano,
+}
+
+func (x *Record) timestamp() time.Time {
+	if x.Format == formatLEEF {
+		if v := x.Extension["devTime"]; v != "" {
+			if layout := x.Extension["devTimeFormat"]; layout != "" {
+				if t, err := parseJavaTime(layout, v); err == nil {
+					return t.UTC()
+				}
+			}
+			if t, ok := parseCEFTime(v); ok {
+				return t
+			}
+		}
+		return time.Now().UTC()
+	}
+
+	for _, key := range []string{"rt", "end", "start"} {
+		if t, ok := parseCEFTime(x.Extension[key]); ok {
+			return t
+		}
+	}
+	return time.Now().UTC()
+}
+
+func parseCEFTime(v string) (time.Time, bool) {
+	if v == "" {
+		return time.Time{}, false
+	}
+	if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(0, ms*int64(time.Millisecond)).UTC(), true
+	}
+	for _, layout := range cefTimeLayouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseJavaTime supports common patterns of Java SimpleDateFormat that are used as devTimeFormat of LEEF.
+func parseJavaTime(layout, value string) (time.Time, error) {
+	replacer := strings.NewReplacer(
+		"yyyy", "2006", "MMM", "Jan", "MM", "01", "dd", "02",
+		"HH", "15", "mm", "04", "ss", "05", "SSS", "000", "z", "MST", "Z", "-0700",
+	)
+	return time.Parse(replacer.Replace(layout), value)
+}
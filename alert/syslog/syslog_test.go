@@ -0,0 +1,114 @@
+package syslog_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/alert/syslog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCEF(t *testing.T) {
+	line := `<134>Jan 02 03:04:05 ids01 CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|` +
+		`src=10.0.0.1 dst=2.1.2.2 spt=1232 suser=blue msg=Detected a threat. No action needed\=true rt=1577934245000 ` +
+		`fileHash=d41d8cd98f00b204e9800998ecf8427e request=https://example.com/a b`
+
+	record, err := syslog.Parse(line)
+	require.NoError(t, err)
+	assert.Equal(t, "CEF", record.Format)
+	assert.Equal(t, "Security", record.DeviceVendor)
+	assert.Equal(t, "threatmanager", record.DeviceProduct)
+	assert.Equal(t, "100", record.EventID)
+	assert.Equal(t, "10", record.Severity)
+	assert.Equal(t, "1232", record.Extension["spt"])
+	assert.Equal(t, "Detected a threat. No action needed=true", record.Extension["msg"])
+	assert.Equal(t, "https://example.com/a b", record.Extension["request"])
+
+	alert, err := syslog.ParseAlert(line)
+	require.NoError(t, err)
+	require.NoError(t, alert.Validate())
+	assert.Equal(t, "Security/threatmanager", alert.Detector)
+	assert.Equal(t, "100", alert.RuleID)
+	assert.Equal(t, "worm successfully stopped", alert.RuleName)
+	assert.Equal(t, "10.0.0.1", alert.AlertKey)
+	assert.Equal(t, "Detected a threat. No action needed=true", alert.Description)
+	assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), alert.Timestamp)
+
+	require.Equal(t, 5, len(alert.Attributes))
+	src := alert.FindAttributes("src")[0]
+	assert.True(t, src.Match(deepalert.CtxSubject, deepalert.TypeIPAddr))
+	assert.True(t, src.Match(deepalert.CtxClient, deepalert.TypeIPAddr))
+	dst := alert.FindAttributes("dst")[0]
+	assert.True(t, dst.Match(deepalert.CtxObject, deepalert.TypeIPAddr))
+	assert.True(t, dst.Match(deepalert.CtxServer, deepalert.TypeIPAddr))
+	user := alert.FindAttributes("suser")[0]
+	assert.True(t, user.Match(deepalert.CtxSubject, deepalert.TypeUserName))
+	hash := alert.FindAttributes("fileHash")[0]
+	assert.True(t, hash.Match(deepalert.CtxFile, deepalert.TypeFileHashValue))
+	assert.Equal(t, deepalert.TypeURL, alert.FindAttributes("request")[0].Type)
+}
+
+func TestParseCEFInvalidAttribute(t *testing.T) {
+	alert, err := syslog.ParseAlert(`CEF:0|Vendor|Product|1.0|sig|name|5|src=ws01.corp dst=192.0.2.1`)
+	require.NoError(t, err)
+	require.NoError(t, alert.Validate())
+	assert.Equal(t, 0, len(alert.FindAttributes("src")))
+	assert.Equal(t, 1, len(alert.FindAttributes("dst")))
+}
+
+func TestParseCEFHeaderEscape(t *testing.T) {
+	record, err := syslog.Parse(`CEF:0|Vendor\|A|Product\\B|1.0|sig|name|5|src=192.0.2.1`)
+	require.NoError(t, err)
+	assert.Equal(t, `Vendor|A`, record.DeviceVendor)
+	assert.Equal(t, `Product\B`, record.DeviceProduct)
+	assert.Equal(t, "192.0.2.1", record.Extension["src"])
+}
+
+func TestParseLEEF(t *testing.T) {
+	t.Run("LEEF 1.0 with tab delimiter", func(t *testing.T) {
+		line := "Jan 02 03:04:05 fw01 LEEF:1.0|Vendor|Firewall|2.0|deny|cat=Blocked\tsrc=198.51.100.1\tdst=10.0.0.2\t" +
+			"usrName=blue\tsev=7\tdevTime=2020-01-02 03:04:05\tdevTimeFormat=yyyy-MM-dd HH:mm:ss"
+
+		alert, err := syslog.ParseAlert(line)
+		require.NoError(t, err)
+		require.NoError(t, alert.Validate())
+		assert.Equal(t, "Vendor/Firewall", alert.Detector)
+		assert.Equal(t, "deny", alert.RuleID)
+		assert.Equal(t, "Blocked", alert.RuleName)
+		assert.Equal(t, "198.51.100.1", alert.AlertKey)
+		assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), alert.Timestamp)
+
+		require.Equal(t, 3, len(alert.Attributes))
+		user := alert.FindAttributes("usrName")[0]
+		assert.True(t, user.Match(deepalert.CtxSubject, deepalert.TypeUserName))
+		assert.Equal(t, "7", alert.Body.(*syslog.Record).Severity)
+	})
+
+	t.Run("LEEF 2.0 with hex delimiter", func(t *testing.T) {
+		record, err := syslog.Parse("LEEF:2.0|Vendor|IDS|1.0|scan|x5E|src=192.0.2.1^dst=10.0.0.3^url=http://example.com/?a=b")
+		require.NoError(t, err)
+		assert.Equal(t, "192.0.2.1", record.Extension["src"])
+		assert.Equal(t, "10.0.0.3", record.Extension["dst"])
+		assert.Equal(t, "http://example.com/?a=b", record.Extension["url"])
+	})
+
+	t.Run("LEEF 2.0 with character delimiter", func(t *testing.T) {
+		record, err := syslog.Parse("LEEF:2.0|Vendor|IDS|1.0|scan|^|src=192.0.2.1^dst=10.0.0.3")
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.3", record.Extension["dst"])
+	})
+}
+
+func TestParseError(t *testing.T) {
+	_, err := syslog.Parse("Jan 02 03:04:05 host sshd[123]: Accepted publickey for blue")
+	assert.ErrorIs(t, err, syslog.ErrUnsupportedFormat)
+
+	_, err = syslog.Parse("CEF:0|Vendor|Product|1.0")
+	assert.ErrorIs(t, err, syslog.ErrMalformedRecord)
+
+	alert, err := syslog.ParseAlert("  ")
+	assert.NoError(t, err)
+	assert.Nil(t, alert)
+}
@@ -0,0 +1,50 @@
+package alert
+
+import (
+	"strings"
+
+	"github.com/m-mizutani/golambda"
+)
+
+// fieldTemplate is a string with JSONPath placeholders like "{{ $.rule }}:{{ $.host }}".
+// A placeholder is replaced with the first matched value and empty string if nothing is matched.
+type fieldTemplate struct {
+	literals []string
+	paths    []jsonPath
+}
+
+func parseTemplate(s string) (*fieldTemplate, error) {
+	tmpl := &fieldTemplate{}
+	for {
+		start := strings.Index(s, "{{")
+		if start < 0 {
+			tmpl.literals = append(tmpl.literals, s)
+			return tmpl, nil
+		}
+		end := strings.Index(s[start:], "}}")
+		if end < 0 {
+			return nil, golambda.NewError("Unclosed placeholder in template").With("template", s)
+		}
+
+		path, err := parseJSONPath(s[start+2 : start+end])
+		if err != nil {
+			return nil, err
+		}
+		tmpl.literals = append(tmpl.literals, s[:start])
+		tmpl.paths = append(tmpl.paths, path)
+		s = s[start+end+2:]
+	}
+}
+
+func (x *fieldTemplate) render(root interface{}) string {
+	var b strings.Builder
+	for i, literal := range x.literals {
+		b.WriteString(literal)
+		if i < len(x.paths) {
+			if values := x.paths[i].eval(root); len(values) > 0 {
+				b.WriteString(stringify(values[0]))
+			}
+		}
+	}
+	return b.String()
+}
@@ -89,13 +89,13 @@ func TestValidate(t *testing.T) {
 
 	t.Run("Attribute.Key at limit passes", func(t *testing.T) {
 		a := base
-		a.Attributes = []da.Attribute{{Key: strings.Repeat("k", 1024), Value: "v"}}
+		a.Attributes = []da.Attribute{{Type: da.TypeUserName, Key: strings.Repeat("k", 1024), Value: "v"}}
 		require.NoError(t, a.Validate())
 	})
 
 	t.Run("Attribute.Key over limit is rejected", func(t *testing.T) {
 		a := base
-		a.Attributes = []da.Attribute{{Key: strings.Repeat("k", 1025), Value: "v"}}
+		a.Attributes = []da.Attribute{{Type: da.TypeUserName, Key: strings.Repeat("k", 1025), Value: "v"}}
 		err := a.Validate()
 		require.Error(t, err)
 		assert.ErrorIs(t, err, da.ErrInvalidAlert)
@@ -103,13 +103,13 @@ func TestValidate(t *testing.T) {
 
 	t.Run("Attribute.Value at limit passes", func(t *testing.T) {
 		a := base
-		a.Attributes = []da.Attribute{{Key: "k", Value: strings.Repeat("v", 1024)}}
+		a.Attributes = []da.Attribute{{Type: da.TypeUserName, Key: "k", Value: strings.Repeat("v", 1024)}}
 		require.NoError(t, a.Validate())
 	})
 
 	t.Run("Attribute.Value over limit is rejected", func(t *testing.T) {
 		a := base
-		a.Attributes = []da.Attribute{{Key: "k", Value: strings.Repeat("v", 1025)}}
+		a.Attributes = []da.Attribute{{Type: da.TypeUserName, Key: "k", Value: strings.Repeat("v", 1025)}}
 		err := a.Validate()
 		require.Error(t, err)
 		assert.ErrorIs(t, err, da.ErrInvalidAlert)
@@ -118,7 +118,7 @@ func TestValidate(t *testing.T) {
 	t.Run("Attributes at count limit passes", func(t *testing.T) {
 		a := base
 		for i := 0; i < 100; i++ {
-			a.Attributes = append(a.Attributes, da.Attribute{Key: "k", Value: "v"})
+			a.Attributes = append(a.Attributes, da.Attribute{Type: da.TypeUserName, Key: "k", Value: "v"})
 		}
 		require.NoError(t, a.Validate())
 	})
@@ -126,7 +126,7 @@ func TestValidate(t *testing.T) {
 	t.Run("Attributes over count limit is rejected", func(t *testing.T) {
 		a := base
 		for i := 0; i < 101; i++ {
-			a.Attributes = append(a.Attributes, da.Attribute{Key: "k", Value: "v"})
+			a.Attributes = append(a.Attributes, da.Attribute{Type: da.TypeUserName, Key: "k", Value: "v"})
 		}
 		err := a.Validate()
 		require.Error(t, err)
@@ -0,0 +1,209 @@
+package deepalert
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/m-mizutani/golambda"
+)
+
+var customAttrTypes = struct {
+	sync.RWMutex
+	prefixes []string
+}{}
+
+// RegisterCustomAttrTypePrefix allows AttrType that starts with the prefix (e.g. "acme.") in Alert.Validate.
+// Values of custom types are checked only by length. Built-in types can not be overridden by custom type.
+func RegisterCustomAttrTypePrefix(prefix string) error {
+	if prefix == "" {
+		return golambda.NewError("Custom attribute type prefix must not be empty")
+	}
+	if IsBuiltinAttrType(AttrType(prefix)) {
+		return golambda.NewError("Custom attribute type prefix conflicts with built-in type").With("prefix", prefix)
+	}
+
+	customAttrTypes.Lock()
+	defer customAttrTypes.Unlock()
+	for _, p := range customAttrTypes.prefixes {
+		if p == prefix {
+			return nil
+		}
+	}
+	customAttrTypes.prefixes = append(customAttrTypes.prefixes, prefix)
+	return nil
+}
+
+// ResetCustomAttrTypePrefixes removes all registered prefixes. It's mainly for testing.
+func ResetCustomAttrTypePrefixes() {
+	customAttrTypes.Lock()
+	defer customAttrTypes.Unlock()
+	customAttrTypes.prefixes = nil
+}
+
+// IsCustomAttrType returns true if attrType starts with registered prefix.
+func IsCustomAttrType(attrType AttrType) bool {
+	customAttrTypes.RLock()
+	defer customAttrTypes.RUnlock()
+	for _, p := range customAttrTypes.prefixes {
+		if strings.HasPrefix(string(attrType), p) && len(attrType) > len(p) {
+			return true
+		}
+	}
+	return false
+}
+
+type attrValueValidator func(value string) bool
+
+var attrValueValidators = map[AttrType]attrValueValidator{
+	TypeIPAddr:          validIPAddr,
+	TypeDomainName:      validHostName,
+	TypeUserName:        validText,
+	TypeFileHashValue:   validHashValue,
+	TypeJSON:            func(v string) bool { return json.Valid([]byte(v)) },
+	TypeURL:             validURL,
+	TypeEmailAddress:    validEmailAddress,
+	TypeHostName:        validHostName,
+	TypeProcess:         validText,
+	TypeCloudResource:   validCloudResource,
+	TypeFilePath:        func(v string) bool { return v != "" && !strings.ContainsRune(v, 0) },
+	TypeMACAddr:         func(v string) bool { _, err := net.ParseMAC(v); return err == nil },
+	TypeCIDR:            func(v string) bool { _, _, err := net.ParseCIDR(v); return err == nil },
+	TypePort:            validPort,
+	TypeCertFingerprint: validCertFingerprint,
+}
+
+// IsBuiltinAttrType returns true if attrType is defined by deepalert package.
+func IsBuiltinAttrType(attrType AttrType) bool {
+	_, ok := attrValueValidators[attrType]
+	return ok
+}
+
+// IsKnownAttrType returns true if attrType is built-in or has registered custom type prefix.
+func IsKnownAttrType(attrType AttrType) bool {
+	return IsBuiltinAttrType(attrType) || IsCustomAttrType(attrType)
+}
+
+// Validate checks length of Key and Value, and Value against Type. Type must be built-in or have registered
+// custom type prefix.
+func (x *Attribute) Validate() error {
+	if len(x.Key) > maxFieldLen {
+		return golambda.WrapError(ErrInvalidAlert, "Attribute.Key exceeds maximum length")
+	}
+	if len(x.Value) > maxFieldLen {
+		return golambda.WrapError(ErrInvalidAlert, "Attribute.Value exceeds maximum length")
+	}
+
+	validate, ok := attrValueValidators[x.Type]
+	if !ok {
+		if IsCustomAttrType(x.Type) {
+			return nil
+		}
+		return golambda.WrapError(ErrInvalidAlert, "Unknown Attribute.Type").With("type", x.Type)
+	}
+
+	if !validate(x.Value) {
+		return golambda.WrapError(ErrInvalidAlert, "Attribute.Value does not match Attribute.Type").
+			With("type", x.Type).With("value", x.Value)
+	}
+	return nil
+}
+
+func validText(v string) bool {
+	if strings.TrimSpace(v) == "" {
+		return false
+	}
+	for _, c := range v {
+		if unicode.IsControl(c) && c != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+func validIPAddr(v string) bool {
+	// IPv6 zone such as fe80::1%eth0 is accepted
+	if i := strings.IndexByte(v, '%'); i > 0 && strings.Contains(v, ":") {
+		v = v[:i]
+	}
+	return net.ParseIP(v) != nil
+}
+
+// validHostName accepts single label and FQDN with optional trailing dot. Underscore is allowed for
+// service records, and leading "*." for wildcard certificate name.
+func validHostName(v string) bool {
+	v = strings.TrimPrefix(strings.TrimSuffix(v, "."), "*.")
+	if v == "" || len(v) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(v, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c == '-' || c == '_' || c > unicode.MaxASCII || unicode.IsLetter(c) || unicode.IsDigit(c)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func validURL(v string) bool {
+	u, err := url.Parse(v)
+	return err == nil && u.Scheme != "" && (u.Host != "" || u.Opaque != "")
+}
+
+func validEmailAddress(v string) bool {
+	i := strings.LastIndexByte(v, '@')
+	if i <= 0 || strings.ContainsAny(v[:i], " \t<>") {
+		return false
+	}
+	return validHostName(v[i+1:])
+}
+
+func isHex(v string) bool {
+	_, err := hex.DecodeString(v)
+	return err == nil
+}
+
+// validHashValue accepts hex of MD5, SHA-1, SHA-256 and SHA-512.
+func validHashValue(v string) bool {
+	switch len(v) {
+	case 32, 40, 64, 128:
+		return isHex(v)
+	}
+	return false
+}
+
+func validCertFingerprint(v string) bool {
+	v = strings.ReplaceAll(v, ":", "")
+	return (len(v) == 40 || len(v) == 64) && isHex(v)
+}
+
+// validCloudResource accepts ARN (arn:partition:service:region:account:resource) or ID without space.
+func validCloudResource(v string) bool {
+	if strings.HasPrefix(v, "arn:") {
+		parts := strings.SplitN(v, ":", 6)
+		return len(parts) == 6 && parts[1] != "" && parts[2] != "" && parts[5] != ""
+	}
+	return v != "" && !strings.ContainsAny(v, " \t\r\n")
+}
+
+func validPort(v string) bool {
+	if i := strings.IndexByte(v, '/'); i >= 0 {
+		switch strings.ToLower(v[i+1:]) {
+		case "tcp", "udp", "sctp":
+		default:
+			return false
+		}
+		v = v[:i]
+	}
+	n, err := strconv.Atoi(v)
+	return err == nil && n >= 0 && n <= 65535
+}
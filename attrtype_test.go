@@ -0,0 +1,78 @@
+package deepalert_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	da "github.com/cookpad/deepalert"
+)
+
+func TestAttributeValidate(t *testing.T) {
+	testCases := []struct {
+		attrType da.AttrType
+		valid    []string
+		invalid  []string
+	}{
+		{da.TypeIPAddr, []string{"10.0.0.1", "2001:db8::1", "fe80::1%eth0"}, []string{"10.0.0.256", "example.com", ""}},
+		{da.TypeDomainName, []string{"example.com", "EXAMPLE.com.", "*.example.com", "_dmarc.example.com", "xn--r8jz45g.jp"}, []string{"example..com", "-bad.example.com", "exa mple.com", ""}},
+		{da.TypeHostName, []string{"web-01", "web-01.internal"}, []string{"web 01", "web_01/"}},
+		{da.TypeUserName, []string{"alice", "DOMAIN\\bob"}, []string{"", " ", "al\nice"}},
+		{da.TypeFileHashValue, []string{
+			"d41d8cd98f00b204e9800998ecf8427e",
+			"da39a3ee5e6b4b0d3255bfef95601890afd80709",
+			"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		}, []string{"d41d8cd98f00b204e9800998ecf8427", "zz1d8cd98f00b204e9800998ecf8427e"}},
+		{da.TypeJSON, []string{`{"a":1}`, `[]`}, []string{`{"a":`}},
+		{da.TypeURL, []string{"https://example.com/path?q=1", "mailto:alice@example.com"}, []string{"example.com/path", "://"}},
+		{da.TypeEmailAddress, []string{"alice@example.com", "a.b+c@mail.example.com"}, []string{"alice", "@example.com", "Alice <alice@example.com>", "alice@exa mple.com"}},
+		{da.TypeProcess, []string{"/usr/bin/curl -s https://example.com", "powershell.exe"}, []string{""}},
+		{da.TypeCloudResource, []string{"arn:aws:iam::111122223333:role/admin", "i-0123456789abcdef0", "projects/p/instances/vm"}, []string{"arn:aws:iam", "i-0123 4567"}},
+		{da.TypeFilePath, []string{"/etc/passwd", `C:\Windows\System32\cmd.exe`}, []string{"", "/tmp/\x00"}},
+		{da.TypeMACAddr, []string{"00:1a:2b:3c:4d:5e", "00-1A-2B-3C-4D-5E"}, []string{"00:1a:2b:3c:4d", "host"}},
+		{da.TypeCIDR, []string{"10.0.0.0/8", "2001:db8::/32"}, []string{"10.0.0.1", "10.0.0.0/33"}},
+		{da.TypePort, []string{"0", "443", "53/udp", "22/TCP"}, []string{"65536", "-1", "http", "53/icmp"}},
+		{da.TypeCertFingerprint, []string{
+			"da39a3ee5e6b4b0d3255bfef95601890afd80709",
+			"E3:B0:C4:42:98:FC:1C:14:9A:FB:F4:C8:99:6F:B9:24:27:AE:41:E4:64:9B:93:4C:A4:95:99:1B:78:52:B8:55",
+		}, []string{"d41d8cd98f00b204e9800998ecf8427e"}},
+	}
+
+	for _, tc := range testCases {
+		for _, v := range tc.valid {
+			attr := da.Attribute{Type: tc.attrType, Key: "k", Value: v}
+			assert.NoError(t, attr.Validate(), "%s: %q should be valid", tc.attrType, v)
+		}
+		for _, v := range tc.invalid {
+			attr := da.Attribute{Type: tc.attrType, Key: "k", Value: v}
+			err := attr.Validate()
+			assert.ErrorIs(t, err, da.ErrInvalidAlert, "%s: %q should be invalid", tc.attrType, v)
+		}
+	}
+}
+
+func TestCustomAttrType(t *testing.T) {
+	defer da.ResetCustomAttrTypePrefixes()
+
+	attr := da.Attribute{Type: "acme.ticket", Key: "ticket", Value: "SEC-123"}
+	alert := da.Alert{Detector: "det", RuleID: "rid", Attributes: []da.Attribute{attr}}
+
+	t.Run("unknown type is rejected", func(t *testing.T) {
+		assert.ErrorIs(t, alert.Validate(), da.ErrInvalidAlert)
+		assert.False(t, da.IsKnownAttrType(attr.Type))
+	})
+
+	t.Run("type with registered prefix is allowed", func(t *testing.T) {
+		require.NoError(t, da.RegisterCustomAttrTypePrefix("acme."))
+		assert.NoError(t, alert.Validate())
+		assert.True(t, da.IsKnownAttrType(attr.Type))
+		assert.False(t, da.IsKnownAttrType("acme."))
+		assert.False(t, da.IsKnownAttrType("corp.ticket"))
+	})
+
+	t.Run("prefix must not be empty or built-in type", func(t *testing.T) {
+		assert.Error(t, da.RegisterCustomAttrTypePrefix(""))
+		assert.Error(t, da.RegisterCustomAttrTypePrefix(string(da.TypeIPAddr)))
+	})
+}
@@ -4,22 +4,36 @@ import * as iam from '@aws-cdk/aws-iam';
 import * as sns from '@aws-cdk/aws-sns';
 import * as sqs from '@aws-cdk/aws-sqs';
 import * as dynamodb from '@aws-cdk/aws-dynamodb';
+import * as kinesis from '@aws-cdk/aws-kinesis';
+import * as s3 from '@aws-cdk/aws-s3';
+import * as s3n from '@aws-cdk/aws-s3-notifications';
 import * as sfn from '@aws-cdk/aws-stepfunctions';
 import * as tasks from '@aws-cdk/aws-stepfunctions-tasks';
 import {
   SqsEventSource,
   DynamoEventSource,
+  KinesisEventSource,
 } from '@aws-cdk/aws-lambda-event-sources';
 import { SqsSubscription } from '@aws-cdk/aws-sns-subscriptions';
 
 import * as path from 'path';
 import * as fs from 'fs';
+// NamedReviewer is one of reviewers invoked in parallel. name is kept in Report.reviews for audit.
+export interface NamedReviewer {
+  name: string;
+  func: lambda.Function;
+}
+
 export interface Property extends cdk.StackProps {
   assetsPath?: string;
 
   lambdaRoleARN?: string;
   sfnRoleARN?: string;
   reviewer?: lambda.Function;
+  // reviewers are invoked in parallel instead of reviewer, and their results are combined by reviewPolicy:
+  // 'max_severity' (default), 'first_decisive' or 'majority'
+  reviewers?: NamedReviewer[];
+  reviewPolicy?: string;
   inspectDelay?: cdk.Duration;
   reviewDelay?: cdk.Duration;
 
@@ -27,6 +41,30 @@ export interface Property extends cdk.StackProps {
   sentryEnv?: string;
   logLevel?: string;
   alertTopicARN?: string;
+
+  // alertMappings is configuration to convert JSON data from various sources to alert.
+  // See Mapping in alert/mapping.go for format, e.g. { mappings: [{ name: 'falco', ... }] }
+  alertMappings?: object;
+  // customAttrTypePrefixes allows attribute types other than built-in ones, e.g. ['acme.'] for 'acme.ticket'
+  customAttrTypePrefixes?: string[];
+  // iocExtraction enables extraction of IP addresses, domains, URLs, emails and hashes in alert description and
+  // body as attributes. See Config in alert/ioc/ioc.go for format, e.g. { allowlist: ['example.com'], max_attributes: 20 }
+  iocExtraction?: object;
+  // orgCIDRs are IP address ranges of your organization. IP address attributes in them (and private, loopback and
+  // link-local addresses) are tagged with 'local' context and others with 'remote' if the detector does not give.
+  orgCIDRs?: string[];
+  // disableIPContextTagging turns off the tagging above
+  disableIPContextTagging?: boolean;
+  // disabledDerivations turns off derived attributes: 'url_host', 'email_domain' and 'registrable_domain'
+  disabledDerivations?: string[];
+
+  // alertStream is Kinesis Data Stream that has alerts in records. (Optional)
+  alertStream?: kinesis.IStream;
+  // alertBucket is S3 bucket to put NDJSON or syslog files (can be gzip compressed) of alerts. (Optional)
+  alertBucket?: s3.IBucket;
+  // enableHTTPIngestion creates HTTP ingestion API with Lambda function URL. Callers are authenticated by
+  // sources registered with `deepalert source add`. (Optional)
+  enableHTTPIngestion?: boolean;
 }
 
 export class DeepAlertStack extends cdk.Stack {
@@ -49,6 +87,10 @@ export class DeepAlertStack extends cdk.Stack {
   dummyReviewer: lambda.Function;
   submitReport: lambda.Function;
   publishReport: lambda.Function;
+  ingestKinesis?: lambda.Function;
+  ingestS3?: lambda.Function;
+  ingestHTTP?: lambda.Function;
+  ingestHTTPUrl?: lambda.FunctionUrl;
 
   // StepFunctions
   readonly inspectionMachine: sfn.StateMachine;
@@ -123,10 +165,14 @@ export class DeepAlertStack extends cdk.Stack {
       TASK_TOPIC: this.taskTopic.topicArn,
       REPORT_TOPIC: this.reportTopic.topicArn,
       CACHE_TABLE: this.cacheTable.tableName,
+      DEAD_LETTER_QUEUE: this.deadLetterQueue.queueUrl,
 
       SENTRY_DSN: props.sentryDsn || "",
       SENTRY_ENVIRONMENT: props.sentryEnv || "",
       LOG_LEVEL: props.logLevel || "",
+      CUSTOM_ATTR_TYPE_PREFIXES: (props.customAttrTypePrefixes || []).join(","),
+      DISABLED_DERIVATIONS: (props.disabledDerivations || []).join(","),
+      REVIEW_POLICY: props.reviewPolicy || "",
     };
 
     interface LambdaConfig {
@@ -164,12 +210,12 @@ export class DeepAlertStack extends cdk.Stack {
     const lambdaConfigs: LambdaConfig[] = [
       {
         funcName: 'submitFinding',
-        events: [new SqsEventSource(this.findingQueue)],
+        events: [new SqsEventSource(this.findingQueue, { reportBatchItemFailures: true })],
         setToStack: (f: lambda.Function) => { this.submitFinding = f; }
       },
       {
         funcName: 'feedbackAttribute',
-        events: [new SqsEventSource(this.attributeQueue)],
+        events: [new SqsEventSource(this.attributeQueue, { reportBatchItemFailures: true })],
         timeout: attributeQueueTimeout,
         setToStack: (f: lambda.Function) => { this.feedbackAttribute = f; }
       },
@@ -214,6 +260,7 @@ export class DeepAlertStack extends cdk.Stack {
       this, id,
       this.compileReport,
       props.reviewer || this.dummyReviewer,
+      props.reviewers || [],
       this.submitReport,
       props.reviewDelay,
       sfnRole
@@ -222,15 +269,54 @@ export class DeepAlertStack extends cdk.Stack {
     const envVarsWithSF = Object.assign(baseEnvVars, {
       INSPECTOR_MACHINE: this.inspectionMachine.stateMachineArn,
       REVIEW_MACHINE: this.reviewMachine.stateMachineArn,
+      ALERT_MAPPINGS: props.alertMappings ? JSON.stringify(props.alertMappings) : "",
+      IOC_EXTRACTION: props.iocExtraction ? JSON.stringify(props.iocExtraction) : "",
+      ORG_CIDRS: (props.orgCIDRs || []).join(","),
+      DISABLE_IP_CONTEXT_TAGGING: props.disableIPContextTagging ? "true" : "",
     });
     buildLambdaFunction({
       funcName: 'receptAlert',
       timeout: alertQueueTimeout,
-      events: [new SqsEventSource(this.alertQueue)],
+      events: [new SqsEventSource(this.alertQueue, { reportBatchItemFailures: true })],
       environment: envVarsWithSF,
       setToStack: (f: lambda.Function) => { this.receptAlert = f; },
     })
 
+    // Ingestion functions handle alerts in the same way as receptAlert
+    const ingestFunctions: lambda.Function[] = [];
+    if (props.alertStream) {
+      buildLambdaFunction({
+        funcName: 'ingestKinesis',
+        timeout: alertQueueTimeout,
+        events: [new KinesisEventSource(props.alertStream, {
+          startingPosition: lambda.StartingPosition.TRIM_HORIZON,
+          reportBatchItemFailures: true,
+        })],
+        environment: envVarsWithSF,
+        setToStack: (f: lambda.Function) => { this.ingestKinesis = f; ingestFunctions.push(f); },
+      });
+    }
+    if (props.alertBucket) {
+      buildLambdaFunction({
+        funcName: 'ingestS3',
+        timeout: cdk.Duration.minutes(5),
+        environment: envVarsWithSF,
+        setToStack: (f: lambda.Function) => { this.ingestS3 = f; ingestFunctions.push(f); },
+      });
+      props.alertBucket.addEventNotification(s3.EventType.OBJECT_CREATED, new s3n.LambdaDestination(this.ingestS3!));
+    }
+    if (props.enableHTTPIngestion) {
+      buildLambdaFunction({
+        funcName: 'ingestHTTP',
+        timeout: cdk.Duration.seconds(29),
+        environment: envVarsWithSF,
+        setToStack: (f: lambda.Function) => { this.ingestHTTP = f; ingestFunctions.push(f); },
+      });
+      // Authentication is done by the function with API key or HMAC signature of each source
+      this.ingestHTTPUrl = this.ingestHTTP!.addFunctionUrl({ authType: lambda.FunctionUrlAuthType.NONE });
+      new cdk.CfnOutput(this, 'IngestHTTPUrl', { value: this.ingestHTTPUrl.url });
+    }
+
 
     if (lambdaRole === undefined) {
       this.inspectionMachine.grantStartExecution(this.receptAlert);
@@ -247,6 +333,21 @@ export class DeepAlertStack extends cdk.Stack {
       this.cacheTable.grantReadWriteData(this.submitReport);
       this.cacheTable.grantReadWriteData(this.publishReport);
 
+      // Messages that can never be processed are moved to deadLetterQueue directly
+      this.deadLetterQueue.grantSendMessages(this.receptAlert);
+      this.deadLetterQueue.grantSendMessages(this.submitFinding);
+      this.deadLetterQueue.grantSendMessages(this.feedbackAttribute);
+
+      ingestFunctions.forEach((f) => {
+        this.inspectionMachine.grantStartExecution(f);
+        this.reviewMachine.grantStartExecution(f);
+        this.cacheTable.grantReadWriteData(f);
+        this.deadLetterQueue.grantSendMessages(f);
+      });
+      if (props.alertBucket && this.ingestS3) {
+        props.alertBucket.grantRead(this.ingestS3);
+      }
+
     }
   }
 }
@@ -283,12 +384,50 @@ function buildReviewMachine(
   stackID: string,
   compileReport: lambda.Function,
   reviewer: lambda.Function,
+  reviewers: NamedReviewer[],
   submitReport: lambda.Function,
   delay?: cdk.Duration,
   sfnRole?: iam.IRole
 ): sfn.StateMachine {
   const waitTime = delay || cdk.Duration.minutes(10);
 
+  let review: sfn.IChainable = new tasks.LambdaInvoke(scope, 'invokeReviewer', {
+    lambdaFunction: reviewer,
+    resultPath: '$.result',
+    outputPath: '$',
+    payloadResponseOnly: true,
+  });
+
+  if (reviewers.length > 0) {
+    // Each branch returns { reviewer, result } and all of them are set to $.reviews
+    const parallel = new sfn.Parallel(scope, 'invokeReviewers', {
+      resultPath: '$.reviews',
+    });
+    const names = new Set<string>();
+    reviewers.forEach((r) => {
+      if (names.has(r.name)) {
+        throw new Error(`Duplicated reviewer name: ${r.name}`);
+      }
+      names.add(r.name);
+
+      // Failure of a reviewer is passed as no result so that other reviewers can decide the report
+      const invoke = new tasks.LambdaInvoke(scope, 'invokeReviewer-' + r.name, {
+        lambdaFunction: r.func,
+        resultPath: '$.result',
+        payloadResponseOnly: true,
+      }).addCatch(new sfn.Pass(scope, 'catchReviewer-' + r.name, {
+        result: sfn.Result.fromObject({ reviewer: r.name, result: null }),
+      }));
+
+      parallel.branch(
+        invoke.next(new sfn.Pass(scope, 'tagReviewer-' + r.name, {
+          parameters: { reviewer: r.name, 'result.$': '$.result' },
+        }))
+      );
+    });
+    review = parallel;
+  }
+
   const wait = new sfn.Wait(scope, 'WaitCompile', {
     time: sfn.WaitTime.duration(waitTime),
   });
@@ -301,14 +440,7 @@ function buildReviewMachine(
         payloadResponseOnly: true,
       })
     )
-    .next(
-      new tasks.LambdaInvoke(scope, 'invokeReviewer', {
-        lambdaFunction: reviewer,
-        resultPath: '$.result',
-        outputPath: '$',
-        payloadResponseOnly: true,
-      })
-    )
+    .next(review)
     .next(
       new tasks.LambdaInvoke(scope, 'invokeSubmitReport', {
         lambdaFunction: submitReport,
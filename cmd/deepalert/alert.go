@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/alert"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/m-mizutani/golambda"
+	"github.com/urfave/cli/v2"
+)
+
+func newAlertCommand(args *handler.Arguments) *cli.Command {
+	return &cli.Command{
+		Name:  "alert",
+		Usage: "Handle alerts",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "send",
+				Usage:     "Validate an alert in JSON and send it to alert queue",
+				ArgsUsage: "[FILE (default: stdin)]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "queue",
+						Aliases:  []string{"q"},
+						Usage:    "URL of alert queue",
+						EnvVars:  []string{"DEEPALERT_ALERT_QUEUE"},
+						Required: true,
+					},
+					&cli.BoolFlag{Name: "dry-run", Usage: "Only validate the alert"},
+				},
+				Action: func(c *cli.Context) error {
+					var src io.Reader = os.Stdin
+					if path := c.Args().First(); path != "" && path != "-" {
+						fd, err := os.Open(path)
+						if err != nil {
+							return golambda.WrapError(err, "Fail to open alert file").With("path", path)
+						}
+						defer fd.Close()
+						src = fd
+					}
+
+					alert, err := readAlert(src)
+					if err != nil {
+						return err
+					}
+
+					if c.Bool("dry-run") {
+						fmt.Fprintf(c.App.Writer, "valid\t%s\n", alert.AlertID())
+						return nil
+					}
+
+					raw, err := json.Marshal(alert)
+					if err != nil {
+						return golambda.WrapError(err, "Fail to marshal alert")
+					}
+					if err := args.SQSService().SendMessage(c.String("queue"), string(raw), nil); err != nil {
+						return err
+					}
+
+					fmt.Fprintf(c.App.Writer, "sent\t%s\n", alert.AlertID())
+					return nil
+				},
+			},
+			{
+				Name:      "preview",
+				Usage:     "Convert JSON data to alerts in the same way as receptAlert and show them",
+				ArgsUsage: "[FILE (default: stdin)]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "mappings",
+						Aliases: []string{"m"},
+						Usage:   "Mapping configuration file in JSON",
+						EnvVars: []string{"DEEPALERT_ALERT_MAPPINGS"},
+					},
+				},
+				Action: func(c *cli.Context) error {
+					var mapper *alert.Mapper
+					if path := c.String("mappings"); path != "" {
+						raw, err := os.ReadFile(path)
+						if err != nil {
+							return golambda.WrapError(err, "Fail to read mapping file").With("path", path)
+						}
+						if mapper, err = alert.LoadMapper(raw); err != nil {
+							return err
+						}
+					}
+
+					var src io.Reader = os.Stdin
+					if path := c.Args().First(); path != "" && path != "-" {
+						fd, err := os.Open(path)
+						if err != nil {
+							return golambda.WrapError(err, "Fail to open data file").With("path", path)
+						}
+						defer fd.Close()
+						src = fd
+					}
+
+					return previewAlerts(c.App.Writer, mapper, src)
+				},
+			},
+		},
+	}
+}
+
+type alertPreview struct {
+	Mapping string             `json:"mapping,omitempty"`
+	Alerts  []*deepalert.Alert `json:"alerts,omitempty"`
+	Error   string             `json:"error,omitempty"`
+}
+
+// previewAlerts converts each JSON value in src. A conversion error is shown in the output instead of stopping.
+func previewAlerts(w io.Writer, mapper *alert.Mapper, src io.Reader) error {
+	decoder := json.NewDecoder(src)
+	for {
+		var data json.RawMessage
+		if err := decoder.Decode(&data); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return golambda.WrapError(err, "Fail to parse input JSON")
+		}
+
+		var preview alertPreview
+		alerts, mapping, err := mapper.Decode(data)
+		preview.Mapping = mapping
+		if err == nil {
+			preview.Alerts = alerts
+			for _, a := range alerts {
+				if err = a.Validate(); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			preview.Error = err.Error()
+		}
+
+		if err := printJSON(w, preview); err != nil {
+			return err
+		}
+	}
+}
+
+func readAlert(src io.Reader) (*deepalert.Alert, error) {
+	decoder := json.NewDecoder(src)
+	decoder.DisallowUnknownFields()
+
+	var alert deepalert.Alert
+	if err := decoder.Decode(&alert); err != nil {
+		return nil, golambda.WrapError(err, "Fail to parse alert JSON")
+	}
+	if decoder.More() {
+		return nil, errors.New("input has multiple JSON values, only one alert is allowed")
+	}
+
+	if err := alert.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &alert, nil
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"text/tabwriter"
+
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/cookpad/deepalert/internal/usecase"
+	"github.com/m-mizutani/golambda"
+	"github.com/urfave/cli/v2"
+)
+
+func newDLQCommand(args *handler.Arguments) *cli.Command {
+	filterFlags := []cli.Flag{
+		&cli.StringFlag{
+			Name:        "queue",
+			Aliases:     []string{"q"},
+			Usage:       "URL of dead-letter queue",
+			Value:       args.DeadLetterQueue,
+			Destination: &args.DeadLetterQueue,
+		},
+		&cli.StringFlag{Name: "kind", Usage: "Select messages by kind (alert, finding, attribute or unknown)"},
+		&cli.StringFlag{Name: "function", Usage: "Select messages by originating function name (partial match)"},
+		&cli.StringFlag{Name: "reason", Usage: "Select messages by error reason (regular expression)"},
+		&cli.IntFlag{Name: "limit", Aliases: []string{"n"}, Usage: "Maximum number of messages, 0 means no limit"},
+	}
+
+	return &cli.Command{
+		Name:  "dlq",
+		Usage: "Inspect and redrive messages in dead-letter queue",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "Show messages in dead-letter queue with originating function and error",
+				Flags: append(filterFlags, &cli.StringFlag{
+					Name:    "format",
+					Aliases: []string{"f"},
+					Usage:   "Output format (table or json). json output can be edited and given to redrive --input",
+					Value:   "table",
+				}),
+				Action: func(c *cli.Context) error {
+					letters, err := fetchDeadLetters(c, args, nil)
+					if err != nil {
+						return err
+					}
+					return printDeadLetters(c.App.Writer, c.String("format"), letters)
+				},
+			},
+			{
+				Name:  "redrive",
+				Usage: "Send messages in dead-letter queue to original queue or topic again",
+				Flags: append(filterFlags,
+					&cli.StringFlag{Name: "input", Aliases: []string{"i"}, Usage: "Redrive only messages in the file (output of list --format json) with edited body"},
+					&cli.StringFlag{Name: "alert-queue", Usage: "URL of alert queue for alerts without source queue", EnvVars: []string{"DEEPALERT_ALERT_QUEUE"}},
+					&cli.StringFlag{Name: "finding-queue", Usage: "URL of finding queue for findings without source queue", EnvVars: []string{"DEEPALERT_FINDING_QUEUE"}},
+					&cli.StringFlag{Name: "attribute-queue", Usage: "URL of attribute queue for attributes without source queue", EnvVars: []string{"DEEPALERT_ATTRIBUTE_QUEUE"}},
+					&cli.BoolFlag{Name: "dry-run", Usage: "Show destination of messages without sending and deleting"},
+				),
+				Action: func(c *cli.Context) error {
+					var edited map[string]*usecase.DeadLetter
+					if path := c.String("input"); path != "" {
+						var err error
+						if edited, err = readDeadLetters(path); err != nil {
+							return err
+						}
+					}
+
+					letters, err := fetchDeadLetters(c, args, edited)
+					if err != nil {
+						return err
+					}
+
+					targets := &usecase.RedriveTargets{
+						AlertQueue:     c.String("alert-queue"),
+						FindingQueue:   c.String("finding-queue"),
+						AttributeQueue: c.String("attribute-queue"),
+					}
+					return redriveDeadLetters(c.App.Writer, args, letters, targets, c.Bool("dry-run"))
+				},
+			},
+		},
+	}
+}
+
+func fetchDeadLetters(c *cli.Context, args *handler.Arguments, edited map[string]*usecase.DeadLetter) ([]*usecase.DeadLetter, error) {
+	if args.DeadLetterQueue == "" {
+		return nil, errors.New("--queue or DEAD_LETTER_QUEUE is required")
+	}
+
+	filter := &usecase.DeadLetterFilter{
+		Kind:     usecase.DeadLetterKind(c.String("kind")),
+		Function: c.String("function"),
+	}
+	if reason := c.String("reason"); reason != "" {
+		ptn, err := regexp.Compile(reason)
+		if err != nil {
+			return nil, golambda.WrapError(err, "Invalid --reason pattern").With("reason", reason)
+		}
+		filter.Reason = ptn
+	}
+	for id := range edited {
+		filter.MessageIDs = append(filter.MessageIDs, id)
+	}
+
+	letters, err := usecase.FetchDeadLetters(args, args.DeadLetterQueue, filter, c.Int("limit"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, letter := range letters {
+		if e, ok := edited[letter.MessageID]; ok {
+			letter.Body = e.Body
+		}
+	}
+
+	return letters, nil
+}
+
+func readDeadLetters(path string) (map[string]*usecase.DeadLetter, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, golambda.WrapError(err, "Fail to open input").With("path", path)
+	}
+	defer fd.Close()
+
+	letters := map[string]*usecase.DeadLetter{}
+	scanner := bufio.NewScanner(fd)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var letter usecase.DeadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &letter); err != nil {
+			return nil, golambda.WrapError(err, "Fail to parse input").With("line", scanner.Text())
+		}
+		letters[letter.MessageID] = &letter
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, golambda.WrapError(err, "Fail to read input").With("path", path)
+	}
+
+	return letters, nil
+}
+
+func printDeadLetters(w io.Writer, format string, letters []*usecase.DeadLetter) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		for _, letter := range letters {
+			if err := encoder.Encode(letter); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "MESSAGE ID\tKIND\tFUNCTION\tRECEIVED\tREASON")
+		for _, letter := range letters {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", letter.MessageID, letter.Kind, letter.Function, letter.ReceiveCount, letter.Reason)
+		}
+		return tw.Flush()
+
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+func redriveDeadLetters(w io.Writer, args *handler.Arguments, letters []*usecase.DeadLetter, targets *usecase.RedriveTargets, dryRun bool) error {
+	var failed int
+	for _, letter := range letters {
+		dst, err := usecase.RedriveDeadLetter(args, args.DeadLetterQueue, letter, targets, dryRun)
+		if err != nil {
+			failed++
+			fmt.Fprintf(w, "failed\t%s\t%v\n", letter.MessageID, err)
+			continue
+		}
+
+		if dryRun {
+			fmt.Fprintf(w, "dry-run\t%s\t%s\n", letter.MessageID, dst)
+		} else {
+			fmt.Fprintf(w, "redriven\t%s\t%s\n", letter.MessageID, dst)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d message(s) were not redriven", failed, len(letters))
+	}
+	return nil
+}
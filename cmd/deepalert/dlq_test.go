@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/cookpad/deepalert/internal/mock"
+	"github.com/cookpad/deepalert/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testDLQ        = "https://sqs.ap-northeast-1.amazonaws.com/111122223333/deadLetterQueue"
+	testAlertQueue = "https://sqs.ap-northeast-1.amazonaws.com/111122223333/alertQueue"
+	testFindingARN = "arn:aws:sqs:ap-northeast-1:111122223333:findingQueue"
+	testFindingURL = "https://sqs.ap-northeast-1.amazonaws.com/111122223333/findingQueue"
+)
+
+func setupDLQ(t *testing.T) (*handler.Arguments, *mock.SQSClient, *mock.SNSClient) {
+	sqsClient, newSQS := mock.NewMockSQSClientSet()
+	snsClient, newSNS := mock.NewMockSNSClientSet()
+	args := &handler.Arguments{NewSQS: newSQS, NewSNS: newSNS}
+
+	toBody := func(v interface{}) *string {
+		raw, err := json.Marshal(v)
+		require.NoError(t, err)
+		return aws.String(string(raw))
+	}
+	strAttr := func(v string) *sqs.MessageAttributeValue {
+		return &sqs.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(v)}
+	}
+
+	// Alert failed repeatedly, moved by redrive policy of alertQueue
+	sqsClient.PutMessage(testDLQ, &sqs.Message{
+		MessageId:     aws.String("m-alert"),
+		ReceiptHandle: aws.String("r-alert"),
+		Body:          toBody(deepalert.Alert{Detector: "blue", RuleID: "r1"}),
+		Attributes: map[string]*string{
+			sqs.MessageSystemAttributeNameApproximateReceiveCount: aws.String("5"),
+		},
+	})
+	// Finding moved by submitFinding because of permanent error
+	sqsClient.PutMessage(testDLQ, &sqs.Message{
+		MessageId:     aws.String("m-finding"),
+		ReceiptHandle: aws.String("r-finding"),
+		Body:          aws.String(`{"report_id":"x","author":"tester","type":"host","content":`),
+		MessageAttributes: map[string]*sqs.MessageAttributeValue{
+			usecase.DeadLetterAttrFunction: strAttr("DeepAlert-submitFinding12345"),
+			usecase.DeadLetterAttrSource:   strAttr(testFindingARN),
+			usecase.DeadLetterAttrReason:   strAttr("Fail to unmarshal Finding: unexpected end of JSON input"),
+		},
+	})
+	// Alert delivered via SNS topic
+	sqsClient.PutMessage(testDLQ, &sqs.Message{
+		MessageId:     aws.String("m-sns"),
+		ReceiptHandle: aws.String("r-sns"),
+		Body: toBody(map[string]string{
+			"Type":     "Notification",
+			"TopicArn": "arn:aws:sns:ap-northeast-1:111122223333:alertTopic",
+			"Message":  `{"detector":"orange","rule_id":"r2"}`,
+		}),
+	})
+
+	return args, sqsClient, snsClient
+}
+
+func TestDLQList(t *testing.T) {
+	args, _, _ := setupDLQ(t)
+	var buf bytes.Buffer
+	app := newApp(args)
+	app.Writer = &buf
+
+	require.NoError(t, app.Run([]string{"deepalert", "dlq", "list", "--queue", testDLQ, "--format", "json"}))
+
+	var letters []*usecase.DeadLetter
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var letter usecase.DeadLetter
+		require.NoError(t, json.Unmarshal([]byte(line), &letter))
+		letters = append(letters, &letter)
+	}
+	require.Equal(t, 3, len(letters))
+
+	assert.Equal(t, usecase.DeadLetterAlert, letters[0].Kind)
+	assert.Equal(t, "receptAlert", letters[0].Function)
+	assert.Equal(t, 5, letters[0].ReceiveCount)
+
+	assert.Equal(t, usecase.DeadLetterUnknown, letters[1].Kind)
+	assert.Equal(t, "DeepAlert-submitFinding12345", letters[1].Function)
+	assert.Contains(t, letters[1].Reason, "unexpected end of JSON input")
+
+	assert.Equal(t, usecase.DeadLetterAlert, letters[2].Kind)
+	assert.Equal(t, "arn:aws:sns:ap-northeast-1:111122223333:alertTopic", letters[2].Topic)
+
+	t.Run("filter by function", func(t *testing.T) {
+		buf.Reset()
+		require.NoError(t, app.Run([]string{"deepalert", "dlq", "list", "--queue", testDLQ, "--function", "submitFinding"}))
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Equal(t, 2, len(lines))
+		assert.Contains(t, lines[1], "m-finding")
+	})
+}
+
+func TestDLQRedrive(t *testing.T) {
+	t.Run("dry-run does not send or delete messages", func(t *testing.T) {
+		args, sqsClient, snsClient := setupDLQ(t)
+		var buf bytes.Buffer
+		app := newApp(args)
+		app.Writer = &buf
+
+		err := app.Run([]string{"deepalert", "dlq", "redrive", "--queue", testDLQ, "--dry-run"})
+		// m-alert has no source queue and --alert-queue is not given
+		require.Error(t, err)
+		assert.Contains(t, buf.String(), "dry-run\tm-finding\t"+testFindingURL)
+		assert.Contains(t, buf.String(), "dry-run\tm-sns\tarn:aws:sns:ap-northeast-1:111122223333:alertTopic")
+		assert.Contains(t, buf.String(), "failed\tm-alert")
+
+		assert.Equal(t, 0, len(sqsClient.Input))
+		assert.Equal(t, 0, len(snsClient.Input))
+		assert.Equal(t, 3, len(sqsClient.Messages(testDLQ)))
+	})
+
+	t.Run("redrive alerts to alert queue", func(t *testing.T) {
+		args, sqsClient, snsClient := setupDLQ(t)
+		app := newApp(args)
+		app.Writer = &bytes.Buffer{}
+
+		require.NoError(t, app.Run([]string{"deepalert", "dlq", "redrive", "--queue", testDLQ, "--kind", "alert", "--alert-queue", testAlertQueue}))
+
+		// Alert via SNS is also sent to alert queue because receptAlert accepts SNS notification
+		msgs := sqsClient.Messages(testAlertQueue)
+		require.Equal(t, 2, len(msgs))
+		assert.Contains(t, *msgs[0].Body, `"detector":"blue"`)
+		assert.Contains(t, *msgs[1].Body, `"Type":"Notification"`)
+		assert.Equal(t, 0, len(snsClient.Input))
+
+		remained := sqsClient.Messages(testDLQ)
+		require.Equal(t, 1, len(remained))
+		assert.Equal(t, "m-finding", *remained[0].MessageId)
+	})
+
+	t.Run("redrive alert to SNS topic if alert queue is unknown", func(t *testing.T) {
+		args, sqsClient, snsClient := setupDLQ(t)
+		app := newApp(args)
+		app.Writer = &bytes.Buffer{}
+
+		require.NoError(t, app.Run([]string{"deepalert", "dlq", "redrive", "--queue", testDLQ, "--kind", "alert", "--limit", "1", "--reason", "nothing matched"}))
+		assert.Equal(t, 0, len(snsClient.Input))
+
+		input := filepath.Join(t.TempDir(), "sns.json")
+		require.NoError(t, os.WriteFile(input, []byte(`{"message_id":"m-sns","body":"{\"Type\":\"Notification\",\"Message\":\"{\\\"detector\\\":\\\"orange\\\",\\\"rule_id\\\":\\\"r3\\\"}\"}"}`), 0600))
+		require.NoError(t, app.Run([]string{"deepalert", "dlq", "redrive", "--queue", testDLQ, "--input", input}))
+
+		require.Equal(t, 1, len(snsClient.Input))
+		assert.Equal(t, "arn:aws:sns:ap-northeast-1:111122223333:alertTopic", *snsClient.Input[0].TopicArn)
+		assert.Equal(t, `{"detector":"orange","rule_id":"r3"}`, *snsClient.Input[0].Message)
+		assert.Equal(t, 2, len(sqsClient.Messages(testDLQ)))
+	})
+
+	t.Run("message without receipt handle is not redriven", func(t *testing.T) {
+		args, sqsClient, _ := setupDLQ(t)
+		sqsClient.PutMessage(testDLQ, &sqs.Message{
+			MessageId: aws.String("m-no-handle"),
+			Body:      aws.String(`{"detector":"blue","rule_id":"r4"}`),
+		})
+		var buf bytes.Buffer
+		app := newApp(args)
+		app.Writer = &buf
+
+		require.Error(t, app.Run([]string{"deepalert", "dlq", "redrive", "--queue", testDLQ, "--kind", "alert", "--alert-queue", testAlertQueue}))
+		assert.Contains(t, buf.String(), "failed\tm-no-handle")
+
+		msgs := sqsClient.Messages(testAlertQueue)
+		require.Equal(t, 2, len(msgs))
+		for _, msg := range msgs {
+			assert.NotContains(t, *msg.Body, `"rule_id":"r4"`)
+		}
+	})
+
+	t.Run("redrive edited messages", func(t *testing.T) {
+		args, sqsClient, _ := setupDLQ(t)
+		app := newApp(args)
+		app.Writer = &bytes.Buffer{}
+
+		fixed := usecase.DeadLetter{
+			MessageID: "m-finding",
+			Body:      `{"report_id":"x","author":"tester","type":"host","content":{}}`,
+		}
+		raw, err := json.Marshal(fixed)
+		require.NoError(t, err)
+		input := filepath.Join(t.TempDir(), "edited.json")
+		require.NoError(t, os.WriteFile(input, raw, 0600))
+
+		require.NoError(t, app.Run([]string{"deepalert", "dlq", "redrive", "--queue", testDLQ, "--input", input}))
+
+		require.Equal(t, 1, len(sqsClient.Messages(testFindingURL)))
+		assert.Equal(t, fixed.Body, *sqsClient.Messages(testFindingURL)[0].Body)
+		assert.Equal(t, 2, len(sqsClient.Messages(testDLQ)))
+	})
+}
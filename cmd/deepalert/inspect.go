@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/inspector"
+	"github.com/google/uuid"
+	"github.com/m-mizutani/golambda"
+	"github.com/urfave/cli/v2"
+)
+
+func newInspectCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "inspect",
+		Usage: "Run inspectors",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "run",
+				Usage:     "Run an inspector locally against an attribute. The inspector must call inspector.StartLocal if inspector.IsLocal() is true",
+				ArgsUsage: "-- COMMAND [ARGS...]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "type", Aliases: []string{"t"}, Usage: "Type of the attribute (e.g. ipaddr, domain)", Required: true},
+					&cli.StringFlag{Name: "value", Aliases: []string{"v"}, Usage: "Value of the attribute", Required: true},
+					&cli.StringFlag{Name: "key", Aliases: []string{"k"}, Usage: "Key of the attribute", Value: "local"},
+					&cli.StringSliceFlag{Name: "context", Aliases: []string{"c"}, Usage: "Context of the attribute (e.g. remote, local)"},
+					&cli.StringFlag{Name: "report-id", Usage: "ReportID of the task (default: random UUID)"},
+					&cli.StringFlag{Name: "format", Aliases: []string{"f"}, Usage: "Output format (table or json)", Value: "table"},
+				},
+				Action: func(c *cli.Context) error {
+					if c.NArg() == 0 {
+						return errors.New("COMMAND of inspector is required")
+					}
+
+					task := &deepalert.Task{
+						ReportID: deepalert.ReportID(c.String("report-id")),
+						Attribute: &deepalert.Attribute{
+							Type:  deepalert.AttrType(c.String("type")),
+							Key:   c.String("key"),
+							Value: c.String("value"),
+						},
+					}
+					if task.ReportID == deepalert.NullReportID {
+						task.ReportID = deepalert.ReportID(uuid.New().String())
+					}
+					for _, ctx := range c.StringSlice("context") {
+						task.Attribute.Context = append(task.Attribute.Context, deepalert.AttrContext(ctx))
+					}
+
+					outputs, err := runLocalInspector(task, c.Args().Slice(), c.App.ErrWriter)
+					if err != nil {
+						return err
+					}
+
+					return printLocalOutputs(c.App.Writer, c.String("format"), outputs)
+				},
+			},
+		},
+	}
+}
+
+func runLocalInspector(task *deepalert.Task, cmdArgs []string, logWriter io.Writer) ([]*inspector.LocalOutput, error) {
+	rawTask, err := json.Marshal(task)
+	if err != nil {
+		return nil, golambda.WrapError(err, "Fail to marshal task")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "deepalert-inspect-")
+	if err != nil {
+		return nil, golambda.WrapError(err, "Fail to create temp directory")
+	}
+	defer os.RemoveAll(tmpDir)
+	outputPath := filepath.Join(tmpDir, "output.json")
+
+	// Logs of the inspector go to logWriter so that they are not mixed with results
+	cmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
+	cmd.Env = append(os.Environ(),
+		inspector.EnvLocalTask+"="+string(rawTask),
+		inspector.EnvLocalOutput+"="+outputPath,
+	)
+	cmd.Stdout = logWriter
+	cmd.Stderr = logWriter
+	if err := cmd.Run(); err != nil {
+		return nil, golambda.WrapError(err, "Inspector failed").With("command", cmdArgs)
+	}
+
+	fd, err := os.Open(outputPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, golambda.NewError("Inspector did not run in local mode, call inspector.StartLocal in the inspector").With("command", cmdArgs)
+		}
+		return nil, golambda.WrapError(err, "Fail to open inspector output")
+	}
+	defer fd.Close()
+
+	var outputs []*inspector.LocalOutput
+	scanner := bufio.NewScanner(fd)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var output inspector.LocalOutput
+		if err := json.Unmarshal(scanner.Bytes(), &output); err != nil {
+			return nil, golambda.WrapError(err, "Fail to parse inspector output").With("line", scanner.Text())
+		}
+		outputs = append(outputs, &output)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, golambda.WrapError(err, "Fail to read inspector output")
+	}
+
+	return outputs, nil
+}
+
+func printLocalOutputs(w io.Writer, format string, outputs []*inspector.LocalOutput) error {
+	switch format {
+	case "json":
+		return printJSON(w, outputs)
+
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "OUTPUT\tAUTHOR\tTYPE\tCONTENT")
+		for _, output := range outputs {
+			switch {
+			case output.Finding != nil:
+				raw, err := json.Marshal(output.Finding.Content)
+				if err != nil {
+					return golambda.WrapError(err, "Fail to marshal content")
+				}
+				fmt.Fprintf(tw, "finding\t%s\t%s\t%s\n", output.Finding.Author, output.Finding.Type, string(raw))
+
+			case output.Attribute != nil:
+				for _, attr := range output.Attribute.Attributes {
+					fmt.Fprintf(tw, "attribute\t%s\t%s\t%s=%s\n", output.Attribute.Author, attr.Type, attr.Key, attr.Value)
+				}
+			}
+		}
+		return tw.Flush()
+
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
@@ -0,0 +1,46 @@
+// Command deepalert is a command line tool to operate deployed DeepAlert stack.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/urfave/cli/v2"
+)
+
+func main() {
+	args := handler.NewArguments()
+	if err := args.BindEnvVars(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load environment variables: %+v\n", err)
+		os.Exit(1)
+	}
+
+	if err := newApp(args).Run(os.Args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func newApp(args *handler.Arguments) *cli.App {
+	return &cli.App{
+		Name:  "deepalert",
+		Usage: "Operate DeepAlert stack",
+		// Lambda environment variables (e.g. CACHE_TABLE, AWS_REGION) are also available
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "region",
+				Usage:       "AWS region of DeepAlert stack",
+				Value:       args.AwsRegion,
+				Destination: &args.AwsRegion,
+			},
+		},
+		Commands: []*cli.Command{
+			newAlertCommand(args),
+			newReportCommand(args),
+			newInspectCommand(),
+			newDLQCommand(args),
+			newSourceCommand(args),
+		},
+	}
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/inspector"
+	"github.com/cookpad/deepalert/internal/adaptor"
+	"github.com/cookpad/deepalert/internal/api"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/cookpad/deepalert/internal/mock"
+	"github.com/cookpad/deepalert/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain works as an inspector if the test binary is invoked by inspect run
+func TestMain(m *testing.M) {
+	if inspector.IsLocal() {
+		err := inspector.StartLocal(inspector.Arguments{
+			Author: "localInspector",
+			Handler: func(ctx context.Context, attr deepalert.Attribute) (*deepalert.TaskResult, error) {
+				return &deepalert.TaskResult{
+					Contents: []deepalert.ReportContent{
+						&deepalert.ContentHost{HostName: []string{"host-of-" + attr.Value}},
+					},
+					NewAttributes: []*deepalert.Attribute{
+						{Type: deepalert.TypeDomainName, Key: "hostname", Value: "host-of-" + attr.Value},
+					},
+				}, nil
+			},
+		})
+		if err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestAlertSend(t *testing.T) {
+	queueURL := "https://sqs.ap-northeast-1.amazonaws.com/111122223333/alertQueue"
+
+	run := func(t *testing.T, input string, cmdArgs ...string) (*mock.SQSClient, string, error) {
+		sqsClient, newSQS := mock.NewMockSQSClientSet()
+		path := filepath.Join(t.TempDir(), "alert.json")
+		require.NoError(t, os.WriteFile(path, []byte(input), 0600))
+
+		var buf bytes.Buffer
+		app := newApp(&handler.Arguments{NewSQS: newSQS})
+		app.Writer = &buf
+		err := app.Run(append([]string{"deepalert", "alert", "send", "--queue", queueURL}, append(cmdArgs, path)...))
+		return sqsClient, buf.String(), err
+	}
+
+	t.Run("valid alert is sent", func(t *testing.T) {
+		sqsClient, out, err := run(t, `{"detector":"blue","rule_id":"r1","attributes":[{"type":"ipaddr","key":"src","value":"10.0.0.1"}]}`)
+		require.NoError(t, err)
+		assert.Contains(t, out, "sent\talert:")
+		require.Equal(t, 1, len(sqsClient.Input))
+		assert.Equal(t, queueURL, *sqsClient.Input[0].QueueUrl)
+
+		var alert deepalert.Alert
+		require.NoError(t, json.Unmarshal([]byte(*sqsClient.Input[0].MessageBody), &alert))
+		assert.Equal(t, "blue", alert.Detector)
+		assert.Equal(t, "10.0.0.1", alert.Attributes[0].Value)
+	})
+
+	t.Run("dry-run only validates", func(t *testing.T) {
+		sqsClient, out, err := run(t, `{"detector":"blue","rule_id":"r1"}`, "--dry-run")
+		require.NoError(t, err)
+		assert.Contains(t, out, "valid\talert:")
+		assert.Equal(t, 0, len(sqsClient.Input))
+	})
+
+	t.Run("invalid alert is not sent", func(t *testing.T) {
+		sqsClient, _, err := run(t, `{"detector":"blue"}`)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, deepalert.ErrInvalidAlert)
+		assert.Equal(t, 0, len(sqsClient.Input))
+	})
+
+	t.Run("unknown field is not allowed", func(t *testing.T) {
+		sqsClient, _, err := run(t, `{"detector":"blue","rule_id":"r1","ruleName":"typo"}`)
+		require.Error(t, err)
+		assert.Equal(t, 0, len(sqsClient.Input))
+	})
+}
+
+func TestAlertPreview(t *testing.T) {
+	dir := t.TempDir()
+	mappingPath := filepath.Join(dir, "mappings.json")
+	require.NoError(t, os.WriteFile(mappingPath, []byte(`{"mappings":[{
+		"name": "github",
+		"route": {"path": "$.action"},
+		"detector": "github-audit",
+		"rule_id": "{{ $.action }}",
+		"alert_key": "{{ $.actor }}",
+		"attributes": [{"type": "username", "key": "actor", "path": "$.actor", "context": ["subject"]}]
+	}]}`), 0600))
+
+	dataPath := filepath.Join(dir, "data.json")
+	require.NoError(t, os.WriteFile(dataPath, []byte(
+		`{"action":"repo.destroy","actor":"blue"}
+		{"detector":"plain","rule_id":"r1"}
+		{"action":""}`), 0600))
+
+	var buf bytes.Buffer
+	app := newApp(&handler.Arguments{})
+	app.Writer = &buf
+	require.NoError(t, app.Run([]string{"deepalert", "alert", "preview", "--mappings", mappingPath, dataPath}))
+
+	decoder := json.NewDecoder(&buf)
+	var previews []alertPreview
+	for decoder.More() {
+		var v alertPreview
+		require.NoError(t, decoder.Decode(&v))
+		previews = append(previews, v)
+	}
+	require.Equal(t, 3, len(previews))
+
+	assert.Equal(t, "github", previews[0].Mapping)
+	require.Equal(t, 1, len(previews[0].Alerts))
+	assert.Equal(t, "github-audit", previews[0].Alerts[0].Detector)
+	assert.Equal(t, "repo.destroy", previews[0].Alerts[0].RuleID)
+	assert.Equal(t, "blue", previews[0].Alerts[0].AlertKey)
+	require.Equal(t, 1, len(previews[0].Alerts[0].Attributes))
+	assert.Equal(t, deepalert.TypeUserName, previews[0].Alerts[0].Attributes[0].Type)
+
+	assert.Equal(t, "", previews[1].Mapping)
+	assert.Equal(t, "", previews[1].Error)
+	require.Equal(t, 1, len(previews[1].Alerts))
+	assert.Equal(t, "plain", previews[1].Alerts[0].Detector)
+
+	assert.Equal(t, "github", previews[2].Mapping)
+	assert.NotEmpty(t, previews[2].Error)
+}
+
+func setupReports(t *testing.T) (*handler.Arguments, *service.RepositoryService) {
+	_, newRepo := mock.NewMockRepositorySet()
+	args := &handler.Arguments{NewRepository: newRepo}
+	args.CacheTable = "test-table"
+	repo, err := args.Repository()
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	require.NoError(t, repo.PutReport(&deepalert.Report{
+		ID:        "r-new",
+		Status:    deepalert.StatusNew,
+		CreatedAt: now,
+	}))
+	require.NoError(t, repo.PutReport(&deepalert.Report{
+		ID:        "r-published",
+		Status:    deepalert.StatusPublished,
+		Result:    deepalert.ReportResult{Severity: deepalert.SevUrgent, Reason: "bad"},
+		CreatedAt: now.Add(-time.Hour),
+	}))
+	require.NoError(t, repo.SaveAlertCache("r-published", deepalert.Alert{Detector: "blue", RuleID: "r1"}, now))
+
+	return args, repo
+}
+
+func TestReport(t *testing.T) {
+	t.Run("get", func(t *testing.T) {
+		args, _ := setupReports(t)
+		var buf bytes.Buffer
+		app := newApp(args)
+		app.Writer = &buf
+
+		require.NoError(t, app.Run([]string{"deepalert", "report", "get", "--format", "json", "r-published"}))
+		var report deepalert.Report
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+		assert.Equal(t, deepalert.SevUrgent, report.Result.Severity)
+		require.Equal(t, 1, len(report.Alerts))
+		assert.Equal(t, "blue", report.Alerts[0].Detector)
+
+		buf.Reset()
+		require.NoError(t, app.Run([]string{"deepalert", "report", "get", "r-published"}))
+		assert.Contains(t, buf.String(), "urgent")
+		assert.Contains(t, buf.String(), "ALERTS (1)")
+
+		assert.Error(t, app.Run([]string{"deepalert", "report", "get", "r-nothing"}))
+	})
+
+	t.Run("list", func(t *testing.T) {
+		args, _ := setupReports(t)
+		var buf bytes.Buffer
+		app := newApp(args)
+		app.Writer = &buf
+
+		require.NoError(t, app.Run([]string{"deepalert", "report", "list"}))
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Equal(t, 3, len(lines))
+		assert.Contains(t, lines[1], "r-new")
+		assert.Contains(t, lines[2], "r-published")
+
+		buf.Reset()
+		require.NoError(t, app.Run([]string{"deepalert", "report", "list", "--status", "published", "--format", "json"}))
+		var reports []*deepalert.Report
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &reports))
+		require.Equal(t, 1, len(reports))
+		assert.Equal(t, deepalert.ReportID("r-published"), reports[0].ID)
+	})
+
+	t.Run("watch", func(t *testing.T) {
+		args, _ := setupReports(t)
+		var buf bytes.Buffer
+		app := newApp(args)
+		app.Writer = &buf
+
+		err := app.Run([]string{"deepalert", "report", "watch", "--interval", "10ms", "--timeout", "30ms", "r-new"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not published")
+
+		require.NoError(t, app.Run([]string{"deepalert", "report", "watch", "--interval", "10ms", "--timeout", "1s", "--format", "json", "r-published"}))
+		assert.Contains(t, buf.String(), `"status": "published"`)
+	})
+}
+
+func TestInspectRun(t *testing.T) {
+	var buf bytes.Buffer
+	app := newApp(&handler.Arguments{})
+	app.Writer = &buf
+	app.ErrWriter = &bytes.Buffer{}
+
+	require.NoError(t, app.Run([]string{"deepalert", "inspect", "run",
+		"--type", "ipaddr", "--value", "10.0.0.1", "--context", "remote", "--format", "json",
+		"--", os.Args[0]}))
+
+	var outputs []*inspector.LocalOutput
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &outputs))
+	require.Equal(t, 2, len(outputs))
+	require.NotNil(t, outputs[0].Finding)
+	assert.Equal(t, "localInspector", outputs[0].Finding.Author)
+	assert.Equal(t, deepalert.AttrContexts{deepalert.CtxRemote}, outputs[0].Finding.Attribute.Context)
+	require.NotNil(t, outputs[1].Attribute)
+	assert.Equal(t, "host-of-10.0.0.1", outputs[1].Attribute.Attributes[0].Value)
+}
+
+func TestSource(t *testing.T) {
+	dummyRepo := mock.NewRepository("", "")
+	args := &handler.Arguments{
+		NewRepository: func(string, string) adaptor.Repository { return dummyRepo },
+	}
+	repo, err := args.Repository()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	app := newApp(args)
+	app.Writer = &buf
+
+	t.Run("add source with API key", func(t *testing.T) {
+		buf.Reset()
+		require.NoError(t, app.Run([]string{"deepalert", "source", "add", "--table", "t", "--quota", "100", "blue"}))
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Equal(t, 2, len(lines))
+		key := strings.TrimPrefix(lines[1], "api_key\t")
+
+		source, err := repo.GetIngestionSource("blue")
+		require.NoError(t, err)
+		require.NotNil(t, source)
+		assert.Equal(t, api.HashAPIKey(key), source.APIKeyHash)
+		assert.Equal(t, int64(100), source.QuotaPerMinute)
+		assert.Equal(t, "", source.HMACSecret)
+	})
+
+	t.Run("add source with HMAC secret", func(t *testing.T) {
+		buf.Reset()
+		require.NoError(t, app.Run([]string{"deepalert", "source", "add", "--table", "t", "--hmac", "orange"}))
+		assert.Contains(t, buf.String(), "hmac_secret\t")
+
+		source, err := repo.GetIngestionSource("orange")
+		require.NoError(t, err)
+		require.NotNil(t, source)
+		assert.NotEqual(t, "", source.HMACSecret)
+		assert.Equal(t, "", source.APIKeyHash)
+	})
+
+	t.Run("delete source", func(t *testing.T) {
+		require.NoError(t, app.Run([]string{"deepalert", "source", "delete", "--table", "t", "blue"}))
+		source, err := repo.GetIngestionSource("blue")
+		require.NoError(t, err)
+		assert.Nil(t, source)
+	})
+
+	t.Run("NAME is required", func(t *testing.T) {
+		assert.Error(t, app.Run([]string{"deepalert", "source", "add", "--table", "t"}))
+	})
+}
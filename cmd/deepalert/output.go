@@ -0,0 +1,12 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+)
+
+func printJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
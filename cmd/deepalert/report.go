@@ -0,0 +1,261 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/cookpad/deepalert/internal/service"
+	"github.com/urfave/cli/v2"
+)
+
+func newReportCommand(args *handler.Arguments) *cli.Command {
+	tableFlag := &cli.StringFlag{
+		Name:        "table",
+		Usage:       "Name of DeepAlert cache table",
+		Value:       args.CacheTable,
+		Destination: &args.CacheTable,
+	}
+	formatFlag := &cli.StringFlag{
+		Name:    "format",
+		Aliases: []string{"f"},
+		Usage:   "Output format (table or json)",
+		Value:   "table",
+	}
+
+	repository := func() (*service.RepositoryService, error) {
+		if args.CacheTable == "" {
+			return nil, errors.New("--table or CACHE_TABLE is required")
+		}
+		return args.Repository()
+	}
+
+	return &cli.Command{
+		Name:  "report",
+		Usage: "Show reports",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "get",
+				Usage:     "Show a compiled report",
+				ArgsUsage: "REPORT_ID",
+				Flags:     []cli.Flag{tableFlag, formatFlag},
+				Action: func(c *cli.Context) error {
+					reportID := deepalert.ReportID(c.Args().First())
+					if reportID == deepalert.NullReportID {
+						return errors.New("REPORT_ID is required")
+					}
+
+					repo, err := repository()
+					if err != nil {
+						return err
+					}
+					report, err := repo.GetReport(reportID)
+					if err != nil {
+						return err
+					}
+					if report == nil {
+						return fmt.Errorf("report is not found: %s", reportID)
+					}
+
+					return printReport(c.App.Writer, c.String("format"), report)
+				},
+			},
+			{
+				Name:      "watch",
+				Usage:     "Wait until the report is published and show it",
+				ArgsUsage: "REPORT_ID",
+				Flags: []cli.Flag{tableFlag, formatFlag,
+					&cli.DurationFlag{Name: "interval", Usage: "Polling interval", Value: 10 * time.Second},
+					&cli.DurationFlag{Name: "timeout", Usage: "Give up waiting after the duration", Value: 30 * time.Minute},
+				},
+				Action: func(c *cli.Context) error {
+					reportID := deepalert.ReportID(c.Args().First())
+					if reportID == deepalert.NullReportID {
+						return errors.New("REPORT_ID is required")
+					}
+
+					repo, err := repository()
+					if err != nil {
+						return err
+					}
+
+					report, err := watchReport(repo, reportID, c.Duration("interval"), c.Duration("timeout"))
+					if err != nil {
+						return err
+					}
+
+					return printReport(c.App.Writer, c.String("format"), report)
+				},
+			},
+			{
+				Name:  "list",
+				Usage: "Show reports in cache table",
+				Flags: []cli.Flag{tableFlag, formatFlag,
+					&cli.StringFlag{Name: "status", Usage: "Show only reports with the status (new, more or published)"},
+					&cli.IntFlag{Name: "limit", Aliases: []string{"n"}, Usage: "Maximum number of reports, 0 means no limit", Value: 20},
+				},
+				Action: func(c *cli.Context) error {
+					repo, err := repository()
+					if err != nil {
+						return err
+					}
+					reports, err := repo.ListReports()
+					if err != nil {
+						return err
+					}
+
+					var selected []*deepalert.Report
+					for _, report := range reports {
+						if status := c.String("status"); status != "" && string(report.Status) != status {
+							continue
+						}
+						selected = append(selected, report)
+						if limit := c.Int("limit"); limit > 0 && len(selected) >= limit {
+							break
+						}
+					}
+
+					return printReportList(c.App.Writer, c.String("format"), selected)
+				},
+			},
+		},
+	}
+}
+
+func watchReport(repo *service.RepositoryService, reportID deepalert.ReportID, interval, timeout time.Duration) (*deepalert.Report, error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		report, err := repo.GetReport(reportID)
+		if err != nil {
+			return nil, err
+		}
+		if report != nil && report.IsPublished() {
+			return report, nil
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			return nil, fmt.Errorf("report is not published in %s: %s", timeout, reportID)
+		}
+		time.Sleep(interval)
+	}
+}
+
+func printReport(w io.Writer, format string, report *deepalert.Report) error {
+	switch format {
+	case "json":
+		return printJSON(w, report)
+	case "table":
+		return printReportTable(w, report)
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+func printReportTable(w io.Writer, report *deepalert.Report) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "ID:\t%s\n", report.ID)
+	fmt.Fprintf(tw, "Status:\t%s\n", report.Status)
+	fmt.Fprintf(tw, "Severity:\t%s\n", report.Result.Severity)
+	if report.Result.Level != "" {
+		fmt.Fprintf(tw, "Risk:\t%s (%d)\n", report.Result.Level, report.Result.Score)
+	}
+	fmt.Fprintf(tw, "Reason:\t%s\n", report.Result.Reason)
+	fmt.Fprintf(tw, "CreatedAt:\t%s\n", report.CreatedAt.Format(time.RFC3339))
+
+	if len(report.Reviews) > 0 {
+		fmt.Fprintf(tw, "\nREVIEWS (%d)\n", len(report.Reviews))
+		fmt.Fprintln(tw, "REVIEWER\tSEVERITY\tREASON")
+		for _, review := range report.Reviews {
+			if review.Result == nil {
+				fmt.Fprintf(tw, "%s\t-\t-\n", review.Reviewer)
+				continue
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", review.Reviewer, review.Result.Severity, review.Result.Reason)
+		}
+	}
+
+	fmt.Fprintf(tw, "\nALERTS (%d)\n", len(report.Alerts))
+	fmt.Fprintln(tw, "DETECTOR\tRULE ID\tRULE NAME\tTIMESTAMP")
+	for _, alert := range report.Alerts {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", alert.Detector, alert.RuleID, alert.RuleName, alert.Timestamp.Format(time.RFC3339))
+	}
+
+	fmt.Fprintf(tw, "\nATTRIBUTES (%d)\n", len(report.Attributes))
+	fmt.Fprintln(tw, "TYPE\tKEY\tVALUE\tCONTEXT")
+	for _, attr := range report.Attributes {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", attr.Type, attr.Key, attr.Value, joinContexts(attr.Context))
+	}
+
+	fmt.Fprintf(tw, "\nSECTIONS (%d)\n", len(report.Sections))
+	fmt.Fprintln(tw, "TYPE\tVALUE\tCONTENTS")
+	for _, section := range report.Sections {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", section.Attr.Type, section.Attr.Value, summarizeSection(section))
+	}
+
+	return tw.Flush()
+}
+
+func summarizeSection(section *deepalert.Section) string {
+	counts := []struct {
+		name string
+		n    int
+	}{
+		{"users", len(section.Users)},
+		{"hosts", len(section.Hosts)},
+		{"binaries", len(section.Binaries)},
+		{"cloud_resources", len(section.CloudResources)},
+		{"vulnerabilities", len(section.Vulnerabilities)},
+		{"threat_intel", len(section.ThreatIntel)},
+		{"process_trees", len(section.ProcessTrees)},
+		{"geolocations", len(section.Geolocations)},
+		{"custom", len(section.Custom)},
+	}
+
+	var parts []string
+	for _, c := range counts {
+		if c.n > 0 {
+			parts = append(parts, fmt.Sprintf("%s:%d", c.name, c.n))
+		}
+	}
+	for _, inspection := range section.Inspections {
+		if inspection.Status == deepalert.FindingFailed {
+			parts = append(parts, fmt.Sprintf("failed:%s", inspection.Author))
+		}
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, " ")
+}
+
+func printReportList(w io.Writer, format string, reports []*deepalert.Report) error {
+	switch format {
+	case "json":
+		return printJSON(w, reports)
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tSTATUS\tSEVERITY\tCREATED AT\tREASON")
+		for _, report := range reports {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", report.ID, report.Status, report.Result.Severity,
+				report.CreatedAt.Format(time.RFC3339), report.Result.Reason)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+func joinContexts(contexts deepalert.AttrContexts) string {
+	var s []string
+	for _, ctx := range contexts {
+		s = append(s, string(ctx))
+	}
+	return strings.Join(s, ",")
+}
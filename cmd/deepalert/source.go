@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cookpad/deepalert/internal/api"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/cookpad/deepalert/internal/models"
+	"github.com/cookpad/deepalert/internal/service"
+	"github.com/urfave/cli/v2"
+)
+
+func newSourceCommand(args *handler.Arguments) *cli.Command {
+	tableFlag := &cli.StringFlag{
+		Name:        "table",
+		Usage:       "Name of DeepAlert cache table",
+		Value:       args.CacheTable,
+		Destination: &args.CacheTable,
+	}
+
+	repository := func() (*service.RepositoryService, error) {
+		if args.CacheTable == "" {
+			return nil, errors.New("--table or CACHE_TABLE is required")
+		}
+		return args.Repository()
+	}
+
+	return &cli.Command{
+		Name:  "source",
+		Usage: "Manage sources of HTTP ingestion API",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "add",
+				Usage:     "Register a source and show its credential. Existing source with the same name is replaced",
+				ArgsUsage: "NAME",
+				Flags: []cli.Flag{
+					tableFlag,
+					&cli.BoolFlag{Name: "hmac", Usage: "Issue HMAC secret instead of API key"},
+					&cli.Int64Flag{Name: "quota", Usage: "Maximum number of alerts per minute, 0 means no limit"},
+				},
+				Action: func(c *cli.Context) error {
+					name := c.Args().First()
+					if name == "" {
+						return errors.New("NAME is required")
+					}
+					if c.Int64("quota") < 0 {
+						return errors.New("--quota must not be negative")
+					}
+
+					repo, err := repository()
+					if err != nil {
+						return err
+					}
+
+					secret, err := api.NewSecret()
+					if err != nil {
+						return err
+					}
+					source := &models.IngestionSource{
+						Name:           name,
+						QuotaPerMinute: c.Int64("quota"),
+						CreatedAt:      time.Now().UTC().Unix(),
+					}
+					label := "api_key"
+					if c.Bool("hmac") {
+						source.HMACSecret = secret
+						label = "hmac_secret"
+					} else {
+						source.APIKeyHash = api.HashAPIKey(secret)
+					}
+
+					if err := repo.PutIngestionSource(source); err != nil {
+						return err
+					}
+					fmt.Fprintf(c.App.Writer, "source\t%s\n%s\t%s\n", name, label, secret)
+					return nil
+				},
+			},
+			{
+				Name:      "delete",
+				Usage:     "Delete a source",
+				ArgsUsage: "NAME",
+				Flags:     []cli.Flag{tableFlag},
+				Action: func(c *cli.Context) error {
+					name := c.Args().First()
+					if name == "" {
+						return errors.New("NAME is required")
+					}
+
+					repo, err := repository()
+					if err != nil {
+						return err
+					}
+					return repo.DeleteIngestionSource(name)
+				},
+			},
+			{
+				Name:  "serve",
+				Usage: "Run HTTP ingestion API as standalone server",
+				Flags: []cli.Flag{
+					tableFlag,
+					&cli.StringFlag{Name: "addr", Usage: "Listen address", Value: ":8080"},
+				},
+				Action: func(c *cli.Context) error {
+					if _, err := repository(); err != nil {
+						return err
+					}
+					fmt.Fprintf(c.App.Writer, "listening on %s\n", c.String("addr"))
+					return http.ListenAndServe(c.String("addr"), api.NewHandler(args))
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,267 @@
+package deepalert
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/m-mizutani/golambda"
+)
+
+// ContentDecoder decodes JSON of Finding.Content into ReportContent.
+type ContentDecoder func(raw []byte) (ReportContent, error)
+
+// NewJSONContentDecoder returns ContentDecoder that unmarshals JSON into a value created by newContent.
+// newContent must return a pointer, e.g. func() ReportContent { return &MyContent{} }.
+func NewJSONContentDecoder(newContent func() ReportContent) ContentDecoder {
+	return func(raw []byte) (ReportContent, error) {
+		content := newContent()
+		if err := json.Unmarshal(raw, content); err != nil {
+			return nil, golambda.WrapError(err, "Fail to unmarshal content").With("type", content.Type())
+		}
+		return content, nil
+	}
+}
+
+var contentRegistry = struct {
+	sync.RWMutex
+	decoders map[ReportContentType]ContentDecoder
+	builtin  map[ReportContentType]bool
+}{
+	decoders: map[ReportContentType]ContentDecoder{},
+	builtin:  map[ReportContentType]bool{},
+}
+
+func registerBuiltinContent(newContent func() ReportContent) {
+	contentType := newContent().Type()
+	contentRegistry.decoders[contentType] = NewJSONContentDecoder(newContent)
+	contentRegistry.builtin[contentType] = true
+}
+
+func init() {
+	registerBuiltinContent(func() ReportContent { return &ContentUser{} })
+	registerBuiltinContent(func() ReportContent { return &ContentHost{} })
+	registerBuiltinContent(func() ReportContent { return &ContentBinary{} })
+	registerBuiltinContent(func() ReportContent { return &ContentCloudResource{} })
+	registerBuiltinContent(func() ReportContent { return &ContentVulnerability{} })
+	registerBuiltinContent(func() ReportContent { return &ContentThreatIntel{} })
+	registerBuiltinContent(func() ReportContent { return &ContentProcessTree{} })
+	registerBuiltinContent(func() ReportContent { return &ContentGeolocation{} })
+}
+
+// RegisterContentType registers a custom content type and its decoder. Findings of the type are kept in
+// Section.Custom as decoded ReportContent. A built-in type can not be overridden, and registering the same
+// custom type again replaces the decoder.
+func RegisterContentType(contentType ReportContentType, decode ContentDecoder) error {
+	if contentType == "" || decode == nil {
+		return golambda.NewError("Content type and decoder are required").With("type", contentType)
+	}
+
+	contentRegistry.Lock()
+	defer contentRegistry.Unlock()
+	if contentRegistry.builtin[contentType] {
+		return golambda.NewError("Built-in content type can not be overridden").With("type", contentType)
+	}
+	contentRegistry.decoders[contentType] = decode
+	return nil
+}
+
+// UnregisterContentType removes a custom content type. It's mainly for testing.
+func UnregisterContentType(contentType ReportContentType) {
+	contentRegistry.Lock()
+	defer contentRegistry.Unlock()
+	if !contentRegistry.builtin[contentType] {
+		delete(contentRegistry.decoders, contentType)
+	}
+}
+
+// IsBuiltinContentType returns true if contentType is defined by deepalert package.
+func IsBuiltinContentType(contentType ReportContentType) bool {
+	contentRegistry.RLock()
+	defer contentRegistry.RUnlock()
+	return contentRegistry.builtin[contentType]
+}
+
+// DecodeContent decodes JSON of content by registered decoder of contentType. Content of unregistered type is
+// returned as *ContentRaw without error.
+func DecodeContent(contentType ReportContentType, raw []byte) (ReportContent, error) {
+	contentRegistry.RLock()
+	decode, ok := contentRegistry.decoders[contentType]
+	contentRegistry.RUnlock()
+
+	if !ok {
+		return &ContentRaw{ContentType: contentType, Data: append(json.RawMessage{}, raw...)}, nil
+	}
+	return decode(raw)
+}
+
+// ContentRaw keeps content of unregistered type as is.
+type ContentRaw struct {
+	ContentType ReportContentType
+	Data        json.RawMessage
+}
+
+// Type of ContentRaw returns original type of the content
+func (x *ContentRaw) Type() ReportContentType {
+	return x.ContentType
+}
+
+// MarshalJSON outputs the original content
+func (x *ContentRaw) MarshalJSON() ([]byte, error) {
+	if len(x.Data) == 0 {
+		return []byte("null"), nil
+	}
+	return x.Data, nil
+}
+
+// SectionContent is a content of custom or unknown type in Section.
+type SectionContent struct {
+	Author  string            `json:"author,omitempty"`
+	Type    ReportContentType `json:"type"`
+	Content ReportContent     `json:"content"`
+}
+
+// UnmarshalJSON decodes Content by registered decoder of Type.
+func (x *SectionContent) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Author  string            `json:"author"`
+		Type    ReportContentType `json:"type"`
+		Content json.RawMessage   `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	content, err := DecodeContent(raw.Type, raw.Content)
+	if err != nil {
+		return err
+	}
+
+	x.Author, x.Type, x.Content = raw.Author, raw.Type, content
+	return nil
+}
+
+// Contents returns all contents of the section, built-in types first in order of fields and then Custom.
+// Author of a built-in content is set only if the content has Provenance because Section does not keep it.
+func (x *Section) Contents() []*SectionContent {
+	var contents []*SectionContent
+	add := func(content ReportContent, index int) {
+		c := &SectionContent{Type: content.Type(), Content: content}
+		if p := x.ProvenanceOf(c.Type, index); p != nil {
+			c.Author = p.Author
+		}
+		contents = append(contents, c)
+	}
+
+	for i, c := range x.Users {
+		add(c, i)
+	}
+	for i, c := range x.Hosts {
+		add(c, i)
+	}
+	for i, c := range x.Binaries {
+		add(c, i)
+	}
+	for i, c := range x.CloudResources {
+		add(c, i)
+	}
+	for i, c := range x.Vulnerabilities {
+		add(c, i)
+	}
+	for i, c := range x.ThreatIntel {
+		add(c, i)
+	}
+	for i, c := range x.ProcessTrees {
+		add(c, i)
+	}
+	for i, c := range x.Geolocations {
+		add(c, i)
+	}
+	return append(contents, x.Custom...)
+}
+
+// UnmarshalJSON decodes Content into concrete ReportContent by registered decoder of Type. Content of
+// unregistered type is set as *ContentRaw.
+func (x *Finding) UnmarshalJSON(data []byte) error {
+	type finding Finding
+	var raw struct {
+		*finding
+		Content json.RawMessage `json:"content"`
+	}
+	raw.finding = (*finding)(x)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if !x.Status.hasContent() {
+		x.Content = nil
+		return nil
+	}
+	if len(raw.Content) == 0 {
+		raw.Content = json.RawMessage("null")
+	}
+	content, err := DecodeContent(x.Type, raw.Content)
+	if err != nil {
+		return err
+	}
+	x.Content = content
+	return nil
+}
+
+// ReportContent returns Content as ReportContent. Content that is not ReportContent (e.g. a map or a value
+// type built in code) is converted via JSON by registered decoder of Type.
+func (x *Finding) ReportContent() (ReportContent, error) {
+	if content, ok := x.Content.(ReportContent); ok {
+		return content, nil
+	}
+
+	raw, err := json.Marshal(x.Content)
+	if err != nil {
+		return nil, golambda.WrapError(err, "Fail to marshal content").With("type", x.Type)
+	}
+	return DecodeContent(x.Type, raw)
+}
+
+func contentAs[T any](content interface{}) (*T, bool) {
+	switch v := content.(type) {
+	case *T:
+		return v, v != nil
+	case T:
+		return &v, true
+	default:
+		return nil, false
+	}
+}
+
+// AsUser returns Content as *ContentUser. It returns false if Content is not ContentUser.
+func (x *Finding) AsUser() (*ContentUser, bool) { return contentAs[ContentUser](x.Content) }
+
+// AsHost returns Content as *ContentHost. It returns false if Content is not ContentHost.
+func (x *Finding) AsHost() (*ContentHost, bool) { return contentAs[ContentHost](x.Content) }
+
+// AsBinary returns Content as *ContentBinary. It returns false if Content is not ContentBinary.
+func (x *Finding) AsBinary() (*ContentBinary, bool) { return contentAs[ContentBinary](x.Content) }
+
+// AsCloudResource returns Content as *ContentCloudResource. It returns false if Content is not ContentCloudResource.
+func (x *Finding) AsCloudResource() (*ContentCloudResource, bool) {
+	return contentAs[ContentCloudResource](x.Content)
+}
+
+// AsVulnerability returns Content as *ContentVulnerability. It returns false if Content is not ContentVulnerability.
+func (x *Finding) AsVulnerability() (*ContentVulnerability, bool) {
+	return contentAs[ContentVulnerability](x.Content)
+}
+
+// AsThreatIntel returns Content as *ContentThreatIntel. It returns false if Content is not ContentThreatIntel.
+func (x *Finding) AsThreatIntel() (*ContentThreatIntel, bool) {
+	return contentAs[ContentThreatIntel](x.Content)
+}
+
+// AsProcessTree returns Content as *ContentProcessTree. It returns false if Content is not ContentProcessTree.
+func (x *Finding) AsProcessTree() (*ContentProcessTree, bool) {
+	return contentAs[ContentProcessTree](x.Content)
+}
+
+// AsGeolocation returns Content as *ContentGeolocation. It returns false if Content is not ContentGeolocation.
+func (x *Finding) AsGeolocation() (*ContentGeolocation, bool) {
+	return contentAs[ContentGeolocation](x.Content)
+}
@@ -0,0 +1,224 @@
+package deepalert_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	da "github.com/cookpad/deepalert"
+)
+
+type contentTicket struct {
+	ID string `json:"id"`
+}
+
+func (x *contentTicket) Type() da.ReportContentType { return "ticket" }
+
+func TestContentRegistry(t *testing.T) {
+	defer da.UnregisterContentType("ticket")
+
+	t.Run("unregistered type is decoded as raw content", func(t *testing.T) {
+		content, err := da.DecodeContent("ticket", []byte(`{"id":"SEC-1"}`))
+		require.NoError(t, err)
+		raw, ok := content.(*da.ContentRaw)
+		require.True(t, ok)
+		assert.Equal(t, da.ReportContentType("ticket"), raw.Type())
+
+		out, err := json.Marshal(raw)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id":"SEC-1"}`, string(out))
+	})
+
+	t.Run("registered type is decoded by decoder", func(t *testing.T) {
+		require.NoError(t, da.RegisterContentType("ticket", da.NewJSONContentDecoder(func() da.ReportContent { return &contentTicket{} })))
+		content, err := da.DecodeContent("ticket", []byte(`{"id":"SEC-1"}`))
+		require.NoError(t, err)
+		assert.Equal(t, &contentTicket{ID: "SEC-1"}, content)
+
+		_, err = da.DecodeContent("ticket", []byte(`[]`))
+		assert.Error(t, err)
+	})
+
+	t.Run("built-in type can not be overridden", func(t *testing.T) {
+		assert.True(t, da.IsBuiltinContentType(da.ContentTypeHost))
+		assert.Error(t, da.RegisterContentType(da.ContentTypeHost, da.NewJSONContentDecoder(func() da.ReportContent { return &da.ContentHost{} })))
+		assert.Error(t, da.RegisterContentType("", nil))
+
+		content, err := da.DecodeContent(da.ContentTypeHost, []byte(`{"hostname":["h1"]}`))
+		require.NoError(t, err)
+		assert.Equal(t, &da.ContentHost{HostName: []string{"h1"}}, content)
+	})
+
+	t.Run("section keeps custom contents through JSON", func(t *testing.T) {
+		require.NoError(t, da.RegisterContentType("ticket", da.NewJSONContentDecoder(func() da.ReportContent { return &contentTicket{} })))
+		section := da.Section{
+			Attr: da.Attribute{Type: da.TypeIPAddr, Key: "k", Value: "10.0.0.1"},
+			Custom: []*da.SectionContent{
+				{Author: "a1", Type: "ticket", Content: &contentTicket{ID: "SEC-1"}},
+				{Author: "a2", Type: "other", Content: &da.ContentRaw{ContentType: "other", Data: json.RawMessage(`{"v":true}`)}},
+			},
+		}
+
+		raw, err := json.Marshal(section)
+		require.NoError(t, err)
+		var decoded da.Section
+		require.NoError(t, json.Unmarshal(raw, &decoded))
+		require.Equal(t, 2, len(decoded.Custom))
+		assert.Equal(t, &contentTicket{ID: "SEC-1"}, decoded.Custom[0].Content)
+		assert.Equal(t, "a2", decoded.Custom[1].Author)
+		assert.JSONEq(t, `{"v":true}`, string(decoded.Custom[1].Content.(*da.ContentRaw).Data))
+	})
+}
+
+func TestBuiltinContents(t *testing.T) {
+	t.Run("new built-in types are decoded", func(t *testing.T) {
+		testCases := []struct {
+			contentType da.ReportContentType
+			raw         string
+			expected    da.ReportContent
+		}{
+			{
+				da.ContentTypeCloudResource,
+				`{"provider":"aws","account_id":"123456789012","tags":{"env":"prod"},"owner_team":"platform"}`,
+				&da.ContentCloudResource{Provider: "aws", AccountID: "123456789012", Tags: map[string]string{"env": "prod"}, OwnerTeam: "platform"},
+			},
+			{
+				da.ContentTypeVulnerability,
+				`{"cve":"CVE-2021-44228","cvss":{"version":"3.1","score":10},"package":{"name":"log4j-core","version":"2.14.1"}}`,
+				&da.ContentVulnerability{CVE: "CVE-2021-44228", CVSS: &da.EntityCVSS{Version: "3.1", Score: 10}, Package: &da.EntityPackage{Name: "log4j-core", Version: "2.14.1"}},
+			},
+			{
+				da.ContentTypeThreatIntel,
+				`{"feed":"f1","verdict":"malicious","confidence":80,"tags":["c2"]}`,
+				&da.ContentThreatIntel{Feed: "f1", Verdict: da.VerdictMalicious, Confidence: 80, Tags: []string{"c2"}},
+			},
+			{
+				da.ContentTypeProcessTree,
+				`{"hostname":"h1","processes":[{"pid":1,"name":"init"},{"pid":2,"parent_pid":1,"name":"sh"}]}`,
+				&da.ContentProcessTree{HostName: "h1", Processes: []da.EntityProcess{{PID: 1, Name: "init"}, {PID: 2, ParentPID: 1, Name: "sh"}}},
+			},
+			{
+				da.ContentTypeGeolocation,
+				`{"latitude":35.6895,"longitude":139.6917,"country":"JP"}`,
+				&da.ContentGeolocation{Latitude: 35.6895, Longitude: 139.6917, Country: "JP"},
+			},
+		}
+
+		for _, tc := range testCases {
+			assert.True(t, da.IsBuiltinContentType(tc.contentType))
+			content, err := da.DecodeContent(tc.contentType, []byte(tc.raw))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, content)
+			assert.Equal(t, tc.contentType, content.Type())
+		}
+	})
+
+	t.Run("process tree returns children", func(t *testing.T) {
+		tree := da.ContentProcessTree{
+			Processes: []da.EntityProcess{
+				{PID: 1, Name: "init"},
+				{PID: 10, ParentPID: 1, Name: "sshd"},
+				{PID: 11, ParentPID: 1, Name: "cron"},
+				{PID: 20, ParentPID: 10, Name: "bash"},
+			},
+		}
+		children := tree.Children(1)
+		require.Equal(t, 2, len(children))
+		assert.Equal(t, "sshd", children[0].Name)
+		assert.Equal(t, "cron", children[1].Name)
+		assert.Equal(t, 0, len(tree.Children(20)))
+	})
+}
+
+func TestFindingContent(t *testing.T) {
+	defer da.UnregisterContentType("ticket")
+	require.NoError(t, da.RegisterContentType("ticket", da.NewJSONContentDecoder(func() da.ReportContent { return &contentTicket{} })))
+
+	t.Run("built-in content is decoded into concrete type", func(t *testing.T) {
+		var finding da.Finding
+		require.NoError(t, json.Unmarshal([]byte(`{"report_id":"r1","author":"a1","type":"host","content":{"hostname":["h1"]}}`), &finding))
+		assert.Equal(t, da.ReportID("r1"), finding.ReportID)
+		assert.Equal(t, "a1", finding.Author)
+		assert.Equal(t, &da.ContentHost{HostName: []string{"h1"}}, finding.Content)
+
+		host, ok := finding.AsHost()
+		require.True(t, ok)
+		assert.Equal(t, []string{"h1"}, host.HostName)
+		_, ok = finding.AsUser()
+		assert.False(t, ok)
+	})
+
+	t.Run("custom and unknown contents are decoded", func(t *testing.T) {
+		var finding da.Finding
+		require.NoError(t, json.Unmarshal([]byte(`{"type":"ticket","content":{"id":"SEC-1"}}`), &finding))
+		assert.Equal(t, &contentTicket{ID: "SEC-1"}, finding.Content)
+
+		require.NoError(t, json.Unmarshal([]byte(`{"type":"other","content":{"v":1}}`), &finding))
+		raw, ok := finding.Content.(*da.ContentRaw)
+		require.True(t, ok)
+		assert.JSONEq(t, `{"v":1}`, string(raw.Data))
+	})
+
+	t.Run("invalid built-in content is error", func(t *testing.T) {
+		var finding da.Finding
+		assert.Error(t, json.Unmarshal([]byte(`{"type":"host","content":{"hostname":"h1"}}`), &finding))
+	})
+
+	t.Run("accessors accept value and pointer contents", func(t *testing.T) {
+		finding := da.Finding{Type: da.ContentTypeGeolocation, Content: da.ContentGeolocation{Country: "JP"}}
+		geo, ok := finding.AsGeolocation()
+		require.True(t, ok)
+		assert.Equal(t, "JP", geo.Country)
+
+		finding.Content = &da.ContentGeolocation{Country: "US"}
+		geo, ok = finding.AsGeolocation()
+		require.True(t, ok)
+		assert.Equal(t, "US", geo.Country)
+	})
+
+	t.Run("provenance is nested in JSON", func(t *testing.T) {
+		finding := da.Finding{Type: da.ContentTypeHost, Content: &da.ContentHost{}, Provenance: &da.Provenance{Confidence: 70}}
+		raw, err := json.Marshal(finding)
+		require.NoError(t, err)
+		assert.Contains(t, string(raw), `"provenance":{"confidence":70}`)
+
+		var decoded da.Finding
+		require.NoError(t, json.Unmarshal(raw, &decoded))
+		require.NotNil(t, decoded.Provenance)
+		assert.Equal(t, 70, decoded.Provenance.Confidence)
+
+		raw, err = json.Marshal(da.Finding{Type: da.ContentTypeHost, Content: &da.ContentHost{}})
+		require.NoError(t, err)
+		assert.NotContains(t, string(raw), "provenance")
+	})
+
+	t.Run("ReportContent converts content built in code", func(t *testing.T) {
+		finding := da.Finding{Type: da.ContentTypeHost, Content: map[string]interface{}{"hostname": []string{"h1"}}}
+		content, err := finding.ReportContent()
+		require.NoError(t, err)
+		assert.Equal(t, &da.ContentHost{HostName: []string{"h1"}}, content)
+	})
+}
+
+func TestSectionContents(t *testing.T) {
+	section := da.Section{
+		Hosts:       []*da.ContentHost{{HostName: []string{"h1"}}},
+		ThreatIntel: []*da.ContentThreatIntel{{Feed: "f1"}, {Feed: "f2"}},
+		Custom:      []*da.SectionContent{{Author: "ticketing", Type: "ticket", Content: &contentTicket{ID: "T-1"}}},
+		Provenances: []*da.ContentProvenance{
+			{Type: da.ContentTypeThreatIntel, Index: 1, Author: "intel", Provenance: da.Provenance{Confidence: 50}},
+		},
+	}
+
+	contents := section.Contents()
+	require.Equal(t, 4, len(contents))
+	assert.Equal(t, da.ContentTypeHost, contents[0].Type)
+	assert.Equal(t, "", contents[0].Author)
+	assert.Equal(t, &da.ContentThreatIntel{Feed: "f1"}, contents[1].Content)
+	assert.Equal(t, "", contents[1].Author)
+	assert.Equal(t, "intel", contents[2].Author)
+	assert.Equal(t, da.ReportContentType("ticket"), contents[3].Type)
+	assert.Equal(t, "ticketing", contents[3].Author)
+}
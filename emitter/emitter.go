@@ -1,11 +1,15 @@
-// Package emitter provides utilities for consuming DeepAlert reports from SNS events.
+// Package emitter provides utilities for consuming DeepAlert reports from SNS events and encoding them for
+// external systems.
 package emitter
 
 import (
+	"bytes"
 	"encoding/json"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/export/ocsf"
+	"github.com/cookpad/deepalert/export/stix"
 	"github.com/m-mizutani/golambda"
 )
 
@@ -24,3 +28,74 @@ func SNSEventToReport(event events.SNSEvent) ([]*deepalert.Report, error) {
 
 	return reports, nil
 }
+
+// Format is output format of a report for external systems.
+type Format string
+
+const (
+	// FormatJSON is JSON of deepalert.Report as it is. It's default format.
+	FormatJSON Format = "json"
+	// FormatSTIX is STIX 2.1 bundle
+	FormatSTIX Format = "stix"
+	// FormatOCSF is OCSF Detection Finding events in newline delimited JSON, one event per alert. A report
+	// without alert is error.
+	FormatOCSF Format = "ocsf"
+)
+
+// ErrInvalidFormat means the format is not one of FormatJSON, FormatSTIX and FormatOCSF.
+var ErrInvalidFormat = golambda.NewError("Invalid output format")
+
+// ErrNoAlert means the report has no alert to be converted to OCSF events.
+var ErrNoAlert = golambda.NewError("Report has no alert")
+
+// ParseFormat returns Format of s. Empty s means FormatJSON.
+func ParseFormat(s string) (Format, error) {
+	switch format := Format(s); format {
+	case "":
+		return FormatJSON, nil
+	case FormatJSON, FormatSTIX, FormatOCSF:
+		return format, nil
+	default:
+		return "", golambda.WrapError(ErrInvalidFormat, s)
+	}
+}
+
+// Marshal encodes report in format to send it to threat intelligence platform, data lake, etc.
+func Marshal(report *deepalert.Report, format Format) ([]byte, error) {
+	switch format {
+	case "", FormatJSON:
+		raw, err := json.Marshal(report)
+		if err != nil {
+			return nil, golambda.WrapError(err, "Fail to marshal report").With("report.ID", report.ID)
+		}
+		return raw, nil
+
+	case FormatSTIX:
+		bundle, err := stix.NewBundle(report)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := json.Marshal(bundle)
+		if err != nil {
+			return nil, golambda.WrapError(err, "Fail to marshal STIX bundle").With("report.ID", report.ID)
+		}
+		return raw, nil
+
+	case FormatOCSF:
+		// No event means nothing to send, and it should not be ignored silently
+		if len(report.Alerts) == 0 {
+			return nil, golambda.WrapError(ErrNoAlert, "Fail to convert report to OCSF events").With("report.ID", report.ID)
+		}
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		for _, event := range ocsf.NewDetectionFindings(report) {
+			if err := encoder.Encode(event); err != nil {
+				return nil, golambda.WrapError(err, "Fail to marshal OCSF event").With("report.ID", report.ID)
+			}
+		}
+		return buf.Bytes(), nil
+
+	default:
+		return nil, golambda.WrapError(ErrInvalidFormat, string(format))
+	}
+}
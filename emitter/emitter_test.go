@@ -2,6 +2,7 @@ package emitter_test
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -54,3 +55,59 @@ func TestEmitter(t *testing.T) {
 		assert.Equal(t, 0, len(reports))
 	})
 }
+
+func TestMarshal(t *testing.T) {
+	report := &deepalert.Report{
+		ID: "r1",
+		Alerts: []*deepalert.Alert{
+			{Detector: "ids", RuleID: "r1", Attributes: []deepalert.Attribute{{Type: deepalert.TypeIPAddr, Value: "198.51.100.7"}}},
+			{Detector: "ids", RuleID: "r2"},
+		},
+		Result: deepalert.ReportResult{Severity: deepalert.SevUrgent},
+	}
+
+	t.Run("JSON by default", func(tt *testing.T) {
+		format, err := emitter.ParseFormat("")
+		require.NoError(tt, err)
+		raw, err := emitter.Marshal(report, format)
+		require.NoError(tt, err)
+		var decoded deepalert.Report
+		require.NoError(tt, json.Unmarshal(raw, &decoded))
+		assert.Equal(tt, deepalert.ReportID("r1"), decoded.ID)
+	})
+
+	t.Run("STIX bundle", func(tt *testing.T) {
+		raw, err := emitter.Marshal(report, emitter.FormatSTIX)
+		require.NoError(tt, err)
+		var decoded struct {
+			Type    string                   `json:"type"`
+			Objects []map[string]interface{} `json:"objects"`
+		}
+		require.NoError(tt, json.Unmarshal(raw, &decoded))
+		assert.Equal(tt, "bundle", decoded.Type)
+		assert.NotEqual(tt, 0, len(decoded.Objects))
+	})
+
+	t.Run("OCSF events in NDJSON", func(tt *testing.T) {
+		raw, err := emitter.Marshal(report, emitter.FormatOCSF)
+		require.NoError(tt, err)
+		lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+		require.Equal(tt, 2, len(lines))
+		var event map[string]interface{}
+		require.NoError(tt, json.Unmarshal([]byte(lines[0]), &event))
+		assert.Equal(tt, float64(2004), event["class_uid"])
+	})
+
+	t.Run("OCSF of report without alert is error", func(tt *testing.T) {
+		raw, err := emitter.Marshal(&deepalert.Report{ID: "r0"}, emitter.FormatOCSF)
+		assert.ErrorIs(tt, err, emitter.ErrNoAlert)
+		assert.Nil(tt, raw)
+	})
+
+	t.Run("invalid format", func(tt *testing.T) {
+		_, err := emitter.ParseFormat("csv")
+		assert.ErrorIs(tt, err, emitter.ErrInvalidFormat)
+		_, err = emitter.Marshal(report, "csv")
+		assert.ErrorIs(tt, err, emitter.ErrInvalidFormat)
+	})
+}
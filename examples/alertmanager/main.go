@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/alert"
+	"github.com/cookpad/deepalert/alert/alertmanager"
+)
+
+// Receive Alertmanager webhook and send alerts to alertQueue of DeepAlert.
+//
+//	receivers:
+//	  - name: deepalert
+//	    webhook_configs:
+//	      - url: http://localhost:8080/
+//	        http_config:
+//	          authorization:
+//	            credentials: <WEBHOOK_TOKEN>
+func main() {
+	config := alertmanager.Config{
+		// Convert labels of Prometheus alerts to attributes
+		Attributes: []alertmanager.AttrMapping{
+			{Label: "instance_ip", Type: deepalert.TypeIPAddr, Context: []deepalert.AttrContext{deepalert.CtxLocal}},
+			{Label: "remote_ip", Type: deepalert.TypeIPAddr, Context: []deepalert.AttrContext{deepalert.CtxRemote}},
+			{Annotation: "user", Type: deepalert.TypeUserName, Context: []deepalert.AttrContext{deepalert.CtxSubject}},
+		},
+	}
+	if path := os.Getenv("ALERTMANAGER_CONFIG"); path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := json.Unmarshal(raw, &config); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	client, err := alert.New(alert.Arguments{QueueURL: os.Getenv("DEEPALERT_ALERT_QUEUE")})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	handler, err := alertmanager.NewHandler(config, client, os.Getenv("WEBHOOK_TOKEN"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	addr := os.Getenv("LISTEN_ADDR")
+	if addr == "" {
+		addr = ":8080"
+	}
+	log.Fatal(http.ListenAndServe(addr, handler))
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
+	"os"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
@@ -10,6 +12,11 @@ import (
 )
 
 func main() {
+	// OUTPUT_FORMAT is optional: "json" (default), "stix" or "ocsf"
+	format, err := emitter.ParseFormat(os.Getenv("OUTPUT_FORMAT"))
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	lambda.Start(func(ctx context.Context, event events.SNSEvent) error {
 		reports, err := emitter.SNSEventToReport(event)
@@ -20,6 +27,16 @@ func main() {
 		for _, report := range reports {
 			log.Println(report.Result.Severity)
 			// Or do appropriate action according to report content and severity
+
+			raw, err := emitter.Marshal(report, format)
+			if errors.Is(err, emitter.ErrNoAlert) {
+				log.Println("No OCSF event for report", report.ID)
+				continue
+			} else if err != nil {
+				return err
+			}
+			// Send raw to your threat intelligence platform or data lake
+			log.Println(string(raw))
 		}
 
 		return nil
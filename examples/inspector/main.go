@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"log"
 	"os"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -25,7 +26,8 @@ func Handler(ctx context.Context, attr deepalert.Attribute) (*deepalert.TaskResu
 	// Example
 	resp := lookupHostname(attr.Value)
 	if resp == nil {
-		return nil, nil
+		// Checked, but nothing found
+		return &deepalert.TaskResult{}, nil
 	}
 
 	result := deepalert.TaskResult{
@@ -40,6 +42,17 @@ func Handler(ctx context.Context, attr deepalert.Attribute) (*deepalert.TaskResu
 }
 
 func main() {
+	// Run with a task given by `deepalert inspect run` for local testing
+	if inspector.IsLocal() {
+		if err := inspector.StartLocal(inspector.Arguments{
+			Handler: Handler,
+			Author:  "testInspector",
+		}); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	lambda.Start(func(ctx context.Context, event events.SNSEvent) error {
 		tasks, err := inspector.SNSEventToTasks(event)
 		if err != nil {
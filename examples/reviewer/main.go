@@ -20,6 +20,11 @@ func evaluate(ctx context.Context, report deepalert.Report) (*deepalert.ReportRe
 		}
 	}
 
+	// Do not evaluate the alert as safe if inspection of asset inventory failed
+	if len(report.FailedInspections("assetInventory")) > 0 {
+		return nil, nil
+	}
+
 	// Extract results of Inspector
 	for _, section := range report.Sections {
 		for _, host := range section.Hosts {
@@ -29,6 +29,26 @@ func TestEvaluateAlert(t *testing.T) {
 		assert.Equal(tt, deepalert.SevSafe, result.Severity)
 	})
 
+	t.Run("Return nil if inspection of asset inventory failed", func(tt *testing.T) {
+		report := deepalert.Report{
+			Alerts: []*deepalert.Alert{{RuleID: "your_alert_rule_id"}},
+			Sections: []*deepalert.Section{
+				{
+					Hosts: []*deepalert.ContentHost{
+						{Owner: []string{"YOUR_COMPANY"}},
+					},
+					Inspections: []*deepalert.InspectionStatus{
+						{Author: "assetInventory", Status: deepalert.FindingFailed, Error: "timeout"},
+					},
+				},
+			},
+		}
+
+		result, err := evaluate(context.Background(), report)
+		require.NoError(tt, err)
+		require.Nil(tt, result)
+	})
+
 	t.Run("Return nil for an alert with your_alert_rule_id but not YOUR_COMPANY PC", func(tt *testing.T) {
 		report := deepalert.Report{
 			Alerts: []*deepalert.Alert{{RuleID: "your_alert_rule_id"}},
@@ -0,0 +1,330 @@
+// Package ocsf converts deepalert.Report to OCSF Detection Finding events (class 2004) for data lakes.
+//
+// One event is generated per alert of the report. Attributes of the alert are observables, and contents of
+// sections (findings) for the attributes are enrichments. Severity and risk come from the result of the report.
+package ocsf
+
+import (
+	"time"
+
+	"github.com/cookpad/deepalert"
+)
+
+// SchemaVersion is version of OCSF schema of events
+const SchemaVersion = "1.1.0"
+
+const (
+	// CategoryFindings is category_uid of Findings
+	CategoryFindings = 2
+	// ClassDetectionFinding is class_uid of Detection Finding
+	ClassDetectionFinding = 2004
+)
+
+// Activity IDs of Detection Finding
+const (
+	ActivityCreate = 1
+	ActivityUpdate = 2
+)
+
+// Status IDs of Detection Finding
+const (
+	StatusNew        = 1
+	StatusSuppressed = 3
+)
+
+// Severity IDs of OCSF
+const (
+	SeverityUnknown       = 0
+	SeverityInformational = 1
+	SeverityLow           = 2
+	SeverityMedium        = 3
+	SeverityHigh          = 4
+	SeverityCritical      = 5
+)
+
+// Observable type IDs of OCSF
+const (
+	ObservableHostname     = 1
+	ObservableIPAddress    = 2
+	ObservableMACAddress   = 3
+	ObservableUserName     = 4
+	ObservableEmailAddress = 5
+	ObservableURLString    = 6
+	ObservableFileName     = 7
+	ObservableHash         = 8
+	ObservableProcessName  = 9
+	ObservableResourceUID  = 10
+	ObservablePort         = 11
+	ObservableSubnet       = 12
+	ObservableOther        = 99
+)
+
+// Product is product of metadata.
+type Product struct {
+	Name       string `json:"name"`
+	VendorName string `json:"vendor_name"`
+}
+
+// Metadata is metadata of event. CorrelationUID is ID of deepalert.Report.
+type Metadata struct {
+	Version        string  `json:"version"`
+	Product        Product `json:"product"`
+	UID            string  `json:"uid,omitempty"`
+	CorrelationUID string  `json:"correlation_uid,omitempty"`
+	LoggedTime     int64   `json:"logged_time,omitempty"`
+}
+
+// Analytic is detection rule of the alert.
+type Analytic struct {
+	UID    string `json:"uid,omitempty"`
+	Name   string `json:"name,omitempty"`
+	TypeID int    `json:"type_id"`
+	Type   string `json:"type"`
+}
+
+// FindingInfo is information of the alert.
+type FindingInfo struct {
+	UID           string    `json:"uid"`
+	Title         string    `json:"title"`
+	Desc          string    `json:"desc,omitempty"`
+	CreatedTime   int64     `json:"created_time,omitempty"`
+	FirstSeenTime int64     `json:"first_seen_time,omitempty"`
+	LastSeenTime  int64     `json:"last_seen_time,omitempty"`
+	Analytic      *Analytic `json:"analytic,omitempty"`
+	DataSources   []string  `json:"data_sources,omitempty"`
+}
+
+// Observable is an attribute of the alert.
+type Observable struct {
+	Name   string `json:"name"`
+	TypeID int    `json:"type_id"`
+	Type   string `json:"type"`
+	Value  string `json:"value"`
+}
+
+// Enrichment is a content of section given by an inspector (Provider) for an attribute.
+type Enrichment struct {
+	Name     string      `json:"name"`
+	Value    string      `json:"value"`
+	Type     string      `json:"type"`
+	Provider string      `json:"provider,omitempty"`
+	Data     interface{} `json:"data"`
+}
+
+// DetectionFinding is OCSF Detection Finding event.
+type DetectionFinding struct {
+	ActivityID   int    `json:"activity_id"`
+	ActivityName string `json:"activity_name"`
+	CategoryUID  int    `json:"category_uid"`
+	CategoryName string `json:"category_name"`
+	ClassUID     int    `json:"class_uid"`
+	ClassName    string `json:"class_name"`
+	TypeUID      int    `json:"type_uid"`
+	TypeName     string `json:"type_name"`
+	Time         int64  `json:"time"`
+	SeverityID   int    `json:"severity_id"`
+	Severity     string `json:"severity"`
+	StatusID     int    `json:"status_id"`
+	Status       string `json:"status"`
+	Message      string `json:"message,omitempty"`
+
+	Metadata    Metadata      `json:"metadata"`
+	FindingInfo FindingInfo   `json:"finding_info"`
+	Observables []*Observable `json:"observables,omitempty"`
+	Enrichments []*Enrichment `json:"enrichments,omitempty"`
+
+	RiskScore   *int   `json:"risk_score,omitempty"`
+	RiskLevelID *int   `json:"risk_level_id,omitempty"`
+	RiskLevel   string `json:"risk_level,omitempty"`
+}
+
+func epochMillis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+var severityNames = map[int]string{
+	SeverityUnknown:       "Unknown",
+	SeverityInformational: "Informational",
+	SeverityLow:           "Low",
+	SeverityMedium:        "Medium",
+	SeverityHigh:          "High",
+	SeverityCritical:      "Critical",
+}
+
+// severityOf returns severity_id by RiskLevel of the result, or by ReportSeverity if the level is not set or
+// inconsistent with the severity given by the reviewer.
+func severityOf(result *deepalert.ReportResult) int {
+	level := result.Level
+	if result.Severity != "" && level.Severity() != result.Severity {
+		level = ""
+	}
+	switch level {
+	case deepalert.RiskInfo:
+		return SeverityInformational
+	case deepalert.RiskLow:
+		return SeverityLow
+	case deepalert.RiskMedium:
+		return SeverityMedium
+	case deepalert.RiskHigh:
+		return SeverityHigh
+	case deepalert.RiskCritical:
+		return SeverityCritical
+	}
+
+	switch result.Severity {
+	case deepalert.SevSafe:
+		return SeverityInformational
+	case deepalert.SevUrgent:
+		return SeverityHigh
+	default:
+		return SeverityUnknown
+	}
+}
+
+var riskLevelIDs = map[deepalert.RiskLevel]int{
+	deepalert.RiskInfo:     0,
+	deepalert.RiskLow:      1,
+	deepalert.RiskMedium:   2,
+	deepalert.RiskHigh:     3,
+	deepalert.RiskCritical: 4,
+}
+
+var riskLevelNames = map[deepalert.RiskLevel]string{
+	deepalert.RiskInfo:     "Info",
+	deepalert.RiskLow:      "Low",
+	deepalert.RiskMedium:   "Medium",
+	deepalert.RiskHigh:     "High",
+	deepalert.RiskCritical: "Critical",
+}
+
+var observableTypes = map[deepalert.AttrType]struct {
+	id   int
+	name string
+}{
+	deepalert.TypeIPAddr:          {ObservableIPAddress, "IP Address"},
+	deepalert.TypeDomainName:      {ObservableHostname, "Hostname"},
+	deepalert.TypeHostName:        {ObservableHostname, "Hostname"},
+	deepalert.TypeMACAddr:         {ObservableMACAddress, "MAC Address"},
+	deepalert.TypeUserName:        {ObservableUserName, "User Name"},
+	deepalert.TypeEmailAddress:    {ObservableEmailAddress, "Email Address"},
+	deepalert.TypeURL:             {ObservableURLString, "URL String"},
+	deepalert.TypeFilePath:        {ObservableFileName, "File Name"},
+	deepalert.TypeFileHashValue:   {ObservableHash, "Hash"},
+	deepalert.TypeCertFingerprint: {ObservableHash, "Hash"},
+	deepalert.TypeProcess:         {ObservableProcessName, "Process Name"},
+	deepalert.TypeCloudResource:   {ObservableResourceUID, "Resource UID"},
+	deepalert.TypePort:            {ObservablePort, "Port"},
+	deepalert.TypeCIDR:            {ObservableSubnet, "Subnet"},
+}
+
+func newObservable(attr *deepalert.Attribute) *Observable {
+	observable := &Observable{
+		Name:   attr.Key,
+		TypeID: ObservableOther,
+		Type:   "Other",
+		Value:  attr.Value,
+	}
+	if t, ok := observableTypes[attr.Type]; ok {
+		observable.TypeID, observable.Type = t.id, t.name
+	}
+	if observable.Name == "" {
+		observable.Name = string(attr.Type)
+	}
+	return observable
+}
+
+// NewDetectionFinding converts an alert of report to a Detection Finding event.
+func NewDetectionFinding(report *deepalert.Report, alert *deepalert.Alert) *DetectionFinding {
+	event := &DetectionFinding{
+		ActivityID:   ActivityUpdate,
+		ActivityName: "Update",
+		CategoryUID:  CategoryFindings,
+		CategoryName: "Findings",
+		ClassUID:     ClassDetectionFinding,
+		ClassName:    "Detection Finding",
+		Time:         epochMillis(alert.Timestamp),
+		SeverityID:   severityOf(&report.Result),
+		StatusID:     StatusNew,
+		Status:       "New",
+		Message:      report.Result.Reason,
+		Metadata: Metadata{
+			Version:        SchemaVersion,
+			Product:        Product{Name: "DeepAlert", VendorName: "Cookpad"},
+			UID:            string(report.ID) + ":" + alert.AlertID(),
+			CorrelationUID: string(report.ID),
+			LoggedTime:     epochMillis(report.CreatedAt),
+		},
+		FindingInfo: FindingInfo{
+			UID:           alert.AlertID(),
+			Title:         alert.RuleName,
+			Desc:          alert.Description,
+			CreatedTime:   epochMillis(report.CreatedAt),
+			FirstSeenTime: epochMillis(alert.Timestamp),
+			LastSeenTime:  epochMillis(alert.Timestamp),
+			Analytic:      &Analytic{UID: alert.RuleID, Name: alert.RuleName, TypeID: 1, Type: "Rule"},
+		},
+	}
+
+	if report.IsNew() {
+		event.ActivityID, event.ActivityName = ActivityCreate, "Create"
+	}
+	// Reviewer dismissed the alert
+	if report.IsPublished() && report.Result.Severity == deepalert.SevSafe {
+		event.StatusID, event.Status = StatusSuppressed, "Suppressed"
+	}
+	event.TypeUID = ClassDetectionFinding*100 + event.ActivityID
+	event.TypeName = "Detection Finding: " + event.ActivityName
+	event.Severity = severityNames[event.SeverityID]
+
+	if event.FindingInfo.Title == "" {
+		event.FindingInfo.Title = alert.RuleID
+	}
+	if alert.Detector != "" {
+		event.FindingInfo.DataSources = []string{alert.Detector}
+	}
+
+	if level := report.Result.Level; level != "" {
+		score, id := report.Result.Score, riskLevelIDs[level]
+		event.RiskScore, event.RiskLevelID, event.RiskLevel = &score, &id, riskLevelNames[level]
+	}
+
+	sections := map[string]*deepalert.Section{}
+	for _, section := range report.Sections {
+		sections[section.Attr.HashWith(deepalert.HashModeIdentity)] = section
+	}
+
+	for i := range alert.Attributes {
+		attr := &alert.Attributes[i]
+		observable := newObservable(attr)
+		event.Observables = append(event.Observables, observable)
+
+		section, ok := sections[attr.HashWith(deepalert.HashModeIdentity)]
+		if !ok {
+			continue
+		}
+		// Enrichments of the same attribute in multiple alerts are added to each event
+		for _, c := range section.Contents() {
+			event.Enrichments = append(event.Enrichments, &Enrichment{
+				Name:     observable.Name,
+				Value:    attr.Value,
+				Type:     string(c.Type),
+				Provider: c.Author,
+				Data:     c.Content,
+			})
+		}
+	}
+
+	return event
+}
+
+// NewDetectionFindings converts report to Detection Finding events, one per alert.
+func NewDetectionFindings(report *deepalert.Report) []*DetectionFinding {
+	var events []*DetectionFinding
+	for _, alert := range report.Alerts {
+		events = append(events, NewDetectionFinding(report, alert))
+	}
+	return events
+}
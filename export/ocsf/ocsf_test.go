@@ -0,0 +1,146 @@
+package ocsf_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/export/ocsf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleReport() *deepalert.Report {
+	createdAt := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	ip := deepalert.Attribute{Type: deepalert.TypeIPAddr, Key: "remote", Value: "198.51.100.7"}
+	user := deepalert.Attribute{Type: deepalert.TypeUserName, Value: "alice"}
+
+	return &deepalert.Report{
+		ID: "r1",
+		Alerts: []*deepalert.Alert{
+			{
+				Detector:    "ids",
+				RuleID:      "c2",
+				RuleName:    "C2 traffic",
+				Description: "Beacon to known C2",
+				Timestamp:   createdAt.Add(time.Minute),
+				Attributes:  []deepalert.Attribute{ip, user},
+			},
+			{
+				Detector:   "proxy",
+				RuleID:     "blocked",
+				Timestamp:  createdAt.Add(2 * time.Minute),
+				Attributes: []deepalert.Attribute{ip},
+			},
+		},
+		Sections: []*deepalert.Section{
+			{
+				Attr: ip,
+				ThreatIntel: []*deepalert.ContentThreatIntel{
+					{Feed: "f1", Verdict: deepalert.VerdictMalicious},
+				},
+				Provenances: []*deepalert.ContentProvenance{
+					{Type: deepalert.ContentTypeThreatIntel, Index: 0, Author: "intel", Provenance: deepalert.Provenance{Confidence: 90}},
+				},
+			},
+		},
+		Result:    deepalert.ReportResult{Severity: deepalert.SevUrgent, Reason: "Known C2", Score: 85, Level: deepalert.RiskCritical},
+		Status:    deepalert.StatusPublished,
+		CreatedAt: createdAt,
+	}
+}
+
+func TestNewDetectionFindings(t *testing.T) {
+	report := sampleReport()
+	events := ocsf.NewDetectionFindings(report)
+	require.Equal(t, 2, len(events))
+
+	event := events[0]
+	assert.Equal(t, ocsf.ClassDetectionFinding, event.ClassUID)
+	assert.Equal(t, ocsf.CategoryFindings, event.CategoryUID)
+	assert.Equal(t, ocsf.ActivityUpdate, event.ActivityID)
+	assert.Equal(t, 200402, event.TypeUID)
+	assert.Equal(t, ocsf.SeverityCritical, event.SeverityID)
+	assert.Equal(t, "Critical", event.Severity)
+	assert.Equal(t, ocsf.StatusNew, event.StatusID)
+	assert.Equal(t, "Known C2", event.Message)
+	assert.Equal(t, report.CreatedAt.Add(time.Minute).UnixMilli(), event.Time)
+
+	assert.Equal(t, "r1", event.Metadata.CorrelationUID)
+	assert.Equal(t, ocsf.SchemaVersion, event.Metadata.Version)
+	assert.Equal(t, report.Alerts[0].AlertID(), event.FindingInfo.UID)
+	assert.Equal(t, "C2 traffic", event.FindingInfo.Title)
+	assert.Equal(t, "c2", event.FindingInfo.Analytic.UID)
+	assert.Equal(t, []string{"ids"}, event.FindingInfo.DataSources)
+
+	require.NotNil(t, event.RiskScore)
+	assert.Equal(t, 85, *event.RiskScore)
+	require.NotNil(t, event.RiskLevelID)
+	assert.Equal(t, 4, *event.RiskLevelID)
+
+	require.Equal(t, 2, len(event.Observables))
+	assert.Equal(t, &ocsf.Observable{Name: "remote", TypeID: ocsf.ObservableIPAddress, Type: "IP Address", Value: "198.51.100.7"}, event.Observables[0])
+	assert.Equal(t, &ocsf.Observable{Name: "username", TypeID: ocsf.ObservableUserName, Type: "User Name", Value: "alice"}, event.Observables[1])
+
+	require.Equal(t, 1, len(event.Enrichments))
+	assert.Equal(t, "threat_intel", event.Enrichments[0].Type)
+	assert.Equal(t, "intel", event.Enrichments[0].Provider)
+	assert.Equal(t, "198.51.100.7", event.Enrichments[0].Value)
+
+	// Title falls back to rule ID, and enrichments are given to every alert with the attribute
+	assert.Equal(t, "blocked", events[1].FindingInfo.Title)
+	assert.Equal(t, 1, len(events[1].Enrichments))
+
+	raw, err := json.Marshal(event)
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	assert.Equal(t, float64(2004), decoded["class_uid"])
+	assert.Equal(t, "f1", decoded["enrichments"].([]interface{})[0].(map[string]interface{})["data"].(map[string]interface{})["feed"])
+}
+
+func TestDetectionFindingStatus(t *testing.T) {
+	t.Run("new report is created", func(t *testing.T) {
+		report := sampleReport()
+		report.Status = deepalert.StatusNew
+		report.Result = deepalert.ReportResult{}
+
+		event := ocsf.NewDetectionFinding(report, report.Alerts[0])
+		assert.Equal(t, ocsf.ActivityCreate, event.ActivityID)
+		assert.Equal(t, "Detection Finding: Create", event.TypeName)
+		assert.Equal(t, ocsf.SeverityUnknown, event.SeverityID)
+		assert.Nil(t, event.RiskScore)
+	})
+
+	t.Run("safe report is suppressed", func(t *testing.T) {
+		report := sampleReport()
+		report.Result = deepalert.ReportResult{Severity: deepalert.SevSafe, Reason: "Test host"}
+
+		event := ocsf.NewDetectionFinding(report, report.Alerts[0])
+		assert.Equal(t, ocsf.StatusSuppressed, event.StatusID)
+		assert.Equal(t, ocsf.SeverityInformational, event.SeverityID)
+	})
+
+	t.Run("severity given by reviewer takes precedence over inconsistent level", func(t *testing.T) {
+		report := sampleReport()
+		report.Result = deepalert.ReportResult{Severity: deepalert.SevSafe, Score: 85, Level: deepalert.RiskCritical}
+
+		event := ocsf.NewDetectionFinding(report, report.Alerts[0])
+		assert.Equal(t, ocsf.StatusSuppressed, event.StatusID)
+		assert.Equal(t, ocsf.SeverityInformational, event.SeverityID)
+	})
+}
+
+func TestSharedAttribute(t *testing.T) {
+	report := sampleReport()
+	// The same IP address observed at different time is one section of the report
+	ts1, ts2 := report.CreatedAt, report.CreatedAt.Add(time.Hour)
+	report.Alerts[0].Attributes[0].Timestamp = &ts1
+	report.Alerts[1].Attributes[0].Timestamp = &ts2
+
+	events := ocsf.NewDetectionFindings(report)
+	require.Equal(t, 2, len(events))
+	assert.Equal(t, 1, len(events[0].Enrichments))
+	assert.Equal(t, 1, len(events[1].Enrichments))
+}
@@ -0,0 +1,564 @@
+// Package stix converts deepalert.Report to a STIX 2.1 bundle for threat intelligence platforms.
+//
+// Attributes are converted to observables (SCO) and indicators, alerts to observed-data and sightings of the
+// indicators, and contents of sections (findings) to notes. All objects are referred by a report object that
+// has the verdict of the report. IDs are deterministic from the report, so exporting the same report again
+// updates objects in the platform instead of creating duplicates.
+package stix
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cookpad/deepalert"
+	"github.com/google/uuid"
+	"github.com/m-mizutani/golambda"
+)
+
+// SpecVersion is version of STIX specification
+const SpecVersion = "2.1"
+
+var (
+	// scoNamespace is namespace of UUIDv5 for deterministic identifiers of SCO defined in STIX 2.1
+	scoNamespace = uuid.MustParse("00abedb4-aa42-466c-9c01-fed23315a9b7")
+	// sdoNamespace is namespace of UUIDv5 for identifiers of objects generated by DeepAlert
+	sdoNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://github.com/cookpad/deepalert"))
+)
+
+// Object is a STIX object in Bundle.
+type Object interface {
+	ObjectID() string
+	ObjectType() string
+}
+
+// Common is common properties of all objects.
+type Common struct {
+	Type        string `json:"type"`
+	SpecVersion string `json:"spec_version"`
+	ID          string `json:"id"`
+}
+
+// ObjectID returns ID of the object
+func (x *Common) ObjectID() string { return x.ID }
+
+// ObjectType returns type of the object
+func (x *Common) ObjectType() string { return x.Type }
+
+// DomainCommon is common properties of SDO and SRO.
+type DomainCommon struct {
+	Common
+	Created      string   `json:"created"`
+	Modified     string   `json:"modified"`
+	CreatedByRef string   `json:"created_by_ref,omitempty"`
+	Labels       []string `json:"labels,omitempty"`
+}
+
+// Identity is identity SDO of DeepAlert and detectors.
+type Identity struct {
+	DomainCommon
+	Name          string `json:"name"`
+	IdentityClass string `json:"identity_class"`
+}
+
+// Observable is SCO converted from an attribute. Only properties for the type are set.
+type Observable struct {
+	Common
+	Value        string            `json:"value,omitempty"`
+	Name         string            `json:"name,omitempty"`
+	Hashes       map[string]string `json:"hashes,omitempty"`
+	AccountLogin string            `json:"account_login,omitempty"`
+	CommandLine  string            `json:"command_line,omitempty"`
+}
+
+// Indicator is indicator SDO with STIX pattern of an attribute.
+type Indicator struct {
+	DomainCommon
+	Name           string   `json:"name"`
+	IndicatorTypes []string `json:"indicator_types,omitempty"`
+	Pattern        string   `json:"pattern"`
+	PatternType    string   `json:"pattern_type"`
+	ValidFrom      string   `json:"valid_from"`
+}
+
+// ObservedData is observed-data SDO of an alert.
+type ObservedData struct {
+	DomainCommon
+	FirstObserved  string   `json:"first_observed"`
+	LastObserved   string   `json:"last_observed"`
+	NumberObserved int      `json:"number_observed"`
+	ObjectRefs     []string `json:"object_refs"`
+}
+
+// Sighting is sighting SRO of an indicator in an alert.
+type Sighting struct {
+	DomainCommon
+	Description      string   `json:"description,omitempty"`
+	FirstSeen        string   `json:"first_seen"`
+	LastSeen         string   `json:"last_seen"`
+	Count            int      `json:"count"`
+	SightingOfRef    string   `json:"sighting_of_ref"`
+	ObservedDataRefs []string `json:"observed_data_refs,omitempty"`
+	WhereSightedRefs []string `json:"where_sighted_refs,omitempty"`
+}
+
+// Note is note SDO of findings of a content type for an attribute. Content is JSON of the findings.
+type Note struct {
+	DomainCommon
+	Abstract   string   `json:"abstract"`
+	Content    string   `json:"content"`
+	Authors    []string `json:"authors,omitempty"`
+	ObjectRefs []string `json:"object_refs"`
+}
+
+// Report is report SDO that refers all objects in the bundle with the verdict of deepalert.Report.
+type Report struct {
+	DomainCommon
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	ReportTypes []string `json:"report_types"`
+	Published   string   `json:"published"`
+	ObjectRefs  []string `json:"object_refs"`
+
+	Severity  deepalert.ReportSeverity `json:"x_deepalert_severity,omitempty"`
+	RiskScore *int                     `json:"x_deepalert_risk_score,omitempty"`
+	RiskLevel deepalert.RiskLevel      `json:"x_deepalert_risk_level,omitempty"`
+}
+
+// Bundle is STIX bundle.
+type Bundle struct {
+	Type    string   `json:"type"`
+	ID      string   `json:"id"`
+	Objects []Object `json:"objects"`
+}
+
+// ObjectsOf returns objects of the type in the bundle.
+func (x *Bundle) ObjectsOf(objType string) []Object {
+	var objects []Object
+	for _, obj := range x.Objects {
+		if obj.ObjectType() == objType {
+			objects = append(objects, obj)
+		}
+	}
+	return objects
+}
+
+// Get returns an object by ID. It returns nil if not found.
+func (x *Bundle) Get(id string) Object {
+	for _, obj := range x.Objects {
+		if obj.ObjectID() == id {
+			return obj
+		}
+	}
+	return nil
+}
+
+func timestamp(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+func sdoID(objType string, seeds ...string) string {
+	return objType + "--" + uuid.NewSHA1(sdoNamespace, []byte(strings.Join(seeds, "\x00"))).String()
+}
+
+// scoID returns deterministic identifier of SCO from ID contributing properties canonicalized by RFC 8785 (JCS).
+func scoID(objType string, contributing interface{}) (string, error) {
+	// json.Encoder sorts keys of map as JCS does for ASCII keys, and HTML escape is disabled because JCS outputs
+	// characters such as & as is
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(contributing); err != nil {
+		return "", golambda.WrapError(err, "Fail to marshal ID contributing properties").With("type", objType)
+	}
+	raw := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	return objType + "--" + uuid.NewSHA1(scoNamespace, raw).String(), nil
+}
+
+// escapePattern escapes a string literal in STIX pattern
+func escapePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s)
+}
+
+// hashAlgorithm guesses hash algorithm of hex string by length
+func hashAlgorithm(hash string) string {
+	switch len(hash) {
+	case 32:
+		return "MD5"
+	case 40:
+		return "SHA-1"
+	case 64:
+		return "SHA-256"
+	case 128:
+		return "SHA-512"
+	default:
+		return ""
+	}
+}
+
+func isIPv6(attr *deepalert.Attribute) bool {
+	if attr.Context.Have(deepalert.CtxIPv6) {
+		return true
+	}
+	ip := attr.Value
+	if addr, _, err := net.ParseCIDR(ip); err == nil {
+		return addr.To4() == nil
+	}
+	return strings.Contains(ip, ":")
+}
+
+// newObservable converts attribute to SCO and STIX pattern. It returns nil if the attribute type has no
+// corresponding SCO, e.g. port, cloud resource and JSON.
+func newObservable(attr *deepalert.Attribute) (*Observable, string, error) {
+	value := strings.TrimSpace(attr.Value)
+	if value == "" {
+		return nil, "", nil
+	}
+
+	var observable *Observable
+	var pattern string
+	var contributing interface{}
+	valueObservable := func(objType string) {
+		observable = &Observable{Common: Common{Type: objType}, Value: value}
+		pattern = fmt.Sprintf("[%s:value = '%s']", objType, escapePattern(value))
+		contributing = map[string]string{"value": value}
+	}
+	hashObservable := func(objType, hash string) {
+		hash = strings.ToLower(strings.ReplaceAll(hash, ":", ""))
+		algorithm := hashAlgorithm(hash)
+		if algorithm == "" {
+			return
+		}
+		hashes := map[string]string{algorithm: hash}
+		observable = &Observable{Common: Common{Type: objType}, Hashes: hashes}
+		pattern = fmt.Sprintf("[%s:hashes.'%s' = '%s']", objType, algorithm, hash)
+		contributing = map[string]interface{}{"hashes": hashes}
+	}
+
+	switch attr.Type {
+	case deepalert.TypeIPAddr, deepalert.TypeCIDR:
+		if isIPv6(attr) {
+			valueObservable("ipv6-addr")
+		} else {
+			valueObservable("ipv4-addr")
+		}
+
+	case deepalert.TypeDomainName, deepalert.TypeHostName:
+		valueObservable("domain-name")
+
+	case deepalert.TypeURL:
+		valueObservable("url")
+
+	case deepalert.TypeEmailAddress:
+		valueObservable("email-addr")
+
+	case deepalert.TypeMACAddr:
+		valueObservable("mac-addr")
+
+	case deepalert.TypeFileHashValue:
+		hashObservable("file", value)
+
+	case deepalert.TypeCertFingerprint:
+		hashObservable("x509-certificate", value)
+
+	case deepalert.TypeFilePath:
+		if name := value[strings.LastIndexAny(value, `/\`)+1:]; name != "" {
+			observable = &Observable{Common: Common{Type: "file"}, Name: name}
+			pattern = fmt.Sprintf("[file:name = '%s']", escapePattern(name))
+			contributing = map[string]string{"name": name}
+		}
+
+	case deepalert.TypeUserName:
+		observable = &Observable{Common: Common{Type: "user-account"}, AccountLogin: value}
+		pattern = fmt.Sprintf("[user-account:account_login = '%s']", escapePattern(value))
+		contributing = map[string]string{"account_login": value}
+
+	case deepalert.TypeProcess:
+		observable = &Observable{Common: Common{Type: "process"}, CommandLine: value}
+		pattern = fmt.Sprintf("[process:command_line = '%s']", escapePattern(value))
+		contributing = map[string]string{"command_line": value}
+	}
+
+	if observable == nil {
+		return nil, "", nil
+	}
+	id, err := scoID(observable.Type, contributing)
+	if err != nil {
+		return nil, "", err
+	}
+	observable.SpecVersion, observable.ID = SpecVersion, id
+	return observable, pattern, nil
+}
+
+// indicatorTypes returns indicator types by severity of the report
+func indicatorTypes(report *deepalert.Report) []string {
+	switch report.Result.Severity {
+	case deepalert.SevUrgent:
+		return []string{"malicious-activity"}
+	case deepalert.SevSafe:
+		return []string{"benign"}
+	default:
+		return []string{"anomalous-activity"}
+	}
+}
+
+type converter struct {
+	report   *deepalert.Report
+	bundle   *Bundle
+	created  string
+	modified string
+	identity string
+	// observables and indicators are IDs of SCO and indicator by identity hash of attribute. Attributes of the
+	// same entity observed at different time are one indicator, as sections of report are grouped.
+	observables map[string]string
+	indicators  map[string]string
+	detectors   map[string]string
+}
+
+func (x *converter) domainCommon(objType, id string) DomainCommon {
+	return DomainCommon{
+		Common:       Common{Type: objType, SpecVersion: SpecVersion, ID: id},
+		Created:      x.created,
+		Modified:     x.modified,
+		CreatedByRef: x.identity,
+	}
+}
+
+func (x *converter) add(obj Object) {
+	x.bundle.Objects = append(x.bundle.Objects, obj)
+}
+
+func (x *converter) addIdentity(name string) string {
+	identity := &Identity{
+		DomainCommon:  x.domainCommon("identity", sdoID("identity", name)),
+		Name:          name,
+		IdentityClass: "system",
+	}
+	x.add(identity)
+	return identity.ID
+}
+
+func (x *converter) addAttribute(attr *deepalert.Attribute) error {
+	hv := attr.HashWith(deepalert.HashModeIdentity)
+	if _, ok := x.indicators[hv]; ok {
+		return nil
+	}
+	observable, pattern, err := newObservable(attr)
+	if err != nil {
+		return err
+	}
+	if observable == nil {
+		return nil
+	}
+
+	if x.bundle.Get(observable.ID) == nil {
+		x.add(observable)
+	}
+	x.observables[hv] = observable.ID
+
+	validFrom := x.report.CreatedAt
+	if attr.Timestamp != nil {
+		validFrom = *attr.Timestamp
+	}
+	indicator := &Indicator{
+		DomainCommon:   x.domainCommon("indicator", sdoID("indicator", string(x.report.ID), hv)),
+		Name:           fmt.Sprintf("%s: %s", attr.Type, attr.Value),
+		IndicatorTypes: indicatorTypes(x.report),
+		Pattern:        pattern,
+		PatternType:    "stix",
+		ValidFrom:      timestamp(validFrom),
+	}
+	for _, ctx := range attr.Context {
+		indicator.Labels = append(indicator.Labels, string(ctx))
+	}
+	x.add(indicator)
+	x.indicators[hv] = indicator.ID
+	return nil
+}
+
+func (x *converter) addAlert(idx int, alert *deepalert.Alert) {
+	seen := timestamp(alert.Timestamp)
+	seed := []string{string(x.report.ID), alert.AlertID(), fmt.Sprint(idx)}
+
+	var refs, indicators []string
+	for i := range alert.Attributes {
+		hv := alert.Attributes[i].HashWith(deepalert.HashModeIdentity)
+		if id, ok := x.observables[hv]; ok {
+			refs = append(refs, id)
+			indicators = append(indicators, x.indicators[hv])
+		}
+	}
+	if len(refs) == 0 {
+		return
+	}
+
+	observed := &ObservedData{
+		DomainCommon:   x.domainCommon("observed-data", sdoID("observed-data", seed...)),
+		FirstObserved:  seen,
+		LastObserved:   seen,
+		NumberObserved: 1,
+		ObjectRefs:     refs,
+	}
+	x.add(observed)
+
+	detector, ok := x.detectors[alert.Detector]
+	if !ok && alert.Detector != "" {
+		detector = x.addIdentity(alert.Detector)
+		x.detectors[alert.Detector] = detector
+	}
+
+	for _, indicator := range indicators {
+		sighting := &Sighting{
+			DomainCommon:     x.domainCommon("sighting", sdoID("sighting", append(seed, indicator)...)),
+			Description:      strings.TrimSpace(fmt.Sprintf("%s %s: %s", alert.Detector, alert.RuleName, alert.Description)),
+			FirstSeen:        seen,
+			LastSeen:         seen,
+			Count:            1,
+			SightingOfRef:    indicator,
+			ObservedDataRefs: []string{observed.ID},
+		}
+		if detector != "" {
+			sighting.WhereSightedRefs = []string{detector}
+		}
+		x.add(sighting)
+	}
+}
+
+func (x *converter) addSection(section *deepalert.Section, reportID string) error {
+	hv := section.Attr.HashWith(deepalert.HashModeIdentity)
+	refs := []string{reportID}
+	if indicator, ok := x.indicators[hv]; ok {
+		refs = []string{indicator, x.observables[hv]}
+	}
+
+	type group struct {
+		contents []deepalert.ReportContent
+		authors  map[string]bool
+	}
+	groups := map[deepalert.ReportContentType]*group{}
+	var order []deepalert.ReportContentType
+	for _, c := range section.Contents() {
+		g, ok := groups[c.Type]
+		if !ok {
+			g = &group{authors: map[string]bool{}}
+			groups[c.Type] = g
+			order = append(order, c.Type)
+		}
+		g.contents = append(g.contents, c.Content)
+		if c.Author != "" {
+			g.authors[c.Author] = true
+		}
+	}
+
+	for _, contentType := range order {
+		g := groups[contentType]
+		raw, err := json.MarshalIndent(g.contents, "", "  ")
+		if err != nil {
+			return golambda.WrapError(err, "Fail to marshal contents").With("type", contentType)
+		}
+
+		note := &Note{
+			DomainCommon: x.domainCommon("note", sdoID("note", string(x.report.ID), hv, string(contentType))),
+			Abstract:     fmt.Sprintf("%s of %s: %s", contentType, section.Attr.Type, section.Attr.Value),
+			Content:      string(raw),
+			ObjectRefs:   refs,
+		}
+		for author := range g.authors {
+			note.Authors = append(note.Authors, author)
+		}
+		sort.Strings(note.Authors)
+		x.add(note)
+	}
+	return nil
+}
+
+// NewBundle converts report to STIX bundle.
+func NewBundle(report *deepalert.Report) (*Bundle, error) {
+	modified := report.CreatedAt
+	for _, alert := range report.Alerts {
+		if alert.Timestamp.After(modified) {
+			modified = alert.Timestamp
+		}
+	}
+
+	x := &converter{
+		report:      report,
+		bundle:      &Bundle{Type: "bundle", ID: sdoID("bundle", string(report.ID))},
+		created:     timestamp(report.CreatedAt),
+		modified:    timestamp(modified),
+		observables: map[string]string{},
+		indicators:  map[string]string{},
+		detectors:   map[string]string{},
+	}
+	x.identity = x.addIdentity("DeepAlert")
+	x.detectors["DeepAlert"] = x.identity
+
+	for _, attr := range report.Attributes {
+		if err := x.addAttribute(attr); err != nil {
+			return nil, golambda.WrapError(err).With("report.ID", report.ID)
+		}
+	}
+	for _, alert := range report.Alerts {
+		for i := range alert.Attributes {
+			if err := x.addAttribute(&alert.Attributes[i]); err != nil {
+				return nil, golambda.WrapError(err).With("report.ID", report.ID)
+			}
+		}
+	}
+	for i, alert := range report.Alerts {
+		x.addAlert(i, alert)
+	}
+
+	reportID := sdoID("report", string(report.ID))
+	for _, section := range report.Sections {
+		if err := x.addSection(section, reportID); err != nil {
+			return nil, golambda.WrapError(err).With("report.ID", report.ID)
+		}
+	}
+
+	var names []string
+	for _, alert := range report.Alerts {
+		names = append(names, alert.RuleName)
+	}
+	summary := &Report{
+		DomainCommon: x.domainCommon("report", reportID),
+		Name:         fmt.Sprintf("DeepAlert report %s", report.ID),
+		Description:  report.Result.Reason,
+		ReportTypes:  []string{"threat-report"},
+		Published:    x.modified,
+		Severity:     report.Result.Severity,
+		RiskLevel:    report.Result.Level,
+	}
+	if names = uniq(names); len(names) > 0 {
+		summary.Name = strings.Join(names, ", ")
+	}
+	if report.Result.Level != "" {
+		score := report.Result.Score
+		summary.RiskScore = &score
+	}
+	if report.Result.Severity != "" {
+		summary.Labels = []string{string(report.Result.Severity)}
+	}
+	for _, obj := range x.bundle.Objects {
+		summary.ObjectRefs = append(summary.ObjectRefs, obj.ObjectID())
+	}
+	x.add(summary)
+
+	return x.bundle, nil
+}
+
+func uniq(values []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, v := range values {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,217 @@
+package stix_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/export/stix"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleReport() *deepalert.Report {
+	createdAt := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	ip := deepalert.Attribute{Type: deepalert.TypeIPAddr, Key: "remote", Value: "198.51.100.7", Context: deepalert.AttrContexts{"remote"}}
+	port := deepalert.Attribute{Type: deepalert.TypePort, Key: "port", Value: "443"}
+
+	return &deepalert.Report{
+		ID:         "r1",
+		Attributes: []*deepalert.Attribute{&ip, &port},
+		Alerts: []*deepalert.Alert{
+			{
+				Detector:    "ids",
+				RuleID:      "c2",
+				RuleName:    "C2 traffic",
+				Description: "Beacon to known C2",
+				Timestamp:   createdAt.Add(time.Minute),
+				Attributes:  []deepalert.Attribute{ip, port},
+			},
+		},
+		Sections: []*deepalert.Section{
+			{
+				Attr: ip,
+				ThreatIntel: []*deepalert.ContentThreatIntel{
+					{Feed: "f1", Verdict: deepalert.VerdictMalicious, Confidence: 90},
+				},
+				Provenances: []*deepalert.ContentProvenance{
+					{Type: deepalert.ContentTypeThreatIntel, Index: 0, Author: "intel", Provenance: deepalert.Provenance{Confidence: 90}},
+				},
+			},
+			{
+				Attr:  port,
+				Hosts: []*deepalert.ContentHost{{HostName: []string{"web1"}}},
+			},
+		},
+		Result:    deepalert.ReportResult{Severity: deepalert.SevUrgent, Reason: "Known C2", Score: 70, Level: deepalert.RiskHigh},
+		Status:    deepalert.StatusPublished,
+		CreatedAt: createdAt,
+	}
+}
+
+func TestNewBundle(t *testing.T) {
+	report := sampleReport()
+	bundle, err := stix.NewBundle(report)
+	require.NoError(t, err)
+	assert.Equal(t, "bundle", bundle.Type)
+
+	observables := bundle.ObjectsOf("ipv4-addr")
+	require.Equal(t, 1, len(observables))
+	observable := observables[0].(*stix.Observable)
+	assert.Equal(t, "198.51.100.7", observable.Value)
+
+	// port has no SCO and indicator
+	indicators := bundle.ObjectsOf("indicator")
+	require.Equal(t, 1, len(indicators))
+	indicator := indicators[0].(*stix.Indicator)
+	assert.Equal(t, "[ipv4-addr:value = '198.51.100.7']", indicator.Pattern)
+	assert.Equal(t, []string{"malicious-activity"}, indicator.IndicatorTypes)
+	assert.Equal(t, "2026-10-01T09:00:00.000Z", indicator.ValidFrom)
+
+	observed := bundle.ObjectsOf("observed-data")
+	require.Equal(t, 1, len(observed))
+	assert.Equal(t, []string{observable.ID}, observed[0].(*stix.ObservedData).ObjectRefs)
+
+	sightings := bundle.ObjectsOf("sighting")
+	require.Equal(t, 1, len(sightings))
+	sighting := sightings[0].(*stix.Sighting)
+	assert.Equal(t, indicator.ID, sighting.SightingOfRef)
+	assert.Equal(t, []string{observed[0].ObjectID()}, sighting.ObservedDataRefs)
+	assert.Equal(t, "2026-10-01T09:01:00.000Z", sighting.FirstSeen)
+	require.Equal(t, 1, len(sighting.WhereSightedRefs))
+	assert.Equal(t, "ids", bundle.Get(sighting.WhereSightedRefs[0]).(*stix.Identity).Name)
+
+	notes := bundle.ObjectsOf("note")
+	require.Equal(t, 2, len(notes))
+	intel := notes[0].(*stix.Note)
+	assert.Equal(t, "threat_intel of ipaddr: 198.51.100.7", intel.Abstract)
+	assert.Equal(t, []string{"intel"}, intel.Authors)
+	assert.Equal(t, []string{indicator.ID, observable.ID}, intel.ObjectRefs)
+	assert.Contains(t, intel.Content, `"feed": "f1"`)
+
+	reports := bundle.ObjectsOf("report")
+	require.Equal(t, 1, len(reports))
+	summary := reports[0].(*stix.Report)
+	assert.Equal(t, "C2 traffic", summary.Name)
+	assert.Equal(t, "Known C2", summary.Description)
+	assert.Equal(t, deepalert.SevUrgent, summary.Severity)
+	require.NotNil(t, summary.RiskScore)
+	assert.Equal(t, 70, *summary.RiskScore)
+	assert.Equal(t, len(bundle.Objects)-1, len(summary.ObjectRefs))
+	// note of port section without indicator refers the report
+	assert.Equal(t, []string{summary.ID}, notes[1].(*stix.Note).ObjectRefs)
+
+	for _, obj := range bundle.Objects {
+		assert.Regexp(t, `^[a-z0-9-]+--[0-9a-f-]{36}$`, obj.ObjectID())
+	}
+}
+
+func TestSharedAttribute(t *testing.T) {
+	report := sampleReport()
+	ts1, ts2 := report.CreatedAt, report.CreatedAt.Add(time.Hour)
+	ip := deepalert.Attribute{Type: deepalert.TypeIPAddr, Key: "remote", Value: "198.51.100.7", Context: deepalert.AttrContexts{"remote"}}
+	ip1, ip2 := ip, ip
+	ip1.Timestamp, ip2.Timestamp = &ts1, &ts2
+	report.Attributes = []*deepalert.Attribute{&ip1}
+	report.Alerts = []*deepalert.Alert{
+		{Detector: "ids", RuleID: "c2", Timestamp: ts1, Attributes: []deepalert.Attribute{ip1}},
+		{Detector: "proxy", RuleID: "blocked", Timestamp: ts2, Attributes: []deepalert.Attribute{ip2}},
+	}
+
+	bundle, err := stix.NewBundle(report)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, len(bundle.ObjectsOf("ipv4-addr")))
+	indicators := bundle.ObjectsOf("indicator")
+	require.Equal(t, 1, len(indicators))
+
+	sightings := bundle.ObjectsOf("sighting")
+	require.Equal(t, 2, len(sightings))
+	for _, sighting := range sightings {
+		assert.Equal(t, indicators[0].ObjectID(), sighting.(*stix.Sighting).SightingOfRef)
+	}
+
+	notes := bundle.ObjectsOf("note")
+	require.Equal(t, 2, len(notes))
+	assert.Contains(t, notes[0].(*stix.Note).ObjectRefs, indicators[0].ObjectID())
+}
+
+func TestNewBundleIsDeterministic(t *testing.T) {
+	b1, err := stix.NewBundle(sampleReport())
+	require.NoError(t, err)
+	b2, err := stix.NewBundle(sampleReport())
+	require.NoError(t, err)
+
+	raw1, err := json.Marshal(b1)
+	require.NoError(t, err)
+	raw2, err := json.Marshal(b2)
+	require.NoError(t, err)
+	assert.Equal(t, string(raw1), string(raw2))
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw1, &decoded))
+	objects := decoded["objects"].([]interface{})
+	assert.Equal(t, "2.1", objects[0].(map[string]interface{})["spec_version"])
+}
+
+func TestObservables(t *testing.T) {
+	testCases := []struct {
+		attr    deepalert.Attribute
+		objType string
+		pattern string
+	}{
+		{deepalert.Attribute{Type: deepalert.TypeIPAddr, Value: "2001:db8::1"}, "ipv6-addr", "[ipv6-addr:value = '2001:db8::1']"},
+		{deepalert.Attribute{Type: deepalert.TypeCIDR, Value: "10.0.0.0/8"}, "ipv4-addr", "[ipv4-addr:value = '10.0.0.0/8']"},
+		{deepalert.Attribute{Type: deepalert.TypeHostName, Value: "example.com"}, "domain-name", "[domain-name:value = 'example.com']"},
+		{deepalert.Attribute{Type: deepalert.TypeURL, Value: "https://example.com/a'b"}, "url", `[url:value = 'https://example.com/a\'b']`},
+		{deepalert.Attribute{Type: deepalert.TypeEmailAddress, Value: "alice@example.com"}, "email-addr", "[email-addr:value = 'alice@example.com']"},
+		{
+			deepalert.Attribute{Type: deepalert.TypeFileHashValue, Value: "44D88612FEA8A8F36DE82E1278ABB02F"},
+			"file", "[file:hashes.'MD5' = '44d88612fea8a8f36de82e1278abb02f']",
+		},
+		{
+			deepalert.Attribute{Type: deepalert.TypeCertFingerprint, Value: "AB:CD:EF:01:23:45:67:89:AB:CD:EF:01:23:45:67:89:AB:CD:EF:01"},
+			"x509-certificate", "[x509-certificate:hashes.'SHA-1' = 'abcdef0123456789abcdef0123456789abcdef01']",
+		},
+		{deepalert.Attribute{Type: deepalert.TypeFilePath, Value: `C:\Windows\evil.exe`}, "file", "[file:name = 'evil.exe']"},
+		{deepalert.Attribute{Type: deepalert.TypeUserName, Value: "alice"}, "user-account", "[user-account:account_login = 'alice']"},
+		{deepalert.Attribute{Type: deepalert.TypeProcess, Value: `sh -c "id"`}, "process", `[process:command_line = 'sh -c "id"']`},
+		{deepalert.Attribute{Type: deepalert.TypeFileHashValue, Value: "not-a-hash"}, "", ""},
+		{deepalert.Attribute{Type: deepalert.TypeCloudResource, Value: "i-0123456789abcdef0"}, "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.attr.Type)+" "+tc.attr.Value, func(t *testing.T) {
+			attr := tc.attr
+			bundle, err := stix.NewBundle(&deepalert.Report{ID: "r1", Attributes: []*deepalert.Attribute{&attr}})
+			require.NoError(t, err)
+
+			indicators := bundle.ObjectsOf("indicator")
+			if tc.objType == "" {
+				assert.Equal(t, 0, len(indicators))
+				return
+			}
+			require.Equal(t, 1, len(indicators))
+			assert.Equal(t, tc.pattern, indicators[0].(*stix.Indicator).Pattern)
+			assert.Equal(t, 1, len(bundle.ObjectsOf(tc.objType)))
+		})
+	}
+}
+
+func TestObservableIDIsCanonical(t *testing.T) {
+	value := "https://example.com/search?a=1&b=<2>"
+	bundle, err := stix.NewBundle(&deepalert.Report{
+		ID:         "r1",
+		Attributes: []*deepalert.Attribute{{Type: deepalert.TypeURL, Value: value}},
+	})
+	require.NoError(t, err)
+
+	// ID contributing properties are serialized by JCS (RFC 8785) that does not escape & < and >
+	namespace := uuid.MustParse("00abedb4-aa42-466c-9c01-fed23315a9b7")
+	expected := "url--" + uuid.NewSHA1(namespace, []byte(`{"value":"`+value+`"}`)).String()
+	observables := bundle.ObjectsOf("url")
+	require.Equal(t, 1, len(observables))
+	assert.Equal(t, expected, observables[0].ObjectID())
+}
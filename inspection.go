@@ -0,0 +1,112 @@
+package deepalert
+
+import (
+	"sort"
+	"time"
+)
+
+// FindingStatus shows result of inspection. Empty status means FindingFound for backward compatibility.
+type FindingStatus string
+
+const (
+	// FindingFound means the inspector found something and Content is set.
+	FindingFound FindingStatus = "found"
+	// FindingNotFound means the inspector checked the attribute, but found nothing.
+	FindingNotFound FindingStatus = "not_found"
+	// FindingFailed means the inspection failed by error such as rate limit and timeout.
+	FindingFailed FindingStatus = "failed"
+)
+
+// hasContent returns true if a finding of the status has Content.
+func (x FindingStatus) hasContent() bool {
+	return x == "" || x == FindingFound
+}
+
+// priority is used to merge statuses of findings by the same author. A successful retry supersedes failure.
+func (x FindingStatus) priority() int {
+	switch x {
+	case FindingFailed:
+		return 1
+	case FindingNotFound:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// InspectionStatus is status of an inspector (author) for an attribute.
+type InspectionStatus struct {
+	Author     string        `json:"author"`
+	Status     FindingStatus `json:"status"`
+	Error      string        `json:"error,omitempty"`
+	ProducedAt *time.Time    `json:"produced_at,omitempty"`
+}
+
+// AddInspection merges status of finding into Inspections of Section.
+func (x *Section) AddInspection(finding *Finding) {
+	status := finding.Status
+	if status == "" {
+		status = FindingFound
+	}
+
+	inspection := &InspectionStatus{
+		Author: finding.Author,
+		Status: status,
+		Error:  finding.Error,
+	}
+	if finding.Provenance != nil {
+		inspection.ProducedAt = finding.Provenance.ProducedAt
+	}
+
+	for i, current := range x.Inspections {
+		if current.Author == finding.Author {
+			if status.priority() > current.Status.priority() {
+				x.Inspections[i] = inspection
+			}
+			return
+		}
+	}
+
+	x.Inspections = append(x.Inspections, inspection)
+	sort.Slice(x.Inspections, func(i, j int) bool {
+		return x.Inspections[i].Author < x.Inspections[j].Author
+	})
+}
+
+// InspectionOf returns status of inspection by author. It returns nil if the author did not inspect the attribute.
+func (x *Section) InspectionOf(author string) *InspectionStatus {
+	for _, inspection := range x.Inspections {
+		if inspection.Author == author {
+			return inspection
+		}
+	}
+	return nil
+}
+
+// FailedInspections returns failed inspections of all sections. If authors are given, only inspections by
+// the authors are returned. A reviewer should not evaluate the report as safe if key inspections failed.
+func (x *Report) FailedInspections(authors ...string) []*InspectionStatus {
+	target := map[string]bool{}
+	for _, author := range authors {
+		target[author] = true
+	}
+
+	var failed []*InspectionStatus
+	for _, section := range x.Sections {
+		for _, inspection := range section.Inspections {
+			if inspection.Status != FindingFailed {
+				continue
+			}
+			if len(target) > 0 && !target[inspection.Author] {
+				continue
+			}
+			failed = append(failed, inspection)
+		}
+	}
+	return failed
+}
+
+// HasContent returns true if Status of the finding means Content is set.
+func (x *Finding) HasContent() bool {
+	return x.Status.hasContent()
+}
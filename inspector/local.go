@@ -0,0 +1,102 @@
+package inspector
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/cookpad/deepalert"
+	"github.com/m-mizutani/golambda"
+)
+
+const (
+	// EnvLocalTask is environment variable to give JSON of deepalert.Task to inspector invoked by `deepalert inspect run`
+	EnvLocalTask = "DEEPALERT_LOCAL_TASK"
+	// EnvLocalOutput is environment variable of file path that inspector invoked by `deepalert inspect run` writes LocalOutput
+	EnvLocalOutput = "DEEPALERT_LOCAL_OUTPUT"
+
+	localFindingQueueURL = "https://sqs.local.amazonaws.com/000000000000/finding"
+	localAttrQueueURL    = "https://sqs.local.amazonaws.com/000000000000/attribute"
+)
+
+// LocalOutput is a message that inspector sends in local mode. Either of Finding or Attribute is set.
+type LocalOutput struct {
+	Finding   *deepalert.Finding         `json:"finding,omitempty"`
+	Attribute *deepalert.ReportAttribute `json:"attribute,omitempty"`
+}
+
+// IsLocal returns true if the inspector is invoked by `deepalert inspect run`.
+func IsLocal() bool {
+	return os.Getenv(EnvLocalTask) != ""
+}
+
+// StartLocal runs Handler with a task given by `deepalert inspect run` instead of SNS event. Findings
+// and new attributes are written to a file of EnvLocalOutput as JSON lines instead of sending to SQS.
+// Call StartLocal before lambda.Start if IsLocal returns true.
+//
+//	if inspector.IsLocal() {
+//		if err := inspector.StartLocal(args); err != nil {
+//			log.Fatal(err)
+//		}
+//		return
+//	}
+func StartLocal(args Arguments) (err error) {
+	var task deepalert.Task
+	if err := json.Unmarshal([]byte(os.Getenv(EnvLocalTask)), &task); err != nil {
+		return golambda.WrapError(err, "Fail to unmarshal local task").With("task", os.Getenv(EnvLocalTask))
+	}
+
+	path := os.Getenv(EnvLocalOutput)
+	if path == "" {
+		return golambda.NewError("Output file is not set").With("env", EnvLocalOutput)
+	}
+	fd, err := os.Create(path)
+	if err != nil {
+		return golambda.WrapError(err, "Fail to create output file").With("path", path)
+	}
+	defer func() {
+		if closeErr := fd.Close(); closeErr != nil && err == nil {
+			err = golambda.WrapError(closeErr, "Fail to close output file").With("path", path)
+		}
+	}()
+
+	client := &localSQSClient{encoder: json.NewEncoder(fd)}
+	args.NewSQS = func(string) (SQSClient, error) { return client, nil }
+	args.FindingQueueURL = localFindingQueueURL
+	args.AttrQueueURL = localAttrQueueURL
+	if args.Context == nil {
+		args.Context = context.Background()
+	}
+
+	return HandleTask(args.Context, &task, args)
+}
+
+type localSQSClient struct {
+	encoder *json.Encoder
+}
+
+func (x *localSQSClient) SendMessage(input *sqs.SendMessageInput) (*sqs.SendMessageOutput, error) {
+	var output LocalOutput
+	var dst interface{}
+	switch aws.StringValue(input.QueueUrl) {
+	case localFindingQueueURL:
+		output.Finding = &deepalert.Finding{}
+		dst = output.Finding
+	case localAttrQueueURL:
+		output.Attribute = &deepalert.ReportAttribute{}
+		dst = output.Attribute
+	default:
+		return nil, golambda.NewError("Unknown local queue").With("url", aws.StringValue(input.QueueUrl))
+	}
+
+	if err := json.Unmarshal([]byte(aws.StringValue(input.MessageBody)), dst); err != nil {
+		return nil, golambda.WrapError(err, "Fail to unmarshal local message")
+	}
+	if err := x.encoder.Encode(output); err != nil {
+		return nil, golambda.WrapError(err, "Fail to write local output")
+	}
+
+	return &sqs.SendMessageOutput{}, nil
+}
@@ -23,6 +23,11 @@ type Repository interface {
 	GetAttributeCaches(pk string) ([]*models.AttributeCache, error)
 	PutReport(pk string, report *deepalert.Report) error
 	GetReport(pk string) (*deepalert.Report, error)
+	ScanReports(pkPrefix string) ([]*deepalert.Report, error)
+	PutIngestionSource(source *models.IngestionSource) error
+	GetIngestionSource(pk, sk string) (*models.IngestionSource, error)
+	DeleteIngestionSource(pk, sk string) error
+	IncrementCounter(pk, sk string, delta int64, expiresAt int64) (int64, error)
 
 	IsConditionalCheckErr(err error) bool
 }
@@ -0,0 +1,24 @@
+package adaptor
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// S3ClientFactory is interface S3Client constructor
+type S3ClientFactory func(region string) (S3Client, error)
+
+// S3Client is interface of AWS SDK S3
+type S3Client interface {
+	GetObject(*s3.GetObjectInput) (*s3.GetObjectOutput, error)
+}
+
+// NewS3Client creates actual AWS S3 SDK client
+func NewS3Client(region string) (S3Client, error) {
+	ssn, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+	if err != nil {
+		return nil, err
+	}
+	return s3.New(ssn), nil
+}
@@ -0,0 +1,26 @@
+package adaptor
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// SQSClientFactory is interface SQSClient constructor
+type SQSClientFactory func(region string) (SQSClient, error)
+
+// SQSClient is interface of AWS SDK SQS
+type SQSClient interface {
+	SendMessage(*sqs.SendMessageInput) (*sqs.SendMessageOutput, error)
+	ReceiveMessage(*sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessage(*sqs.DeleteMessageInput) (*sqs.DeleteMessageOutput, error)
+}
+
+// NewSQSClient creates actual AWS SQS SDK client
+func NewSQSClient(region string) (SQSClient, error) {
+	ssn, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+	if err != nil {
+		return nil, err
+	}
+	return sqs.New(ssn), nil
+}
@@ -0,0 +1,241 @@
+// Package api provides HTTP ingestion API of alerts. It can be used behind API Gateway or as a standalone server.
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/cookpad/deepalert/internal/usecase"
+	"github.com/m-mizutani/golambda"
+)
+
+var logger = golambda.Logger
+
+// maxBodySize is limit of request body.
+const maxBodySize = 1024 * 1024
+
+// Handler is http.Handler of HTTP ingestion API. Request body is an alert, an array of alerts or NDJSON.
+// An element can be any format that receptAlert accepts.
+type Handler struct {
+	args *handler.Arguments
+	now  func() time.Time
+}
+
+// NewHandler is constructor of Handler
+func NewHandler(args *handler.Arguments) *Handler {
+	return &Handler{args: args, now: time.Now}
+}
+
+// SetNow replaces clock for testing.
+func (x *Handler) SetNow(now func() time.Time) {
+	x.now = now
+}
+
+// ItemError is error of an element in request body.
+type ItemError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// ItemResult is result of an element in request body. ReportIDs are of handled alerts of the element.
+type ItemResult struct {
+	Index     int                  `json:"index"`
+	ReportIDs []deepalert.ReportID `json:"report_ids"`
+	Error     string               `json:"error,omitempty"`
+}
+
+// Response is response body of the API. ReportIDs are of handled alerts in order of alerts, Results has result of
+// each element in order of request body, and Failed has index of elements in request body that were not handled.
+type Response struct {
+	ReportIDs []deepalert.ReportID `json:"report_ids,omitempty"`
+	Results   []*ItemResult        `json:"results,omitempty"`
+	Error     string               `json:"error,omitempty"`
+	Failed    []*ItemError         `json:"failed,omitempty"`
+}
+
+func writeResponse(w http.ResponseWriter, code int, resp *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.With("err", err).Error("Fail to write response")
+	}
+}
+
+// ServeHTTP authenticates the caller by X-DeepAlert-Source header, validates all alerts, checks quota of the
+// source and handles alerts. No alert is handled if some of them are invalid. If some alerts fail to be handled,
+// it responds 207 with ReportIDs of handled alerts, Results of all elements and index of failed elements, and 500
+// if all of them failed.
+// The API is not idempotent: the caller should retry only failed elements, otherwise duplicated alerts are
+// aggregated to the report again and counted by quota again.
+func (x *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeResponse(w, http.StatusMethodNotAllowed, &Response{Error: "only POST is allowed"})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeResponse(w, http.StatusRequestEntityTooLarge, &Response{Error: "request body is too large"})
+		return
+	}
+
+	name := r.Header.Get(HeaderSource)
+	if name == "" {
+		writeResponse(w, http.StatusUnauthorized, &Response{Error: "unknown source"})
+		return
+	}
+
+	now := x.now().UTC()
+	repo, err := x.args.Repository()
+	if err != nil {
+		x.internalError(w, err)
+		return
+	}
+
+	source, err := repo.GetIngestionSource(name)
+	if err != nil {
+		x.internalError(w, err)
+		return
+	}
+	if source == nil {
+		writeResponse(w, http.StatusUnauthorized, &Response{Error: "unknown source"})
+		return
+	}
+	if err := authenticate(source, r.Header, body, now); err != nil {
+		logger.With("source", name).With("err", err).Warn("Authentication failed")
+		writeResponse(w, http.StatusUnauthorized, &Response{Error: "authentication failed"})
+		return
+	}
+
+	mapper, err := usecase.LoadAlertMapper(x.args)
+	if err != nil {
+		x.internalError(w, err)
+		return
+	}
+	if _, err := usecase.LoadEnricher(x.args); err != nil {
+		x.internalError(w, err)
+		return
+	}
+	alerts, n, failed := parseBody(body, mapper.Decode)
+	if len(failed) > 0 {
+		writeResponse(w, http.StatusBadRequest, &Response{Error: "invalid alert", Failed: failed})
+		return
+	}
+	if len(alerts) == 0 {
+		writeResponse(w, http.StatusBadRequest, &Response{Error: "no alert"})
+		return
+	}
+
+	if source.QuotaPerMinute > 0 {
+		accepted, windowEnd, err := repo.ReserveIngestion(name, int64(len(alerts)), source.QuotaPerMinute, now)
+		if err != nil {
+			x.internalError(w, err)
+			return
+		}
+		if !accepted {
+			retryAfter := int(windowEnd.Sub(now).Seconds()) + 1
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			writeResponse(w, http.StatusTooManyRequests, &Response{Error: "quota exceeded"})
+			return
+		}
+	}
+
+	resp := Response{Results: make([]*ItemResult, n)}
+	for i := range resp.Results {
+		resp.Results[i] = &ItemResult{Index: i, ReportIDs: []deepalert.ReportID{}}
+	}
+	for _, item := range alerts {
+		result := resp.Results[item.index]
+		report, err := usecase.HandleAlert(x.args, item.alert, now)
+		if err != nil {
+			logger.With("source", name).With("index", item.index).With("err", err).Error("Fail to handle alert")
+			golambda.EmitError(err)
+			// An element that has multiple alerts is reported once
+			if result.Error == "" {
+				result.Error = "failed to handle alert"
+				resp.Failed = append(resp.Failed, &ItemError{Index: item.index, Error: result.Error})
+			}
+			continue
+		}
+		resp.ReportIDs = append(resp.ReportIDs, report.ID)
+		result.ReportIDs = append(result.ReportIDs, report.ID)
+	}
+
+	logger.With("source", name).With("alerts", len(alerts)).With("failed", len(resp.Failed)).Info("Ingested alerts via HTTP")
+	switch {
+	case len(resp.Failed) == 0:
+		writeResponse(w, http.StatusOK, &resp)
+	case len(resp.ReportIDs) == 0:
+		resp.Error = "failed to handle alert"
+		writeResponse(w, http.StatusInternalServerError, &resp)
+	default:
+		resp.Error = "failed to handle some alerts"
+		writeResponse(w, http.StatusMultiStatus, &resp)
+	}
+}
+
+func (x *Handler) internalError(w http.ResponseWriter, err error) {
+	golambda.EmitError(err)
+	writeResponse(w, http.StatusInternalServerError, &Response{Error: "internal error"})
+}
+
+type decodeFunc func(data []byte) ([]*deepalert.Alert, string, error)
+
+// bodyAlert is an alert decoded from an element of request body. An element can have multiple alerts.
+type bodyAlert struct {
+	index int
+	alert *deepalert.Alert
+}
+
+// parseBody splits body into JSON values (an array or a sequence of values as NDJSON) and decodes them. It returns
+// decoded alerts and number of elements.
+func parseBody(body []byte, decode decodeFunc) ([]*bodyAlert, int, []*ItemError) {
+	var items []json.RawMessage
+
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, 0, []*ItemError{{Index: 0, Error: "invalid JSON array: " + err.Error()}}
+		}
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		for {
+			var item json.RawMessage
+			if err := decoder.Decode(&item); errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return nil, 0, []*ItemError{{Index: len(items), Error: "invalid JSON: " + err.Error()}}
+			}
+			items = append(items, item)
+		}
+	}
+
+	var alerts []*bodyAlert
+	var failed []*ItemError
+	for i, item := range items {
+		decoded, _, err := decode(item)
+		if err == nil {
+			for _, alert := range decoded {
+				if err = usecase.ValidateAlert(alert); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			failed = append(failed, &ItemError{Index: i, Error: err.Error()})
+			continue
+		}
+		for _, alert := range decoded {
+			alerts = append(alerts, &bodyAlert{index: i, alert: alert})
+		}
+	}
+
+	return alerts, len(items), failed
+}
@@ -0,0 +1,268 @@
+package api_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/cookpad/deepalert/internal/adaptor"
+	"github.com/cookpad/deepalert/internal/api"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/cookpad/deepalert/internal/mock"
+	"github.com/cookpad/deepalert/internal/models"
+	"github.com/m-mizutani/golambda"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testAPIKey = "test-api-key"
+	testSecret = "test-hmac-secret"
+)
+
+var testNow = time.Date(2026, 5, 1, 12, 30, 10, 0, time.UTC)
+
+func setup(t *testing.T, quota int64) (*api.Handler, *mock.SFnClient) {
+	dummySFn, _ := mock.NewSFnClient("")
+	return setupWithSFn(t, quota, dummySFn), dummySFn.(*mock.SFnClient)
+}
+
+func setupWithSFn(t *testing.T, quota int64, dummySFn adaptor.SFnClient) *api.Handler {
+	dummyRepo := mock.NewRepository("", "")
+	args := &handler.Arguments{
+		NewRepository: func(string, string) adaptor.Repository { return dummyRepo },
+		NewSFn:        func(string) (adaptor.SFnClient, error) { return dummySFn, nil },
+		EnvVars: handler.EnvVars{
+			InspectorMachine: "arn:aws:states:us-east-1:111122223333:stateMachine:blue",
+			ReviewMachine:    "arn:aws:states:us-east-1:111122223333:stateMachine:orange",
+		},
+	}
+
+	repo, err := args.Repository()
+	require.NoError(t, err)
+	require.NoError(t, repo.PutIngestionSource(&models.IngestionSource{
+		Name:           "key-source",
+		APIKeyHash:     api.HashAPIKey(testAPIKey),
+		QuotaPerMinute: quota,
+	}))
+	require.NoError(t, repo.PutIngestionSource(&models.IngestionSource{
+		Name:       "hmac-source",
+		HMACSecret: testSecret,
+	}))
+
+	h := api.NewHandler(args)
+	h.SetNow(func() time.Time { return testNow })
+	return h
+}
+
+// failingSFnClient fails StartExecution after limit executions
+type failingSFnClient struct {
+	limit int
+	Input []*sfn.StartExecutionInput
+}
+
+func (x *failingSFnClient) StartExecution(input *sfn.StartExecutionInput) (*sfn.StartExecutionOutput, error) {
+	if len(x.Input) >= x.limit {
+		return nil, errors.New("temporary failure")
+	}
+	x.Input = append(x.Input, input)
+	return &sfn.StartExecutionOutput{}, nil
+}
+
+func post(h http.Handler, body string, headers map[string]string) (*httptest.ResponseRecorder, *api.Response) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var resp api.Response
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec, &resp
+}
+
+func keyHeaders() map[string]string {
+	return map[string]string{
+		api.HeaderSource: "key-source",
+		"Authorization":  "Bearer " + testAPIKey,
+	}
+}
+
+const alertA = `{"detector":"blue","rule_id":"r1","alert_key":"a"}`
+const alertB = `{"detector":"blue","rule_id":"r2","alert_key":"b"}`
+
+func TestHandler(t *testing.T) {
+	t.Run("single alert with API key", func(t *testing.T) {
+		h, sfn := setup(t, 0)
+		rec, resp := post(h, alertA, keyHeaders())
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, 1, len(resp.ReportIDs))
+		assert.Equal(t, 2, len(sfn.Input))
+	})
+
+	t.Run("array and NDJSON", func(t *testing.T) {
+		h, _ := setup(t, 0)
+		rec, resp := post(h, "["+alertA+","+alertB+"]", keyHeaders())
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, 2, len(resp.ReportIDs))
+		assert.NotEqual(t, resp.ReportIDs[0], resp.ReportIDs[1])
+
+		rec, resp = post(h, alertA+"\n"+alertB+"\n", keyHeaders())
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, 2, len(resp.ReportIDs))
+	})
+
+	t.Run("invalid alert rejects whole request", func(t *testing.T) {
+		h, sfn := setup(t, 0)
+		rec, resp := post(h, alertA+"\n"+`{"detector":"blue"}`, keyHeaders())
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		require.Equal(t, 1, len(resp.Failed))
+		assert.Equal(t, 1, resp.Failed[0].Index)
+		assert.Equal(t, 0, len(sfn.Input))
+
+		rec, _ = post(h, "", keyHeaders())
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("partial failure returns handled report IDs and failed index", func(t *testing.T) {
+		// Executions for alertA (inspection and review) succeed, and alertB fails
+		h := setupWithSFn(t, 0, &failingSFnClient{limit: 2})
+		rec, resp := post(h, "["+alertA+","+alertB+"]", keyHeaders())
+		require.Equal(t, http.StatusMultiStatus, rec.Code)
+		assert.Equal(t, 1, len(resp.ReportIDs))
+		require.Equal(t, 1, len(resp.Failed))
+		assert.Equal(t, 1, resp.Failed[0].Index)
+
+		// Results are aligned with elements of request body
+		require.Equal(t, 2, len(resp.Results))
+		assert.Equal(t, 0, resp.Results[0].Index)
+		assert.Equal(t, resp.ReportIDs, resp.Results[0].ReportIDs)
+		assert.Empty(t, resp.Results[0].Error)
+		assert.Equal(t, 1, resp.Results[1].Index)
+		assert.Equal(t, 0, len(resp.Results[1].ReportIDs))
+		assert.NotEmpty(t, resp.Results[1].Error)
+	})
+
+	t.Run("all alerts failed", func(t *testing.T) {
+		h := setupWithSFn(t, 0, &failingSFnClient{limit: 0})
+		rec, resp := post(h, "["+alertA+","+alertB+"]", keyHeaders())
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, 0, len(resp.ReportIDs))
+		assert.Equal(t, 2, len(resp.Failed))
+	})
+
+	t.Run("API key authentication", func(t *testing.T) {
+		h, _ := setup(t, 0)
+		rec, _ := post(h, alertA, map[string]string{api.HeaderSource: "key-source", "Authorization": "Bearer wrong"})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec, _ = post(h, alertA, map[string]string{"Authorization": "Bearer " + testAPIKey})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		rec, _ = post(h, alertA, map[string]string{api.HeaderSource: "", "Authorization": "Bearer " + testAPIKey})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec, _ = post(h, alertA, map[string]string{api.HeaderSource: "hmac-source", "Authorization": "Bearer " + testAPIKey})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("HMAC authentication", func(t *testing.T) {
+		h, _ := setup(t, 0)
+		sign := func(ts time.Time, secret string) map[string]string {
+			return map[string]string{
+				api.HeaderSource:    "hmac-source",
+				api.HeaderTimestamp: strconv.FormatInt(ts.Unix(), 10),
+				api.HeaderSignature: api.Sign(secret, ts.Unix(), []byte(alertA)),
+			}
+		}
+
+		rec, resp := post(h, alertA, sign(testNow.Add(-time.Minute), testSecret))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, 1, len(resp.ReportIDs))
+
+		rec, _ = post(h, alertA, sign(testNow, "wrong"))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec, _ = post(h, alertA, sign(testNow.Add(-10*time.Minute), testSecret))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("quota per source", func(t *testing.T) {
+		h, _ := setup(t, 3)
+		rec, _ := post(h, "["+alertA+","+alertB+"]", keyHeaders())
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		rec, _ = post(h, "["+alertA+","+alertB+"]", keyHeaders())
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "51", rec.Header().Get("Retry-After"))
+
+		// Rejected alerts do not consume quota
+		rec, _ = post(h, alertA, keyHeaders())
+		assert.Equal(t, http.StatusOK, rec.Code)
+		rec, _ = post(h, alertA, keyHeaders())
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	})
+
+	t.Run("empty source is rejected without repository", func(t *testing.T) {
+		args := &handler.Arguments{
+			NewRepository: func(string, string) adaptor.Repository {
+				require.Fail(t, "repository must not be used")
+				return nil
+			},
+		}
+		h := api.NewHandler(args)
+		rec, resp := post(h, alertA, map[string]string{"Authorization": "Bearer " + testAPIKey})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, "unknown source", resp.Error)
+	})
+
+	t.Run("only POST is allowed", func(t *testing.T) {
+		h, _ := setup(t, 0)
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
+func TestHandleGatewayEvent(t *testing.T) {
+	t.Run("REST API event", func(t *testing.T) {
+		h, _ := setup(t, 0)
+		event := golambda.Event{Origin: map[string]interface{}{
+			"httpMethod": "POST",
+			"path":       "/alerts",
+			"headers":    keyHeaders(),
+			"body":       alertA,
+		}}
+		out, err := api.HandleGatewayEvent(h, event)
+		require.NoError(t, err)
+		resp, ok := out.(events.APIGatewayProxyResponse)
+		require.True(t, ok)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, resp.Body, "report_ids")
+	})
+
+	t.Run("function URL event with base64 body", func(t *testing.T) {
+		h, _ := setup(t, 0)
+		event := golambda.Event{Origin: map[string]interface{}{
+			"version":         "2.0",
+			"rawPath":         "/",
+			"headers":         keyHeaders(),
+			"body":            "eyJkZXRlY3RvciI6ImJsdWUiLCJydWxlX2lkIjoicjEiLCJhbGVydF9rZXkiOiJhIn0=",
+			"isBase64Encoded": true,
+			"requestContext":  map[string]interface{}{"http": map[string]interface{}{"method": "POST"}},
+		}}
+		out, err := api.HandleGatewayEvent(h, event)
+		require.NoError(t, err)
+		resp, ok := out.(events.APIGatewayV2HTTPResponse)
+		require.True(t, ok)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
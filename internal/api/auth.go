@@ -0,0 +1,87 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cookpad/deepalert/internal/models"
+	"github.com/m-mizutani/golambda"
+)
+
+// Header names of HTTP ingestion API
+const (
+	HeaderSource    = "X-DeepAlert-Source"
+	HeaderSignature = "X-DeepAlert-Signature"
+	HeaderTimestamp = "X-DeepAlert-Timestamp"
+)
+
+// signatureTolerance is allowed difference between X-DeepAlert-Timestamp and current time to prevent replay.
+const signatureTolerance = 5 * time.Minute
+
+var (
+	errUnauthorized = golambda.NewError("Unauthorized")
+)
+
+// NewSecret generates random string for API key and HMAC secret.
+func NewSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", golambda.WrapError(err, "Fail to generate secret")
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// HashAPIKey returns hash of API key to be stored in repository.
+func HashAPIKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
+
+// Sign returns value of X-DeepAlert-Signature header, "sha256=" and hex encoded HMAC-SHA256 of "{timestamp}.{body}".
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// authenticate verifies HMAC signature if the request has X-DeepAlert-Signature header, otherwise API key
+// given by "Authorization: Bearer <key>" header.
+func authenticate(source *models.IngestionSource, header http.Header, body []byte, now time.Time) error {
+	if sig := header.Get(HeaderSignature); sig != "" {
+		if source.HMACSecret == "" {
+			return golambda.WrapError(errUnauthorized, "HMAC is not configured for the source")
+		}
+
+		ts, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+		if err != nil {
+			return golambda.WrapError(errUnauthorized, "Invalid timestamp")
+		}
+		diff := now.Sub(time.Unix(ts, 0))
+		if diff > signatureTolerance || diff < -signatureTolerance {
+			return golambda.WrapError(errUnauthorized, "Timestamp is out of tolerance")
+		}
+
+		expected := Sign(source.HMACSecret, ts, body)
+		if !hmac.Equal([]byte(sig), []byte(expected)) {
+			return golambda.WrapError(errUnauthorized, "Signature mismatch")
+		}
+		return nil
+	}
+
+	key := strings.TrimPrefix(header.Get("Authorization"), "Bearer ")
+	if source.APIKeyHash == "" || key == "" {
+		return golambda.WrapError(errUnauthorized, "API key is required")
+	}
+	if subtle.ConstantTimeCompare([]byte(HashAPIKey(key)), []byte(source.APIKeyHash)) != 1 {
+		return golambda.WrapError(errUnauthorized, "API key mismatch")
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/m-mizutani/golambda"
+)
+
+// HandleGatewayEvent converts API Gateway REST API (payload format 1.0) or HTTP API and Lambda function URL
+// (payload format 2.0) event to http.Request, serves it by h and returns proxy response.
+func HandleGatewayEvent(h http.Handler, event golambda.Event) (interface{}, error) {
+	raw, err := json.Marshal(event.Origin)
+	if err != nil {
+		return nil, golambda.WrapError(err, "Fail to marshal event")
+	}
+
+	var version struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(raw, &version); err != nil {
+		return nil, golambda.WrapError(err, "Fail to unmarshal event")
+	}
+
+	if version.Version == "2.0" {
+		var req events.APIGatewayV2HTTPRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			return nil, golambda.WrapError(err, "Fail to unmarshal HTTP API event")
+		}
+		rec, err := serve(h, req.RequestContext.HTTP.Method, req.RawPath, req.Headers, req.Body, req.IsBase64Encoded)
+		if err != nil {
+			return nil, err
+		}
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode: rec.Code,
+			Headers:    flattenHeader(rec.Header()),
+			Body:       rec.Body.String(),
+		}, nil
+	}
+
+	var req events.APIGatewayProxyRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return nil, golambda.WrapError(err, "Fail to unmarshal REST API event")
+	}
+	headers := req.Headers
+	if len(req.MultiValueHeaders) > 0 {
+		headers = map[string]string{}
+		for key, values := range req.MultiValueHeaders {
+			if len(values) > 0 {
+				headers[key] = values[0]
+			}
+		}
+	}
+	rec, err := serve(h, req.HTTPMethod, req.Path, headers, req.Body, req.IsBase64Encoded)
+	if err != nil {
+		return nil, err
+	}
+	return events.APIGatewayProxyResponse{
+		StatusCode: rec.Code,
+		Headers:    flattenHeader(rec.Header()),
+		Body:       rec.Body.String(),
+	}, nil
+}
+
+func serve(h http.Handler, method, path string, headers map[string]string, body string, isBase64 bool) (*httptest.ResponseRecorder, error) {
+	data := []byte(body)
+	if isBase64 {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return nil, golambda.WrapError(err, "Fail to decode base64 body")
+		}
+		data = decoded
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	req, err := http.NewRequest(method, path, bytes.NewReader(data))
+	if err != nil {
+		return nil, golambda.WrapError(err, "Fail to create request").With("method", method).With("path", path)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec, nil
+}
+
+func flattenHeader(header http.Header) map[string]string {
+	out := map[string]string{}
+	for key := range header {
+		out[key] = header.Get(key)
+	}
+	return out
+}
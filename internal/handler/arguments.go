@@ -12,6 +12,8 @@ type Arguments struct {
 
 	NewSNS        adaptor.SNSClientFactory  `json:"-"`
 	NewSFn        adaptor.SFnClientFactory  `json:"-"`
+	NewSQS        adaptor.SQSClientFactory  `json:"-"`
+	NewS3         adaptor.S3ClientFactory   `json:"-"`
 	NewRepository adaptor.RepositoryFactory `json:"-"`
 }
 
@@ -36,6 +38,22 @@ func (x *Arguments) SFnService() *service.SFnService {
 	return service.NewSFnService(adaptor.NewSFnClient)
 }
 
+// SQSService provides service.SQSService with SQS adaptor
+func (x *Arguments) SQSService() *service.SQSService {
+	if x.NewSQS != nil {
+		return service.NewSQSService(x.NewSQS)
+	}
+	return service.NewSQSService(adaptor.NewSQSClient)
+}
+
+// S3Service provides service.S3Service with S3 adaptor
+func (x *Arguments) S3Service() *service.S3Service {
+	if x.NewS3 != nil {
+		return service.NewS3Service(x.NewS3)
+	}
+	return service.NewS3Service(adaptor.NewS3Client)
+}
+
 // repositoryTTL is the TTL in seconds for all cached records in the repository.
 const repositoryTTL int64 = 3 * 60 * 60 // 3 hours
 
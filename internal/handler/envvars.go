@@ -1,7 +1,10 @@
 package handler
 
 import (
+	"strings"
+
 	"github.com/Netflix/go-env"
+	"github.com/cookpad/deepalert"
 	"github.com/m-mizutani/golambda"
 )
 
@@ -12,17 +15,46 @@ type EnvVars struct {
 	ReportTopic string `env:"REPORT_TOPIC"`
 	CacheTable  string `env:"CACHE_TABLE"`
 
+	// DeadLetterQueue is URL of SQS queue to put messages that can never be processed
+	DeadLetterQueue string `env:"DEAD_LETTER_QUEUE"`
+
 	// Only recvAlert can use because of dependency
 	InspectorMachine string `env:"INSPECTOR_MACHINE"`
 	ReviewMachine    string `env:"REVIEW_MACHINE"`
 
+	// AlertMappings is JSON configuration of alert.Mapper for recvAlert
+	AlertMappings string `env:"ALERT_MAPPINGS"`
+
+	// CustomAttrTypePrefixes is comma separated prefixes of custom attribute type, e.g. "acme.,corp."
+	CustomAttrTypePrefixes string `env:"CUSTOM_ATTR_TYPE_PREFIXES"`
+
+	// DisabledDerivations is comma separated derivations of attributes (url_host, email_domain and
+	// registrable_domain) that dispatchInspection should not produce
+	DisabledDerivations string `env:"DISABLED_DERIVATIONS"`
+
+	// IOCExtraction is JSON configuration of ioc.Extractor to add indicators in alert description and body as
+	// attributes. IOC extraction is disabled if it's empty
+	IOCExtraction string `env:"IOC_EXTRACTION"`
+
+	// OrgCIDRs is comma separated CIDRs of your organization. IP address attributes in them are tagged with
+	// local context in addition to private, loopback and link-local addresses
+	OrgCIDRs string `env:"ORG_CIDRS"`
+
+	// DisableIPContextTagging turns off tagging local/remote and IP version contexts to IP address attributes
+	DisableIPContextTagging bool `env:"DISABLE_IP_CONTEXT_TAGGING"`
+
+	// ReviewPolicy is policy to combine results of multiple reviewers: max_severity (default), first_decisive
+	// or majority
+	ReviewPolicy string `env:"REVIEW_POLICY"`
+
 	// Utilities
 	SentryDSN string `env:"SENTRY_DSN"`
 	SentryEnv string `env:"SENTRY_ENVIRONMENT"`
 	LogLevel  string `env:"LOG_LEVEL"`
 
 	// From AWS Lambda
-	AwsRegion string `env:"AWS_REGION"`
+	AwsRegion    string `env:"AWS_REGION"`
+	FunctionName string `env:"AWS_LAMBDA_FUNCTION_NAME"`
 }
 
 // BindEnvVars loads environments variables and set them to EnvVars
@@ -31,5 +63,14 @@ func (x *EnvVars) BindEnvVars() error {
 		return golambda.WrapError(err)
 	}
 
+	for _, prefix := range strings.Split(x.CustomAttrTypePrefixes, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix == "" {
+			continue
+		}
+		if err := deepalert.RegisterCustomAttrTypePrefix(prefix); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
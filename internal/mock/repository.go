@@ -2,6 +2,7 @@ package mock
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/cookpad/deepalert"
@@ -144,6 +145,49 @@ func (x *Repository) GetReport(pk string) (*deepalert.Report, error) {
 	return report["-"].(*deepalert.Report), nil
 }
 
+func (x *Repository) ScanReports(pkPrefix string) ([]*deepalert.Report, error) {
+	var out []*deepalert.Report
+	for pk, records := range x.data {
+		if !strings.HasPrefix(pk, pkPrefix) {
+			continue
+		}
+		if report, ok := records["-"].(*deepalert.Report); ok {
+			out = append(out, report)
+		}
+	}
+	return out, nil
+}
+
+func (x *Repository) PutIngestionSource(source *models.IngestionSource) error {
+	x.put(source.PKey, source.SKey, source)
+	return nil
+}
+
+func (x *Repository) GetIngestionSource(pk, sk string) (*models.IngestionSource, error) {
+	if source, ok := x.get(pk, sk).(*models.IngestionSource); ok {
+		return source, nil
+	}
+	return nil, nil
+}
+
+func (x *Repository) DeleteIngestionSource(pk, sk string) error {
+	if m, ok := x.data[pk]; ok {
+		delete(m, sk)
+	}
+	return nil
+}
+
+func (x *Repository) IncrementCounter(pk, sk string, delta int64, expiresAt int64) (int64, error) {
+	counter, ok := x.get(pk, sk).(*models.Counter)
+	if !ok {
+		counter = &models.Counter{RecordBase: models.RecordBase{PKey: pk, SKey: sk}}
+		x.put(pk, sk, counter)
+	}
+	counter.Count += delta
+	counter.ExpiresAt = expiresAt
+	return counter.Count, nil
+}
+
 func (x *Repository) IsConditionalCheckErr(err error) bool {
 	return err == errCondition
 }
@@ -0,0 +1,50 @@
+package mock
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cookpad/deepalert/internal/adaptor"
+)
+
+// S3Client is mock. Objects are stored by PutObject in memory.
+type S3Client struct {
+	Region  string
+	Input   []*s3.GetObjectInput
+	objects map[string][]byte
+}
+
+// PutObject stores data as object of bucket and key
+func (x *S3Client) PutObject(bucket, key string, data []byte) {
+	if x.objects == nil {
+		x.objects = map[string][]byte{}
+	}
+	x.objects[bucket+"/"+key] = data
+}
+
+// GetObject of mock S3Client returns stored object or NoSuchKey error
+func (x *S3Client) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	x.Input = append(x.Input, input)
+
+	data, ok := x.objects[aws.StringValue(input.Bucket)+"/"+aws.StringValue(input.Key)]
+	if !ok {
+		return nil, awserr.New(s3.ErrCodeNoSuchKey, "The specified key does not exist.", nil)
+	}
+
+	return &s3.GetObjectOutput{
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: aws.Int64(int64(len(data))),
+	}, nil
+}
+
+// NewMockS3ClientSet returns a pair of S3Client and S3ClientFactory
+func NewMockS3ClientSet() (*S3Client, adaptor.S3ClientFactory) {
+	client := &S3Client{}
+	return client, func(region string) (adaptor.S3Client, error) {
+		client.Region = region
+		return client, nil
+	}
+}
@@ -0,0 +1,85 @@
+package mock
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/cookpad/deepalert/internal/adaptor"
+	"github.com/google/uuid"
+)
+
+// NewSQSClient creates mock SQS client
+func NewSQSClient(region string) (adaptor.SQSClient, error) {
+	return &SQSClient{Region: region}, nil
+}
+
+// SQSClient is mock. Sent messages are stored in Input and also can be received by ReceiveMessage.
+type SQSClient struct {
+	Region  string
+	Input   []*sqs.SendMessageInput
+	Deleted []*sqs.DeleteMessageInput
+
+	queues map[string][]*sqs.Message
+}
+
+// SendMessage of mock SQSClient stores sqs.SendMessageInput and puts the message to the queue
+func (x *SQSClient) SendMessage(input *sqs.SendMessageInput) (*sqs.SendMessageOutput, error) {
+	x.Input = append(x.Input, input)
+
+	msgID := uuid.New().String()
+	x.PutMessage(aws.StringValue(input.QueueUrl), &sqs.Message{
+		MessageId:         aws.String(msgID),
+		ReceiptHandle:     aws.String("receipt-" + msgID),
+		Body:              input.MessageBody,
+		MessageAttributes: input.MessageAttributes,
+	})
+
+	return &sqs.SendMessageOutput{MessageId: aws.String(msgID)}, nil
+}
+
+// PutMessage puts a message to the queue directly for testing
+func (x *SQSClient) PutMessage(queueURL string, msg *sqs.Message) {
+	if x.queues == nil {
+		x.queues = make(map[string][]*sqs.Message)
+	}
+	x.queues[queueURL] = append(x.queues[queueURL], msg)
+}
+
+// Messages returns messages remained in the queue
+func (x *SQSClient) Messages(queueURL string) []*sqs.Message {
+	return x.queues[queueURL]
+}
+
+// ReceiveMessage of mock SQSClient returns messages in the queue. Unlike actual SQS, received messages are not hidden.
+func (x *SQSClient) ReceiveMessage(input *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
+	msgs := x.queues[aws.StringValue(input.QueueUrl)]
+	if n := int(aws.Int64Value(input.MaxNumberOfMessages)); n > 0 && n < len(msgs) {
+		msgs = msgs[:n]
+	}
+	return &sqs.ReceiveMessageOutput{Messages: msgs}, nil
+}
+
+// DeleteMessage of mock SQSClient removes the message from the queue by ReceiptHandle
+func (x *SQSClient) DeleteMessage(input *sqs.DeleteMessageInput) (*sqs.DeleteMessageOutput, error) {
+	url := aws.StringValue(input.QueueUrl)
+	msgs := x.queues[url]
+	for i, msg := range msgs {
+		if aws.StringValue(msg.ReceiptHandle) == aws.StringValue(input.ReceiptHandle) {
+			x.queues[url] = append(msgs[:i:i], msgs[i+1:]...)
+			x.Deleted = append(x.Deleted, input)
+			return &sqs.DeleteMessageOutput{}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("receipt handle not found: %s", aws.StringValue(input.ReceiptHandle))
+}
+
+// NewMockSQSClientSet returns a pair of SQSClient and SQSClientFactory
+func NewMockSQSClientSet() (*SQSClient, adaptor.SQSClientFactory) {
+	client := &SQSClient{}
+	return client, func(region string) (adaptor.SQSClient, error) {
+		client.Region = region
+		return client, nil
+	}
+}
@@ -41,18 +41,38 @@ type InspectorReportRecord struct {
 
 type AttributeCache struct {
 	RecordBase
-	Timestamp   time.Time              `dynamo:"timestamp"`
-	AttrKey     string                 `dynamo:"attr_key"`
-	AttrType    string                 `dynamo:"attr_type"`
-	AttrValue   string                 `dynamo:"attr_value"`
-	AttrContext deepalert.AttrContexts `dynamo:"attr_context"`
+	Timestamp       time.Time              `dynamo:"timestamp"`
+	AttrKey         string                 `dynamo:"attr_key"`
+	AttrType        string                 `dynamo:"attr_type"`
+	AttrValue       string                 `dynamo:"attr_value"`
+	AttrContext     deepalert.AttrContexts `dynamo:"attr_context"`
+	InferredContext deepalert.AttrContexts `dynamo:"inferred_context,omitempty"`
+	DerivedFrom     *deepalert.AttrRef     `dynamo:"derived_from,omitempty"`
 }
 
 type ReportEntry struct {
 	RecordBase
-	ID     string `dynamo:"id"`
-	Result string `dynamo:"result"`
-	Status string `dynamo:"status"`
+	ID      string `dynamo:"id"`
+	Result  string `dynamo:"result"`
+	Reviews string `dynamo:"reviews,omitempty"`
+	Status  string `dynamo:"status"`
+}
+
+// IngestionSource is a caller of HTTP ingestion API. It has no ExpiresAt because it must not expire.
+type IngestionSource struct {
+	PKey           string `dynamo:"pk"`
+	SKey           string `dynamo:"sk"`
+	Name           string `dynamo:"name"`
+	APIKeyHash     string `dynamo:"api_key_hash"`
+	HMACSecret     string `dynamo:"hmac_secret"`
+	QuotaPerMinute int64  `dynamo:"quota_per_minute"`
+	CreatedAt      int64  `dynamo:"created_at"`
+}
+
+// Counter is atomic counter that expires.
+type Counter struct {
+	RecordBase
+	Count int64 `dynamo:"count"`
 }
 
 // ErrRecordIsNotReport means DynamoDB record is not event of add/modify report.
@@ -74,6 +94,7 @@ func (x *ReportEntry) ImportDynamoRecord(record *events.DynamoDBEventRecord) err
 
 	x.ID = getString("id")
 	x.Result = getString("result")
+	x.Reviews = getString("reviews")
 	x.Status = getString("status")
 
 	createdAtValue, ok := record.Change.NewImage["created_at"]
@@ -101,6 +122,14 @@ func (x *ReportEntry) Import(report *deepalert.Report) error {
 	}
 	x.Result = string(raw)
 
+	if len(report.Reviews) > 0 {
+		raw, err := json.Marshal(report.Reviews)
+		if err != nil {
+			return golambda.WrapError(err, "Failed to marshal report.Reviews").With("report", report)
+		}
+		x.Reviews = string(raw)
+	}
+
 	x.Status = string(report.Status)
 	x.CreatedAt = report.CreatedAt.UTC().Unix()
 
@@ -115,6 +144,11 @@ func (x *ReportEntry) Export() (*deepalert.Report, error) {
 	if err := json.Unmarshal([]byte(x.Result), &report.Result); err != nil {
 		return nil, golambda.WrapError(err, "Failed to unmarshal reprot.Result").With("entry", *x)
 	}
+	if x.Reviews != "" {
+		if err := json.Unmarshal([]byte(x.Reviews), &report.Reviews); err != nil {
+			return nil, golambda.WrapError(err, "Failed to unmarshal report.Reviews").With("entry", *x)
+		}
+	}
 
 	report.Status = deepalert.ReportStatus(x.Status)
 	report.CreatedAt = time.Unix(x.CreatedAt, 0)
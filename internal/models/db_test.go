@@ -119,6 +119,24 @@ func TestReportEntry(t *testing.T) {
 			assert.Equal(tt, r1.CreatedAt.UTC().Unix(), r2.CreatedAt.Unix())
 		})
 	})
+
+	t.Run("Reviews are kept", func(tt *testing.T) {
+		r1 := &deepalert.Report{
+			ID:     "xba124",
+			Result: deepalert.ReportResult{Severity: deepalert.SevUrgent, Reason: "cloud: bad"},
+			Reviews: []*deepalert.ReviewResult{
+				{Reviewer: "cloud", Result: &deepalert.ReportResult{Severity: deepalert.SevUrgent, Reason: "bad"}},
+				{Reviewer: "identity", Result: nil},
+			},
+			CreatedAt: time.Now(),
+		}
+
+		var entry models.ReportEntry
+		require.NoError(tt, entry.Import(r1))
+		r2, err := entry.Export()
+		require.NoError(tt, err)
+		assert.Equal(tt, r1.Reviews, r2.Reviews)
+	})
 }
 
 func TestImportDynamoRecord(t *testing.T) {
@@ -145,6 +145,57 @@ func (x *DynamoDBRepository) GetReport(pk string) (*deepalert.Report, error) {
 	return report, nil
 }
 
+func (x *DynamoDBRepository) ScanReports(pkPrefix string) ([]*deepalert.Report, error) {
+	var entries []*models.ReportEntry
+	if err := x.table.Scan().Filter("begins_with($, ?) AND $ = ?", "pk", pkPrefix, "sk", "-").All(&entries); err != nil {
+		return nil, golambda.WrapError(err, "Failed to scan reports").With("pkPrefix", pkPrefix)
+	}
+
+	var reports []*deepalert.Report
+	for _, entry := range entries {
+		report, err := entry.Export()
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func (x *DynamoDBRepository) PutIngestionSource(source *models.IngestionSource) error {
+	if err := x.table.Put(source).Run(); err != nil {
+		return golambda.WrapError(err, "Failed PutIngestionSource").With("name", source.Name)
+	}
+	return nil
+}
+
+func (x *DynamoDBRepository) GetIngestionSource(pk, sk string) (*models.IngestionSource, error) {
+	var output models.IngestionSource
+	if err := x.table.Get("pk", pk).Range("sk", dynamo.Equal, sk).One(&output); err != nil {
+		if err == dynamo.ErrNotFound {
+			return nil, nil
+		}
+		return nil, golambda.WrapError(err, "Failed GetIngestionSource").With("pk", pk)
+	}
+	return &output, nil
+}
+
+func (x *DynamoDBRepository) DeleteIngestionSource(pk, sk string) error {
+	if err := x.table.Delete("pk", pk).Range("sk", sk).Run(); err != nil {
+		return golambda.WrapError(err, "Failed DeleteIngestionSource").With("pk", pk)
+	}
+	return nil
+}
+
+func (x *DynamoDBRepository) IncrementCounter(pk, sk string, delta int64, expiresAt int64) (int64, error) {
+	var output models.Counter
+	query := x.table.Update("pk", pk).Range("sk", sk).Add("count", delta).Set("expires_at", expiresAt)
+	if err := query.Value(&output); err != nil {
+		return 0, golambda.WrapError(err, "Failed IncrementCounter").With("pk", pk)
+	}
+	return output.Count, nil
+}
+
 // Error handling
 
 func (x *DynamoDBRepository) IsConditionalCheckErr(err error) bool {
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"sort"
@@ -36,6 +37,7 @@ import (
 	- alert/{ReportID}, cache/{random} -> Alert(s)
 	- content/{ReportID}, {AttrHash}/{Random} -> Content(S)
 	- attribute/{ReportID}, {AttrHash} -> Attribute (for caching)
+	- ingested/{RecordHash}, fixedkey -> ReportID (to skip records that have been ingested)
 */
 
 const (
@@ -118,6 +120,46 @@ func (x *RepositoryService) GetReportID(alertID string) (deepalert.ReportID, err
 	return existedEntry.ReportID, nil
 }
 
+// -----------------------------------------------------------
+// Control ingestion entry to make ingestion of stream records and file lines idempotent
+//
+
+func toIngestionPKey(recordKey string) string {
+	return fmt.Sprintf("ingested/%x", sha256.Sum256([]byte(recordKey)))
+}
+
+// GetIngestedReportID returns ReportID of the record if the record has been ingested. Otherwise, it returns NullReportID.
+func (x *RepositoryService) GetIngestedReportID(recordKey string, now time.Time) (deepalert.ReportID, error) {
+	entry, err := x.repo.GetAlertEntry(toIngestionPKey(recordKey), alertMapfixedKey)
+	if err != nil {
+		return deepalert.NullReportID, golambda.WrapError(err, "Fail to get ingestion entry").With("recordKey", recordKey)
+	}
+	if entry == nil || entry.ExpiresAt < now.UTC().Unix() {
+		return deepalert.NullReportID, nil
+	}
+
+	return entry.ReportID, nil
+}
+
+// PutIngestedRecord marks the record as ingested. It's not error that the record has been marked already.
+func (x *RepositoryService) PutIngestedRecord(recordKey string, reportID deepalert.ReportID, now time.Time) error {
+	entry := models.AlertEntry{
+		RecordBase: models.RecordBase{
+			PKey:      toIngestionPKey(recordKey),
+			SKey:      alertMapfixedKey,
+			ExpiresAt: now.UTC().Add(x.ttl).Unix(),
+			CreatedAt: now.UTC().Unix(),
+		},
+		ReportID: reportID,
+	}
+
+	if err := x.repo.PutAlertEntry(&entry, now); err != nil && !x.repo.IsConditionalCheckErr(err) {
+		return golambda.WrapError(err, "Fail to put ingestion entry").With("recordKey", recordKey)
+	}
+
+	return nil
+}
+
 // -----------------------------------------------------------
 // Control alertCache to manage published alert data
 //
@@ -232,23 +274,16 @@ func (x *RepositoryService) FetchSection(reportID deepalert.ReportID) ([]*deepal
 	return sections, nil
 }
 
-func rebuildContent(src interface{}, dst interface{}) error {
-	raw, err := json.Marshal(src)
-	if err != nil {
-		return golambda.NewError("Failed to marshal content")
-	}
-	if err := json.Unmarshal(raw, dst); err != nil {
-		return golambda.WrapError(err, "Failed to unmarshal marshaled content").With("raw", string(raw))
-	}
-
-	return nil
+func invalidContent(ir *deepalert.Finding) error {
+	return golambda.NewError("Content does not match type").With("type", ir.Type).With("author", ir.Author)
 }
 
 func remapSection(inspectReports []*deepalert.Finding) ([]*deepalert.Section, error) {
 	sections := map[string]*deepalert.Section{}
+	findings := map[string][]*deepalert.Finding{}
 
 	for _, ir := range inspectReports {
-		hv := ir.Attribute.Hash()
+		hv := ir.Attribute.HashWith(deepalert.HashModeIdentity)
 		section, ok := sections[hv]
 		if !ok {
 			section = &deepalert.Section{
@@ -256,27 +291,105 @@ func remapSection(inspectReports []*deepalert.Finding) ([]*deepalert.Section, er
 			}
 			sections[hv] = section
 		}
+		findings[hv] = append(findings[hv], ir)
+
+		section.AddInspection(ir)
+		if !ir.HasContent() {
+			continue
+		}
+
+		var index int
 		switch ir.Type {
 		case deepalert.ContentTypeHost:
-			var c deepalert.ContentHost
-			if err := rebuildContent(ir.Content, &c); err != nil {
-				return nil, golambda.WrapError(err, "Invalid deepalert.ContentHost data")
+			c, ok := ir.AsHost()
+			if !ok {
+				return nil, invalidContent(ir)
 			}
-			section.Hosts = append(section.Hosts, &c)
+			section.Hosts = append(section.Hosts, c)
+			index = len(section.Hosts) - 1
 
 		case deepalert.ContentTypeUser:
-			var c deepalert.ContentUser
-			if err := rebuildContent(ir.Content, &c); err != nil {
-				return nil, golambda.WrapError(err, "Invalid deepalert.ContentUser data")
+			c, ok := ir.AsUser()
+			if !ok {
+				return nil, invalidContent(ir)
 			}
-			section.Users = append(section.Users, &c)
+			section.Users = append(section.Users, c)
+			index = len(section.Users) - 1
 
 		case deepalert.ContentTypeBinary:
-			var c deepalert.ContentBinary
-			if err := rebuildContent(ir.Content, &c); err != nil {
-				return nil, golambda.WrapError(err, "Invalid deepalert.ContentBinary data")
+			c, ok := ir.AsBinary()
+			if !ok {
+				return nil, invalidContent(ir)
+			}
+			section.Binaries = append(section.Binaries, c)
+			index = len(section.Binaries) - 1
+
+		case deepalert.ContentTypeCloudResource:
+			c, ok := ir.AsCloudResource()
+			if !ok {
+				return nil, invalidContent(ir)
 			}
-			section.Binaries = append(section.Binaries, &c)
+			section.CloudResources = append(section.CloudResources, c)
+			index = len(section.CloudResources) - 1
+
+		case deepalert.ContentTypeVulnerability:
+			c, ok := ir.AsVulnerability()
+			if !ok {
+				return nil, invalidContent(ir)
+			}
+			section.Vulnerabilities = append(section.Vulnerabilities, c)
+			index = len(section.Vulnerabilities) - 1
+
+		case deepalert.ContentTypeThreatIntel:
+			c, ok := ir.AsThreatIntel()
+			if !ok {
+				return nil, invalidContent(ir)
+			}
+			section.ThreatIntel = append(section.ThreatIntel, c)
+			index = len(section.ThreatIntel) - 1
+
+		case deepalert.ContentTypeProcessTree:
+			c, ok := ir.AsProcessTree()
+			if !ok {
+				return nil, invalidContent(ir)
+			}
+			section.ProcessTrees = append(section.ProcessTrees, c)
+			index = len(section.ProcessTrees) - 1
+
+		case deepalert.ContentTypeGeolocation:
+			c, ok := ir.AsGeolocation()
+			if !ok {
+				return nil, invalidContent(ir)
+			}
+			section.Geolocations = append(section.Geolocations, c)
+			index = len(section.Geolocations) - 1
+
+		default:
+			content, err := ir.ReportContent()
+			if err != nil {
+				return nil, golambda.WrapError(err, "Invalid custom content data").With("type", ir.Type)
+			}
+			section.Custom = append(section.Custom, &deepalert.SectionContent{
+				Author:  ir.Author,
+				Type:    ir.Type,
+				Content: content,
+			})
+			index = len(section.Custom) - 1
+		}
+
+		if ir.Provenance != nil && !ir.Provenance.IsEmpty() {
+			section.Provenances = append(section.Provenances, &deepalert.ContentProvenance{
+				Type:       ir.Type,
+				Index:      index,
+				Author:     ir.Author,
+				Provenance: *ir.Provenance,
+			})
+		}
+	}
+
+	for hv, section := range sections {
+		if summary := deepalert.SummarizeFindings(findings[hv]); !summary.IsEmpty() {
+			section.Summary = summary
 		}
 	}
 
@@ -331,7 +444,9 @@ func IsReportStreamEvent(record *events.DynamoDBEventRecord) bool {
 }
 
 // PutAttributeCache puts attributeCache to DB and returns true. If the attribute alrady exists,
-// it returns false.
+// it returns false. Attributes are identified by deepalert.HashModeIdentity, then the same entity with
+// different notation or observed time is inspected only once. attr is saved as given, so it should be
+// normalized in advance.
 func (x *RepositoryService) PutAttributeCache(reportID deepalert.ReportID, attr deepalert.Attribute, now time.Time) (bool, error) {
 	var ts time.Time
 	if attr.Timestamp != nil {
@@ -343,14 +458,16 @@ func (x *RepositoryService) PutAttributeCache(reportID deepalert.ReportID, attr
 	cache := &models.AttributeCache{
 		RecordBase: models.RecordBase{
 			PKey:      toAttributeCacheKey(reportID),
-			SKey:      attr.Hash(),
+			SKey:      attr.HashWith(deepalert.HashModeIdentity),
 			ExpiresAt: now.Add(x.ttl).Unix(),
 		},
-		Timestamp:   ts,
-		AttrKey:     attr.Key,
-		AttrType:    string(attr.Type),
-		AttrValue:   attr.Value,
-		AttrContext: attr.Context,
+		Timestamp:       ts,
+		AttrKey:         attr.Key,
+		AttrType:        string(attr.Type),
+		AttrValue:       attr.Value,
+		AttrContext:     attr.Context,
+		InferredContext: attr.InferredContext,
+		DerivedFrom:     attr.DerivedFrom,
 	}
 
 	if err := x.repo.PutAttributeCache(cache, now); err != nil {
@@ -379,11 +496,13 @@ func (x *RepositoryService) FetchAttributeCache(reportID deepalert.ReportID) ([]
 	var attrs []*deepalert.Attribute
 	for _, cache := range caches {
 		attr := deepalert.Attribute{
-			Type:      deepalert.AttrType(cache.AttrType),
-			Key:       cache.AttrKey,
-			Value:     cache.AttrValue,
-			Context:   cache.AttrContext,
-			Timestamp: &cache.Timestamp,
+			Type:            deepalert.AttrType(cache.AttrType),
+			Key:             cache.AttrKey,
+			Value:           cache.AttrValue,
+			Context:         cache.AttrContext,
+			InferredContext: cache.InferredContext,
+			Timestamp:       &cache.Timestamp,
+			DerivedFrom:     cache.DerivedFrom,
 		}
 
 		attrs = append(attrs, &attr)
@@ -401,6 +520,20 @@ func (x *RepositoryService) PutReport(report *deepalert.Report) error {
 	return nil
 }
 
+// ListReports returns reports without attributes, alerts and sections. The reports are sorted by CreatedAt in descending order.
+func (x *RepositoryService) ListReports() ([]*deepalert.Report, error) {
+	reports, err := x.repo.ScanReports(toReportKey(""))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].CreatedAt.After(reports[j].CreatedAt)
+	})
+
+	return reports, nil
+}
+
 // GetReport gets a report by a key based on report.ID with attributes, alerts and sections.
 func (x *RepositoryService) GetReport(reportID deepalert.ReportID) (*deepalert.Report, error) {
 	pk := toReportKey(reportID)
@@ -433,3 +566,54 @@ func (x *RepositoryService) GetReport(reportID deepalert.ReportID) (*deepalert.R
 
 	return report, nil
 }
+
+// -----------------------------------------------------------
+// Control ingestion source and quota counter for HTTP ingestion API
+//
+
+func toIngestionSourceKey(name string) (string, string) {
+	return "source/" + name, "-"
+}
+
+// PutIngestionSource saves the source. Existing source with the same name is overwritten.
+func (x *RepositoryService) PutIngestionSource(source *models.IngestionSource) error {
+	source.PKey, source.SKey = toIngestionSourceKey(source.Name)
+	return x.repo.PutIngestionSource(source)
+}
+
+// GetIngestionSource returns nil without error if the source does not exist.
+func (x *RepositoryService) GetIngestionSource(name string) (*models.IngestionSource, error) {
+	pk, sk := toIngestionSourceKey(name)
+	return x.repo.GetIngestionSource(pk, sk)
+}
+
+func (x *RepositoryService) DeleteIngestionSource(name string) error {
+	pk, sk := toIngestionSourceKey(name)
+	return x.repo.DeleteIngestionSource(pk, sk)
+}
+
+// quotaWindow is time window of ingestion quota
+const quotaWindow = time.Minute
+
+// ReserveIngestion adds n to number of ingested alerts of the source in current time window if the total does not
+// exceed limit, and returns whether the alerts are accepted and end of the window. Rejected alerts are not counted.
+func (x *RepositoryService) ReserveIngestion(name string, n, limit int64, now time.Time) (bool, time.Time, error) {
+	start := now.UTC().Truncate(quotaWindow)
+	end := start.Add(quotaWindow)
+	pk := fmt.Sprintf("quota/%s/%d", name, start.Unix())
+	expiresAt := end.Add(quotaWindow).Unix()
+
+	count, err := x.repo.IncrementCounter(pk, "-", n, expiresAt)
+	if err != nil {
+		return false, end, err
+	}
+	if count <= limit {
+		return true, end, nil
+	}
+
+	// Roll back the count so that rejected alerts do not consume quota
+	if _, err := x.repo.IncrementCounter(pk, "-", -n, expiresAt); err != nil {
+		return false, end, err
+	}
+	return false, end, nil
+}
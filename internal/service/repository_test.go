@@ -164,8 +164,148 @@ func testFinding(t *testing.T, svc *service.RepositoryService) {
 		assert.Contains(tt, attrs, sections[0].Attr)
 		assert.Contains(tt, attrs, sections[1].Attr)
 	})
+
+	t.Run("New built-in content types are remapped", func(tt *testing.T) {
+		id := deepalert.ReportID(uuid.New().String())
+		attr := deepalert.Attribute{Type: deepalert.TypeIPAddr, Value: "192.0.2.1"}
+		now := time.Now()
+		contents := []deepalert.ReportContent{
+			&deepalert.ContentCloudResource{Provider: "aws", ResourceID: "i-0123"},
+			&deepalert.ContentVulnerability{CVE: "CVE-2021-44228"},
+			&deepalert.ContentThreatIntel{Feed: "f1", Verdict: deepalert.VerdictSuspicious},
+			&deepalert.ContentProcessTree{Processes: []deepalert.EntityProcess{{PID: 1, Name: "init"}}},
+			&deepalert.ContentGeolocation{Latitude: 1.5, Longitude: 2.5},
+		}
+		for _, c := range contents {
+			require.NoError(tt, svc.SaveFinding(deepalert.Finding{
+				ReportID: id, Author: "a1", Attribute: attr, Type: c.Type(), Content: c,
+			}, now))
+		}
+
+		sections, err := svc.FetchSection(id)
+		require.NoError(tt, err)
+		require.Equal(tt, 1, len(sections))
+		section := sections[0]
+		require.Equal(tt, 1, len(section.CloudResources))
+		assert.Equal(tt, "i-0123", section.CloudResources[0].ResourceID)
+		require.Equal(tt, 1, len(section.Vulnerabilities))
+		assert.Equal(tt, "CVE-2021-44228", section.Vulnerabilities[0].CVE)
+		require.Equal(tt, 1, len(section.ThreatIntel))
+		assert.Equal(tt, deepalert.VerdictSuspicious, section.ThreatIntel[0].Verdict)
+		require.Equal(tt, 1, len(section.ProcessTrees))
+		assert.Equal(tt, "init", section.ProcessTrees[0].Processes[0].Name)
+		require.Equal(tt, 1, len(section.Geolocations))
+		assert.Equal(tt, 2.5, section.Geolocations[0].Longitude)
+		assert.Equal(tt, 0, len(section.Custom))
+	})
+
+	t.Run("Provenance is kept per content", func(tt *testing.T) {
+		id := deepalert.ReportID(uuid.New().String())
+		attr := deepalert.Attribute{Type: deepalert.TypeIPAddr, Value: "192.0.2.2"}
+		now := time.Now().UTC()
+		require.NoError(tt, svc.SaveFinding(deepalert.Finding{
+			ReportID: id, Author: "a1", Attribute: attr, Type: deepalert.ContentTypeHost,
+			Content: &deepalert.ContentHost{HostName: []string{"h1"}},
+		}, now))
+		require.NoError(tt, svc.SaveFinding(deepalert.Finding{
+			ReportID: id, Author: "a2", Attribute: attr, Type: deepalert.ContentTypeHost,
+			Content: &deepalert.ContentHost{HostName: []string{"h2"}},
+			Provenance: &deepalert.Provenance{
+				ProducedAt:       &now,
+				InspectorVersion: "v2",
+				Confidence:       70,
+				EvidenceURLs:     []string{"https://example.com/e"},
+			},
+		}, now))
+
+		sections, err := svc.FetchSection(id)
+		require.NoError(tt, err)
+		require.Equal(tt, 1, len(sections))
+		section := sections[0]
+		require.Equal(tt, 2, len(section.Hosts))
+		require.Equal(tt, 1, len(section.Provenances))
+
+		p := section.Provenances[0]
+		assert.Equal(tt, "a2", p.Author)
+		assert.Equal(tt, "v2", p.InspectorVersion)
+		assert.Equal(tt, 70, p.Confidence)
+		assert.Equal(tt, []string{"h2"}, section.Hosts[p.Index].HostName)
+		assert.Equal(tt, p, section.ProvenanceOf(deepalert.ContentTypeHost, p.Index))
+		assert.Nil(tt, section.ProvenanceOf(deepalert.ContentTypeHost, 1-p.Index))
+	})
+
+	t.Run("Status of inspections are kept", func(tt *testing.T) {
+		id := deepalert.ReportID(uuid.New().String())
+		attr := deepalert.Attribute{Type: deepalert.TypeIPAddr, Value: "192.0.2.3"}
+		now := time.Now().UTC()
+		findings := []deepalert.Finding{
+			{ReportID: id, Author: "clean", Attribute: attr, Status: deepalert.FindingNotFound},
+			{ReportID: id, Author: "broken", Attribute: attr, Status: deepalert.FindingFailed, Error: "timeout"},
+			{ReportID: id, Author: "retried", Attribute: attr, Status: deepalert.FindingFailed, Error: "rate limit"},
+			{ReportID: id, Author: "retried", Attribute: attr, Type: deepalert.ContentTypeHost,
+				Content: &deepalert.ContentHost{HostName: []string{"h1"}}},
+		}
+		for _, f := range findings {
+			require.NoError(tt, svc.SaveFinding(f, now))
+		}
+
+		sections, err := svc.FetchSection(id)
+		require.NoError(tt, err)
+		require.Equal(tt, 1, len(sections))
+		section := sections[0]
+		assert.Equal(tt, 1, len(section.Hosts))
+		require.Equal(tt, 3, len(section.Inspections))
+		assert.Equal(tt, deepalert.FindingFailed, section.InspectionOf("broken").Status)
+		assert.Equal(tt, "timeout", section.InspectionOf("broken").Error)
+		assert.Equal(tt, deepalert.FindingNotFound, section.InspectionOf("clean").Status)
+		assert.Equal(tt, deepalert.FindingFound, section.InspectionOf("retried").Status)
+		assert.Nil(tt, section.InspectionOf("unknown"))
+
+		report := deepalert.Report{Sections: sections}
+		assert.Equal(tt, 1, len(report.FailedInspections()))
+		assert.Equal(tt, 0, len(report.FailedInspections("clean", "retried")))
+	})
+
+	t.Run("Custom and unknown content types are kept", func(tt *testing.T) {
+		require.NoError(tt, deepalert.RegisterContentType("ticket", deepalert.NewJSONContentDecoder(
+			func() deepalert.ReportContent { return &ticketContent{} })))
+		defer deepalert.UnregisterContentType("ticket")
+
+		id := deepalert.ReportID(uuid.New().String())
+		attr := deepalert.Attribute{Type: deepalert.TypeIPAddr, Value: "10.0.0.1"}
+		now := time.Now()
+		require.NoError(tt, svc.SaveFinding(deepalert.Finding{
+			ReportID: id, Author: "a1", Attribute: attr,
+			Type: "ticket", Content: &ticketContent{ID: "SEC-1"},
+		}, now))
+		require.NoError(tt, svc.SaveFinding(deepalert.Finding{
+			ReportID: id, Author: "a2", Attribute: attr,
+			Type: "unknown", Content: map[string]interface{}{"x": 1},
+		}, now))
+
+		sections, err := svc.FetchSection(id)
+		require.NoError(tt, err)
+		require.Equal(tt, 1, len(sections))
+		require.Equal(tt, 2, len(sections[0].Custom))
+
+		byAuthor := map[string]*deepalert.SectionContent{}
+		for _, c := range sections[0].Custom {
+			byAuthor[c.Author] = c
+		}
+		assert.Equal(tt, &ticketContent{ID: "SEC-1"}, byAuthor["a1"].Content)
+		raw, ok := byAuthor["a2"].Content.(*deepalert.ContentRaw)
+		require.True(tt, ok)
+		assert.Equal(tt, deepalert.ReportContentType("unknown"), raw.Type())
+		assert.JSONEq(tt, `{"x":1}`, string(raw.Data))
+	})
 }
 
+type ticketContent struct {
+	ID string `json:"id"`
+}
+
+func (x *ticketContent) Type() deepalert.ReportContentType { return "ticket" }
+
 func testAttributeCache(t *testing.T, svc *service.RepositoryService) {
 	t.Run("Put and Fetch attributes", func(t *testing.T) {
 		id1 := deepalert.ReportID(uuid.New().String())
@@ -242,6 +382,59 @@ func testAttributeCache(t *testing.T, svc *service.RepositoryService) {
 		attrs[0].Timestamp = nil
 		assert.Equal(t, attr1, *attrs[0])
 	})
+
+	t.Run("Same attribute in different notation and time", func(t *testing.T) {
+		id1 := deepalert.ReportID(uuid.New().String())
+		now := time.Now()
+		ts := now.Add(-time.Hour)
+
+		attr1 := deepalert.Attribute{
+			Type:    deepalert.TypeDomainName,
+			Context: deepalert.AttrContexts{deepalert.CtxRemote, deepalert.CtxServer},
+			Key:     "domain",
+			Value:   "EXAMPLE.com.",
+		}
+		attr2 := deepalert.Attribute{
+			Type:      deepalert.TypeDomainName,
+			Context:   deepalert.AttrContexts{deepalert.CtxServer, deepalert.CtxRemote},
+			Key:       "domain",
+			Value:     "example[.]com",
+			Timestamp: &ts,
+		}
+
+		b1, err := svc.PutAttributeCache(id1, attr1, now)
+		require.NoError(t, err)
+		assert.True(t, b1)
+		b2, err := svc.PutAttributeCache(id1, attr2, now)
+		require.NoError(t, err)
+		assert.False(t, b2)
+
+		attrs, err := svc.FetchAttributeCache(id1)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(attrs))
+		// Value is saved as given. Attributes are normalized at intake of alerts and feedback
+		assert.Equal(t, "EXAMPLE.com.", attrs[0].Value)
+	})
+
+	t.Run("Derived attribute keeps parent", func(t *testing.T) {
+		id1 := deepalert.ReportID(uuid.New().String())
+		parent := &deepalert.AttrRef{Type: deepalert.TypeURL, Key: "url", Value: "https://www.example.com/"}
+		attr := deepalert.Attribute{
+			Type:        deepalert.TypeDomainName,
+			Key:         "url.host",
+			Value:       "www.example.com",
+			DerivedFrom: parent,
+		}
+
+		b, err := svc.PutAttributeCache(id1, attr, time.Now())
+		require.NoError(t, err)
+		assert.True(t, b)
+
+		attrs, err := svc.FetchAttributeCache(id1)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(attrs))
+		assert.Equal(t, parent, attrs[0].DerivedFrom)
+	})
 }
 
 func testRpoert(t *testing.T, svc *service.RepositoryService) {
@@ -342,6 +535,31 @@ func testRpoert(t *testing.T, svc *service.RepositoryService) {
 		require.NoError(tt, err)
 		assert.Nil(tt, r0)
 	})
+
+	t.Run("List", func(tt *testing.T) {
+		now := time.Now().UTC().Truncate(time.Second)
+		r1 := &deepalert.Report{ID: deepalert.ReportID(uuid.New().String()), Status: deepalert.StatusNew, CreatedAt: now.Add(-time.Minute)}
+		r2 := &deepalert.Report{ID: deepalert.ReportID(uuid.New().String()), Status: deepalert.StatusPublished, CreatedAt: now}
+		require.NoError(tt, svc.PutReport(r1))
+		require.NoError(tt, svc.PutReport(r2))
+
+		reports, err := svc.ListReports()
+		require.NoError(tt, err)
+
+		var idx1, idx2 = -1, -1
+		for i, r := range reports {
+			switch r.ID {
+			case r1.ID:
+				idx1 = i
+			case r2.ID:
+				idx2 = i
+			}
+		}
+		require.NotEqual(tt, -1, idx1)
+		require.NotEqual(tt, -1, idx2)
+		assert.Less(tt, idx2, idx1) // Newer report comes first
+		assert.Equal(tt, deepalert.StatusPublished, reports[idx2].Status)
+	})
 }
 
 func TestDynamoDBRepository(t *testing.T) {
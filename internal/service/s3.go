@@ -0,0 +1,70 @@
+package service
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cookpad/deepalert/internal/adaptor"
+	"github.com/m-mizutani/golambda"
+)
+
+// S3Service is accessor to S3
+type S3Service struct {
+	newS3 adaptor.S3ClientFactory
+}
+
+// NewS3Service is constructor of S3Service
+func NewS3Service(newS3 adaptor.S3ClientFactory) *S3Service {
+	return &S3Service{
+		newS3: newS3,
+	}
+}
+
+type gzipReadCloser struct {
+	*gzip.Reader
+	body io.Closer
+}
+
+func (x *gzipReadCloser) Close() error {
+	if err := x.Reader.Close(); err != nil {
+		return err
+	}
+	return x.body.Close()
+}
+
+type bufferedReadCloser struct {
+	*bufio.Reader
+	io.Closer
+}
+
+// GetObject is wrapper of s3:GetObject of AWS. Gzip compressed object is decompressed automatically
+// by magic number regardless of key or Content-Encoding. Caller must close returned reader.
+func (x *S3Service) GetObject(region, bucket, key string) (io.ReadCloser, error) {
+	client, err := x.newS3(region)
+	if err != nil {
+		return nil, golambda.WrapError(err).With("region", region)
+	}
+
+	output, err := client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, golambda.WrapError(err, "Fail to get S3 object").With("bucket", bucket).With("key", key)
+	}
+
+	reader := bufio.NewReader(output.Body)
+	if magic, err := reader.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			output.Body.Close()
+			return nil, golambda.WrapError(err, "Fail to read gzip header").With("bucket", bucket).With("key", key)
+		}
+		return &gzipReadCloser{Reader: gz, body: output.Body}, nil
+	}
+
+	return &bufferedReadCloser{Reader: reader, Closer: output.Body}, nil
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"regexp"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/cookpad/deepalert/internal/adaptor"
+	"github.com/m-mizutani/golambda"
+)
+
+// SQSService is accessor to SQS
+type SQSService struct {
+	newSQS adaptor.SQSClientFactory
+}
+
+// NewSQSService is constructor of SQSService
+func NewSQSService(newSQS adaptor.SQSClientFactory) *SQSService {
+	return &SQSService{
+		newSQS: newSQS,
+	}
+}
+
+// Sample: https://sqs.ap-northeast-1.amazonaws.com/123456789xxx/some-queue-name
+var regexSQSURL = regexp.MustCompile(`^https://sqs\.([a-z0-9-]+)\.amazonaws\.com/`)
+
+func extractSQSRegion(queueURL string) (string, error) {
+	m := regexSQSURL.FindStringSubmatch(queueURL)
+	if len(m) != 2 {
+		return "", golambda.NewError("Invalid SQS queue URL").With("URL", queueURL)
+	}
+
+	return m[1], nil
+}
+
+// SendMessage is wrapper of sqs:SendMessage of AWS. attrs are set as String type message attributes.
+func (x *SQSService) SendMessage(queueURL string, body string, attrs map[string]string) error {
+	region, err := extractSQSRegion(queueURL)
+	if err != nil {
+		return err
+	}
+
+	client, err := x.newSQS(region)
+	if err != nil {
+		return golambda.WrapError(err, "Failed to create new SQS adaptor")
+	}
+
+	input := sqs.SendMessageInput{
+		QueueUrl:    aws.String(queueURL),
+		MessageBody: aws.String(body),
+	}
+
+	for key, value := range attrs {
+		// SQS rejects a message attribute with empty value
+		if value == "" {
+			continue
+		}
+		if input.MessageAttributes == nil {
+			input.MessageAttributes = make(map[string]*sqs.MessageAttributeValue)
+		}
+		input.MessageAttributes[key] = &sqs.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(value),
+		}
+	}
+
+	resp, err := client.SendMessage(&input)
+	if err != nil {
+		return golambda.WrapError(err, "Fail to send SQS message").With("url", queueURL)
+	}
+
+	logger.With("resp", resp).Trace("Sent SQS message")
+
+	return nil
+}
+
+// ReceiveMessages is wrapper of sqs:ReceiveMessage of AWS. Received messages become invisible
+// for visibilityTimeout seconds and appear again if they are not deleted.
+func (x *SQSService) ReceiveMessages(queueURL string, maxMessages, visibilityTimeout int64) ([]*sqs.Message, error) {
+	region, err := extractSQSRegion(queueURL)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := x.newSQS(region)
+	if err != nil {
+		return nil, golambda.WrapError(err, "Failed to create new SQS adaptor")
+	}
+
+	input := sqs.ReceiveMessageInput{
+		QueueUrl:              aws.String(queueURL),
+		MaxNumberOfMessages:   aws.Int64(maxMessages),
+		VisibilityTimeout:     aws.Int64(visibilityTimeout),
+		AttributeNames:        []*string{aws.String(sqs.QueueAttributeNameAll)},
+		MessageAttributeNames: []*string{aws.String(sqs.QueueAttributeNameAll)},
+	}
+
+	resp, err := client.ReceiveMessage(&input)
+	if err != nil {
+		return nil, golambda.WrapError(err, "Fail to receive SQS message").With("url", queueURL)
+	}
+
+	return resp.Messages, nil
+}
+
+// DeleteMessage is wrapper of sqs:DeleteMessage of AWS
+func (x *SQSService) DeleteMessage(queueURL, receiptHandle string) error {
+	region, err := extractSQSRegion(queueURL)
+	if err != nil {
+		return err
+	}
+
+	client, err := x.newSQS(region)
+	if err != nil {
+		return golambda.WrapError(err, "Failed to create new SQS adaptor")
+	}
+
+	input := sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(queueURL),
+		ReceiptHandle: aws.String(receiptHandle),
+	}
+	if _, err := client.DeleteMessage(&input); err != nil {
+		return golambda.WrapError(err, "Fail to delete SQS message").With("url", queueURL)
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/cookpad/deepalert"
@@ -10,11 +11,39 @@ import (
 
 var logger = golambda.Logger
 
+// ValidateAlert normalizes attributes of alert and then validates it. Attributes are normalized first so that
+// a defanged value (e.g. "example[.]com") is refanged before it's checked against the type.
+func ValidateAlert(alert *deepalert.Alert) error {
+	normalizeAttributes(alert.Attributes)
+	if err := alert.Validate(); err != nil {
+		return golambda.WrapError(err, "Invalid alert format")
+	}
+	return nil
+}
+
+func normalizeAttributes(attrs []deepalert.Attribute) {
+	for i := range attrs {
+		attrs[i] = attrs[i].Normalize()
+	}
+}
+
 // HandleAlert creates a report from alert and invoke delay machines
 func HandleAlert(args *handler.Arguments, alert *deepalert.Alert, now time.Time) (*deepalert.Report, error) {
-	if err := alert.Validate(); err != nil {
-		return nil, golambda.WrapError(err, "Invalid alert format")
+	if err := ValidateAlert(alert); err != nil {
+		return nil, err
 	}
+	return handleAlert(args, alert, now)
+}
+
+// handleAlert is HandleAlert for alert that is already normalized and validated by ValidateAlert.
+func handleAlert(args *handler.Arguments, alert *deepalert.Alert, now time.Time) (*deepalert.Report, error) {
+	enricher, err := LoadEnricher(args)
+	if err != nil {
+		return nil, err
+	}
+	n := len(alert.Attributes)
+	enricher.Enrich(alert)
+	normalizeAttributes(alert.Attributes[n:])
 
 	logger.With("alert_id", alert.AlertID()).Info("Taking report")
 
@@ -65,3 +94,51 @@ func HandleAlert(args *handler.Arguments, alert *deepalert.Alert, now time.Time)
 
 	return report, nil
 }
+
+// HandleAlerts handles alerts decoded from one message and returns IDs of their reports. If the message has
+// multiple alerts, each handled alert is recorded with "{messageKey}#{index}" and skipped when the message is
+// retried, so that a transient failure of one alert does not handle the others again and start duplicate review
+// executions. messageKey must identify the message, e.g. hash of its body.
+func HandleAlerts(args *handler.Arguments, messageKey string, alerts []*deepalert.Alert, now time.Time) ([]deepalert.ReportID, error) {
+	for _, alert := range alerts {
+		if err := ValidateAlert(alert); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(alerts) == 1 {
+		report, err := handleAlert(args, alerts[0], now)
+		if err != nil {
+			return nil, err
+		}
+		return []deepalert.ReportID{report.ID}, nil
+	}
+
+	repo, err := args.Repository()
+	if err != nil {
+		return nil, err
+	}
+
+	var reportIDs []deepalert.ReportID
+	for i, alert := range alerts {
+		key := fmt.Sprintf("%s#%d", messageKey, i)
+		if reportID, err := repo.GetIngestedReportID(key, now); err != nil {
+			return nil, err
+		} else if reportID != deepalert.NullReportID {
+			logger.With("key", key).With("reportID", reportID).Debug("Skip handled alert")
+			reportIDs = append(reportIDs, reportID)
+			continue
+		}
+
+		report, err := handleAlert(args, alert, now)
+		if err != nil {
+			return nil, golambda.WrapError(err, "Fail to handle alert").With("key", key)
+		}
+		if err := repo.PutIngestedRecord(key, report.ID, now); err != nil {
+			return nil, err
+		}
+		reportIDs = append(reportIDs, report.ID)
+	}
+
+	return reportIDs, nil
+}
@@ -31,6 +31,55 @@ func TestHandleAlert(t *testing.T) {
 		return args, dummySFn, dummyRepo
 	}
 
+	t.Run("Attributes of alert are normalized", func(t *testing.T) {
+		alert := &deepalert.Alert{
+			AlertKey: "6",
+			RuleID:   "six",
+			Detector: "ao",
+			Attributes: []deepalert.Attribute{
+				{Type: deepalert.TypeDomainName, Key: "domain", Value: "WWW.Example.COM"},
+				{Type: deepalert.TypeEmailAddress, Key: "from", Value: "Alice@EXAMPLE.com"},
+			},
+		}
+
+		args, _, _ := basicSetup()
+		report, err := usecase.HandleAlert(args, alert, time.Now())
+		require.NoError(t, err)
+		require.Equal(t, 1, len(report.Alerts))
+		require.Equal(t, 2, len(report.Alerts[0].Attributes))
+		assert.Equal(t, "www.example.com", report.Alerts[0].Attributes[0].Value)
+		assert.Equal(t, "Alice@example.com", report.Alerts[0].Attributes[1].Value)
+	})
+
+	t.Run("Defanged attributes are refanged before validation", func(t *testing.T) {
+		testCases := []struct {
+			attr     deepalert.Attribute
+			expected string
+		}{
+			{deepalert.Attribute{Type: deepalert.TypeURL, Key: "url", Value: "hxxps://example[.]com/a"}, "https://example.com/a"},
+			{deepalert.Attribute{Type: deepalert.TypeDomainName, Key: "domain", Value: "example[.]com"}, "example.com"},
+			{deepalert.Attribute{Type: deepalert.TypeIPAddr, Key: "ip", Value: "1.2.3[.]4"}, "1.2.3.4"},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.attr.Value, func(t *testing.T) {
+				alert := &deepalert.Alert{
+					AlertKey:   "7",
+					RuleID:     "seven",
+					Detector:   "ao",
+					Attributes: []deepalert.Attribute{tc.attr},
+				}
+
+				args, _, _ := basicSetup()
+				report, err := usecase.HandleAlert(args, alert, time.Now())
+				require.NoError(t, err)
+				require.NotNil(t, report)
+				require.Equal(t, 1, len(report.Alerts[0].Attributes))
+				assert.Equal(t, tc.expected, report.Alerts[0].Attributes[0].Value)
+			})
+		}
+	})
+
 	t.Run("Recept single alert", func(t *testing.T) {
 		alert := &deepalert.Alert{
 			AlertKey: "5",
@@ -223,4 +272,67 @@ func TestHandleAlert(t *testing.T) {
 			assert.NotEqual(t, report1.ID, report2.ID)
 		})
 	})
+
+	t.Run("IOC extraction stage", func(t *testing.T) {
+		args, dummySFn, _ := basicSetup()
+		args.IOCExtraction = `{"allowlist":["example.com"]}`
+		alert := &deepalert.Alert{
+			Detector:    "ao",
+			RuleID:      "five",
+			Description: "Beacon to hxxp://evil[.]test.com/a from 10.1.2.3 (see https://www.example.com/doc)",
+		}
+
+		_, err := usecase.HandleAlert(args, alert, time.Now())
+		require.NoError(t, err)
+
+		sfn := dummySFn.(*mock.SFnClient)
+		var report deepalert.Report
+		require.NoError(t, json.Unmarshal([]byte(*sfn.Input[0].Input), &report))
+		require.Equal(t, 1, len(report.Alerts))
+		attrs := report.Alerts[0].FindAttributes("ioc:description")
+		require.Equal(t, 2, len(attrs))
+		assert.Equal(t, "http://evil.test.com/a", attrs[0].Value)
+		assert.Equal(t, "10.1.2.3", attrs[1].Value)
+		assert.True(t, attrs[1].Context.Have(deepalert.CtxAdditionalInfo))
+	})
+
+	t.Run("Invalid IOC extraction config", func(t *testing.T) {
+		args, _, _ := basicSetup()
+		args.IOCExtraction = `{"allowlist":`
+		_, err := usecase.HandleAlert(args, &deepalert.Alert{Detector: "ao", RuleID: "five"}, time.Now())
+		assert.Error(t, err)
+	})
+
+	t.Run("IP context tagging stage", func(t *testing.T) {
+		args, dummySFn, _ := basicSetup()
+		args.OrgCIDRs = "203.0.113.0/24"
+		alert := &deepalert.Alert{
+			Detector: "ao",
+			RuleID:   "five",
+			Attributes: []deepalert.Attribute{
+				{Type: deepalert.TypeIPAddr, Key: "src", Value: "203.0.113.5"},
+				{Type: deepalert.TypeIPAddr, Key: "dst", Value: "10.0.0.1", Context: deepalert.AttrContexts{deepalert.CtxRemote}},
+			},
+		}
+
+		_, err := usecase.HandleAlert(args, alert, time.Now())
+		require.NoError(t, err)
+
+		var report deepalert.Report
+		require.NoError(t, json.Unmarshal([]byte(*dummySFn.(*mock.SFnClient).Input[0].Input), &report))
+		attrs := report.Alerts[0].Attributes
+		assert.Equal(t, deepalert.AttrContexts{deepalert.CtxLocal, deepalert.CtxIPv4}, attrs[0].InferredContext)
+		assert.True(t, attrs[0].Match(deepalert.CtxLocal, deepalert.TypeIPAddr))
+		assert.False(t, attrs[1].Context.Have(deepalert.CtxLocal))
+		assert.Equal(t, deepalert.AttrContexts{deepalert.CtxIPv4}, attrs[1].InferredContext)
+
+		args, dummySFn, _ = basicSetup()
+		args.DisableIPContextTagging = true
+		alert.Attributes = []deepalert.Attribute{{Type: deepalert.TypeIPAddr, Key: "src", Value: "203.0.113.5"}}
+		_, err = usecase.HandleAlert(args, alert, time.Now())
+		require.NoError(t, err)
+		var untagged deepalert.Report
+		require.NoError(t, json.Unmarshal([]byte(*dummySFn.(*mock.SFnClient).Input[0].Input), &untagged))
+		assert.Nil(t, untagged.Alerts[0].Attributes[0].InferredContext)
+	})
 }
@@ -0,0 +1,298 @@
+package usecase
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/m-mizutani/golambda"
+)
+
+// DeadLetterKind shows what kind of deepalert message is in dead-letter queue.
+type DeadLetterKind string
+
+const (
+	// DeadLetterAlert means the message is deepalert.Alert (or SNS notification including it) for receptAlert
+	DeadLetterAlert DeadLetterKind = "alert"
+	// DeadLetterFinding means the message is deepalert.Finding for submitFinding
+	DeadLetterFinding DeadLetterKind = "finding"
+	// DeadLetterAttribute means the message is deepalert.ReportAttribute for feedbackAttribute
+	DeadLetterAttribute DeadLetterKind = "attribute"
+	// DeadLetterUnknown means the message can not be classified
+	DeadLetterUnknown DeadLetterKind = "unknown"
+)
+
+// Functions that consume each kind of message
+var deadLetterFunctions = map[DeadLetterKind]string{
+	DeadLetterAlert:     "receptAlert",
+	DeadLetterFinding:   "submitFinding",
+	DeadLetterAttribute: "feedbackAttribute",
+}
+
+// Message attribute names set by Lambda when an asynchronous invocation failed
+const (
+	lambdaAttrRequestID    = "RequestID"
+	lambdaAttrErrorMessage = "ErrorMessage"
+)
+
+// ErrNoRedriveTarget means destination of the dead letter can not be determined.
+var ErrNoRedriveTarget = golambda.NewError("No redrive target for dead letter")
+
+// DeadLetter is a message in dead-letter queue with classified origin and error.
+type DeadLetter struct {
+	MessageID    string         `json:"message_id"`
+	Kind         DeadLetterKind `json:"kind"`
+	Function     string         `json:"function,omitempty"`
+	Source       string         `json:"source,omitempty"`
+	Topic        string         `json:"topic,omitempty"`
+	Reason       string         `json:"reason,omitempty"`
+	ReceiveCount int            `json:"receive_count,omitempty"`
+	Body         string         `json:"body"`
+
+	receiptHandle string
+}
+
+// NewDeadLetter classifies a message received from dead-letter queue. Function and reason are
+// taken from message attributes set by HandleSQSEvent or Lambda, and kind of message is
+// determined by the message body.
+func NewDeadLetter(msg *sqs.Message) *DeadLetter {
+	letter := &DeadLetter{
+		MessageID:     aws.StringValue(msg.MessageId),
+		Body:          aws.StringValue(msg.Body),
+		receiptHandle: aws.StringValue(msg.ReceiptHandle),
+	}
+
+	attr := func(key string) string {
+		if v, ok := msg.MessageAttributes[key]; ok {
+			return aws.StringValue(v.StringValue)
+		}
+		return ""
+	}
+
+	letter.Kind, letter.Topic = classifyDeadLetterBody(letter.Body)
+	letter.Function = attr(DeadLetterAttrFunction)
+	letter.Source = attr(DeadLetterAttrSource)
+	letter.Reason = attr(DeadLetterAttrReason)
+
+	if letter.Reason == "" && attr(lambdaAttrRequestID) != "" {
+		letter.Reason = attr(lambdaAttrErrorMessage)
+	}
+	if letter.Function == "" {
+		letter.Function = deadLetterFunctions[letter.Kind]
+	}
+	if letter.Reason == "" {
+		letter.Reason = "retry limit exceeded"
+	}
+
+	if v, ok := msg.Attributes[sqs.MessageSystemAttributeNameApproximateReceiveCount]; ok {
+		if n, err := strconv.Atoi(aws.StringValue(v)); err == nil {
+			letter.ReceiveCount = n
+		}
+	}
+
+	return letter
+}
+
+func classifyDeadLetterBody(body string) (DeadLetterKind, string) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(body), &fields); err != nil {
+		return DeadLetterUnknown, ""
+	}
+
+	// Alert may be delivered via SNS topic subscribed by alertQueue
+	var snsType, topicARN, message string
+	if json.Unmarshal(fields["Type"], &snsType) == nil && snsType == "Notification" &&
+		json.Unmarshal(fields["TopicArn"], &topicARN) == nil &&
+		json.Unmarshal(fields["Message"], &message) == nil {
+		kind, _ := classifyDeadLetterBody(message)
+		return kind, topicARN
+	}
+
+	has := func(keys ...string) bool {
+		for _, key := range keys {
+			if _, ok := fields[key]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch {
+	case has("origin_attr"):
+		return DeadLetterAttribute, ""
+	case has("author", "content"):
+		return DeadLetterFinding, ""
+	case has("detector"), has("rule_id"):
+		return DeadLetterAlert, ""
+	default:
+		return DeadLetterUnknown, ""
+	}
+}
+
+// DeadLetterFilter selects dead letters. Empty fields match any dead letter.
+type DeadLetterFilter struct {
+	Kind       DeadLetterKind
+	Function   string
+	Reason     *regexp.Regexp
+	MessageIDs []string
+}
+
+// Match returns true if the dead letter satisfies all conditions of the filter.
+func (x *DeadLetterFilter) Match(letter *DeadLetter) bool {
+	if x.Kind != "" && x.Kind != letter.Kind {
+		return false
+	}
+	if x.Function != "" && !strings.Contains(letter.Function, x.Function) {
+		return false
+	}
+	if x.Reason != nil && !x.Reason.MatchString(letter.Reason) {
+		return false
+	}
+	if len(x.MessageIDs) > 0 {
+		for _, id := range x.MessageIDs {
+			if id == letter.MessageID {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+const (
+	deadLetterBatchSize         = 10
+	deadLetterVisibilityTimeout = 60
+)
+
+// FetchDeadLetters receives up to limit messages matched with filter from dead-letter queue. The
+// messages are not deleted and become visible again after deadLetterVisibilityTimeout seconds.
+func FetchDeadLetters(args *handler.Arguments, queueURL string, filter *DeadLetterFilter, limit int) ([]*DeadLetter, error) {
+	svc := args.SQSService()
+	seen := map[string]bool{}
+	var letters []*DeadLetter
+
+	for limit <= 0 || len(letters) < limit {
+		msgs, err := svc.ReceiveMessages(queueURL, deadLetterBatchSize, deadLetterVisibilityTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		found := false
+		for _, msg := range msgs {
+			letter := NewDeadLetter(msg)
+			if seen[letter.MessageID] {
+				continue
+			}
+			seen[letter.MessageID] = true
+			found = true
+
+			if filter != nil && !filter.Match(letter) {
+				continue
+			}
+			letters = append(letters, letter)
+			if limit > 0 && len(letters) >= limit {
+				break
+			}
+		}
+
+		if !found {
+			break
+		}
+	}
+
+	return letters, nil
+}
+
+// RedriveTargets has queue URLs to send dead letters of each kind. They are used when source
+// queue of the dead letter is unknown.
+type RedriveTargets struct {
+	AlertQueue     string
+	FindingQueue   string
+	AttributeQueue string
+}
+
+func (x *RedriveTargets) queueOf(kind DeadLetterKind) string {
+	switch kind {
+	case DeadLetterAlert:
+		return x.AlertQueue
+	case DeadLetterFinding:
+		return x.FindingQueue
+	case DeadLetterAttribute:
+		return x.AttributeQueue
+	default:
+		return ""
+	}
+}
+
+// sqsARNToURL converts arn:aws:sqs:{region}:{account}:{name} to queue URL
+func sqsARNToURL(arn string) string {
+	parts := strings.Split(arn, ":")
+	if len(parts) != 6 || parts[2] != "sqs" {
+		return ""
+	}
+	return "https://sqs." + parts[3] + ".amazonaws.com/" + parts[4] + "/" + parts[5]
+}
+
+// RedriveDestination returns queue URL or SNS topic ARN to send the dead letter. Source queue of
+// the message has priority, then queue of RedriveTargets by kind, then the SNS topic that
+// delivered the alert.
+func (x *DeadLetter) RedriveDestination(targets *RedriveTargets) (queueURL, topicARN string) {
+	if url := sqsARNToURL(x.Source); url != "" {
+		return url, ""
+	}
+	if url := targets.queueOf(x.Kind); url != "" {
+		return url, ""
+	}
+	if x.Topic != "" {
+		return "", x.Topic
+	}
+	return "", ""
+}
+
+// RedriveDeadLetter sends the dead letter to its destination and deletes it from dead-letter queue.
+// If dryRun is true, it only returns the destination.
+func RedriveDeadLetter(args *handler.Arguments, dlqURL string, letter *DeadLetter, targets *RedriveTargets, dryRun bool) (string, error) {
+	queueURL, topicARN := letter.RedriveDestination(targets)
+	if queueURL == "" && topicARN == "" {
+		return "", golambda.WrapError(ErrNoRedriveTarget, "Fail to determine destination").With("letter", letter)
+	}
+
+	dst := queueURL
+	if dst == "" {
+		dst = topicARN
+	}
+	if dryRun {
+		return dst, nil
+	}
+	// Letter that can not be deleted after redrive would be redriven again in every run
+	if letter.receiptHandle == "" {
+		return "", golambda.NewError("Dead letter has no receipt handle").With("letter", letter)
+	}
+
+	if queueURL != "" {
+		if err := args.SQSService().SendMessage(queueURL, letter.Body, nil); err != nil {
+			return "", err
+		}
+	} else {
+		var notification struct {
+			Message string `json:"Message"`
+		}
+		if err := json.Unmarshal([]byte(letter.Body), &notification); err != nil {
+			return "", golambda.WrapError(err, "Fail to unmarshal SNS notification").With("letter", letter)
+		}
+		if err := args.SNSService().Publish(topicARN, json.RawMessage(notification.Message)); err != nil {
+			return "", err
+		}
+	}
+
+	if err := args.SQSService().DeleteMessage(dlqURL, letter.receiptHandle); err != nil {
+		return "", golambda.WrapError(err, "Fail to delete redriven dead letter").With("letter", letter)
+	}
+
+	return dst, nil
+}
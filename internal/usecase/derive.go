@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/m-mizutani/golambda"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Derivation is a kind of attribute derived from another attribute
+type Derivation string
+
+const (
+	// DeriveURLHost derives domain name or IP address from host of URL
+	DeriveURLHost Derivation = "url_host"
+	// DeriveEmailDomain derives domain name from email address
+	DeriveEmailDomain Derivation = "email_domain"
+	// DeriveRegistrableDomain derives registrable domain (eTLD+1 by public suffix list) from domain name and
+	// host name, e.g. example.co.uk from www.example.co.uk
+	DeriveRegistrableDomain Derivation = "registrable_domain"
+)
+
+var allDerivations = []Derivation{DeriveURLHost, DeriveEmailDomain, DeriveRegistrableDomain}
+
+// Deriver produces child attributes from an attribute. Children have DerivedFrom that refers the parent and
+// contexts of the parent.
+type Deriver struct {
+	enabled map[Derivation]bool
+}
+
+// NewDeriver creates Deriver with all derivations except disabled ones.
+func NewDeriver(disabled ...Derivation) (*Deriver, error) {
+	enabled := map[Derivation]bool{}
+	for _, d := range allDerivations {
+		enabled[d] = true
+	}
+	for _, d := range disabled {
+		if _, ok := enabled[d]; !ok {
+			return nil, golambda.NewError("Unknown derivation").With("derivation", d)
+		}
+		enabled[d] = false
+	}
+
+	return &Deriver{enabled: enabled}, nil
+}
+
+// LoadDeriver creates Deriver from DisabledDerivations.
+func LoadDeriver(args *handler.Arguments) (*Deriver, error) {
+	var disabled []Derivation
+	for _, d := range strings.Split(args.DisabledDerivations, ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			disabled = append(disabled, Derivation(d))
+		}
+	}
+
+	deriver, err := NewDeriver(disabled...)
+	if err != nil {
+		return nil, golambda.WrapError(err, "Invalid DISABLED_DERIVATIONS")
+	}
+	return deriver, nil
+}
+
+// Derive returns child attributes of attr, including children of the children (e.g. registrable domain of
+// URL host). attr itself is not included.
+func (x *Deriver) Derive(attr deepalert.Attribute) []deepalert.Attribute {
+	var derived []deepalert.Attribute
+	for _, child := range x.deriveOnce(attr) {
+		derived = append(derived, child)
+		derived = append(derived, x.Derive(child)...)
+	}
+	return derived
+}
+
+func (x *Deriver) deriveOnce(attr deepalert.Attribute) []deepalert.Attribute {
+	child := func(attrType deepalert.AttrType, suffix, value string) deepalert.Attribute {
+		return deepalert.Attribute{
+			Type:      attrType,
+			Key:       attr.Key + "." + suffix,
+			Value:     value,
+			Context:   append(deepalert.AttrContexts{}, attr.Context...),
+			Timestamp: attr.Timestamp,
+			DerivedFrom: &deepalert.AttrRef{
+				Type:  attr.Type,
+				Key:   attr.Key,
+				Value: attr.Value,
+			},
+		}
+	}
+	value := deepalert.NormalizeAttrValue(attr.Type, attr.Value)
+
+	switch attr.Type {
+	case deepalert.TypeURL:
+		if !x.enabled[DeriveURLHost] {
+			return nil
+		}
+		u, err := url.Parse(value)
+		if err != nil || u.Hostname() == "" {
+			return nil
+		}
+		host := u.Hostname()
+		if net.ParseIP(host) != nil {
+			return []deepalert.Attribute{child(deepalert.TypeIPAddr, "host", host)}
+		}
+		return []deepalert.Attribute{child(deepalert.TypeDomainName, "host", host)}
+
+	case deepalert.TypeEmailAddress:
+		i := strings.LastIndexByte(value, '@')
+		if !x.enabled[DeriveEmailDomain] || i < 0 || i == len(value)-1 {
+			return nil
+		}
+		return []deepalert.Attribute{child(deepalert.TypeDomainName, "domain", value[i+1:])}
+
+	case deepalert.TypeDomainName, deepalert.TypeHostName:
+		if !x.enabled[DeriveRegistrableDomain] {
+			return nil
+		}
+		domain, err := publicsuffix.EffectiveTLDPlusOne(value)
+		if err != nil || domain == value {
+			return nil
+		}
+		return []deepalert.Attribute{child(deepalert.TypeDomainName, "registrable_domain", domain)}
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package usecase_test
+
+import (
+	"testing"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/cookpad/deepalert/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func derivedValues(attrs []deepalert.Attribute) map[string]string {
+	values := map[string]string{}
+	for _, attr := range attrs {
+		values[attr.Key] = string(attr.Type) + ":" + attr.Value
+	}
+	return values
+}
+
+func TestDeriver(t *testing.T) {
+	urlAttr := deepalert.Attribute{
+		Type:    deepalert.TypeURL,
+		Key:     "url",
+		Value:   "hxxps://WWW.Example.co.uk/login",
+		Context: deepalert.AttrContexts{deepalert.CtxRemote},
+	}
+
+	t.Run("URL host and its registrable domain", func(t *testing.T) {
+		deriver, err := usecase.NewDeriver()
+		require.NoError(t, err)
+		attrs := deriver.Derive(urlAttr)
+		assert.Equal(t, map[string]string{
+			"url.host":                    "domain:www.example.co.uk",
+			"url.host.registrable_domain": "domain:example.co.uk",
+		}, derivedValues(attrs))
+
+		require.Equal(t, 2, len(attrs))
+		require.NotNil(t, attrs[0].DerivedFrom)
+		assert.Equal(t, urlAttr.Value, attrs[0].DerivedFrom.Value)
+		assert.Equal(t, "url.host", attrs[1].DerivedFrom.Key)
+		assert.True(t, attrs[1].Context.Have(deepalert.CtxRemote))
+	})
+
+	t.Run("URL with IP address host", func(t *testing.T) {
+		deriver, err := usecase.NewDeriver()
+		require.NoError(t, err)
+		attrs := deriver.Derive(deepalert.Attribute{Type: deepalert.TypeURL, Key: "u", Value: "http://192.0.2.1:8080/"})
+		assert.Equal(t, map[string]string{"u.host": "ipaddr:192.0.2.1"}, derivedValues(attrs))
+	})
+
+	t.Run("email domain", func(t *testing.T) {
+		deriver, err := usecase.NewDeriver()
+		require.NoError(t, err)
+		attrs := deriver.Derive(deepalert.Attribute{Type: deepalert.TypeEmailAddress, Key: "from", Value: "alice@mail.example.com"})
+		assert.Equal(t, map[string]string{
+			"from.domain":                    "domain:mail.example.com",
+			"from.domain.registrable_domain": "domain:example.com",
+		}, derivedValues(attrs))
+	})
+
+	t.Run("no child of registrable domain itself", func(t *testing.T) {
+		deriver, err := usecase.NewDeriver()
+		require.NoError(t, err)
+		assert.Equal(t, 0, len(deriver.Derive(deepalert.Attribute{Type: deepalert.TypeDomainName, Key: "d", Value: "example.com"})))
+		assert.Equal(t, 0, len(deriver.Derive(deepalert.Attribute{Type: deepalert.TypeHostName, Key: "h", Value: "localhost"})))
+		assert.Equal(t, 0, len(deriver.Derive(deepalert.Attribute{Type: deepalert.TypeIPAddr, Key: "ip", Value: "192.0.2.1"})))
+	})
+
+	t.Run("disabled derivation", func(t *testing.T) {
+		deriver, err := usecase.LoadDeriver(&handler.Arguments{EnvVars: handler.EnvVars{DisabledDerivations: "registrable_domain"}})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"url.host": "domain:www.example.co.uk"}, derivedValues(deriver.Derive(urlAttr)))
+
+		deriver, err = usecase.NewDeriver(usecase.DeriveURLHost)
+		require.NoError(t, err)
+		assert.Equal(t, 0, len(deriver.Derive(urlAttr)))
+
+		_, err = usecase.LoadDeriver(&handler.Arguments{EnvVars: handler.EnvVars{DisabledDerivations: "url_host,unknown"}})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/alert"
+	"github.com/cookpad/deepalert/alert/ioc"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/m-mizutani/golambda"
+)
+
+// LoadIOCExtractor creates ioc.Extractor from IOCExtraction. It returns nil if IOCExtraction is not configured.
+func LoadIOCExtractor(args *handler.Arguments) (*ioc.Extractor, error) {
+	if args.IOCExtraction == "" {
+		return nil, nil
+	}
+
+	extractor, err := ioc.LoadConfig([]byte(args.IOCExtraction))
+	if err != nil {
+		return nil, golambda.WrapError(err, "Invalid IOC_EXTRACTION")
+	}
+	return extractor, nil
+}
+
+// LoadIPContextTagger creates alert.IPContextTagger with OrgCIDRs. It returns nil if DisableIPContextTagging
+// is set.
+func LoadIPContextTagger(args *handler.Arguments) (*alert.IPContextTagger, error) {
+	if args.DisableIPContextTagging {
+		return nil, nil
+	}
+
+	var cidrs []string
+	for _, cidr := range strings.Split(args.OrgCIDRs, ",") {
+		if cidr = strings.TrimSpace(cidr); cidr != "" {
+			cidrs = append(cidrs, cidr)
+		}
+	}
+
+	tagger, err := alert.NewIPContextTagger(cidrs...)
+	if err != nil {
+		return nil, golambda.WrapError(err, "Invalid ORG_CIDRS")
+	}
+	return tagger, nil
+}
+
+// Enricher runs optional enrichment stages of ingestion, IOC extraction and IP context tagging.
+type Enricher struct {
+	extractor *ioc.Extractor
+	tagger    *alert.IPContextTagger
+}
+
+type enricherConfig struct {
+	iocExtraction  string
+	orgCIDRs       string
+	disableTagging bool
+}
+
+// enrichers caches Enricher by configuration because parsing configuration for each alert is expensive and
+// the runtime is reused over invocations.
+var enrichers sync.Map
+
+// LoadEnricher returns Enricher configured by IOCExtraction, OrgCIDRs and DisableIPContextTagging. It's built
+// once for the configuration. An entry point should call it before handling messages so that invalid
+// configuration fails the invocation instead of each message being retried until dead-letter queue.
+func LoadEnricher(args *handler.Arguments) (*Enricher, error) {
+	cfg := enricherConfig{
+		iocExtraction:  args.IOCExtraction,
+		orgCIDRs:       args.OrgCIDRs,
+		disableTagging: args.DisableIPContextTagging,
+	}
+	if cached, ok := enrichers.Load(cfg); ok {
+		return cached.(*Enricher), nil
+	}
+
+	extractor, err := LoadIOCExtractor(args)
+	if err != nil {
+		return nil, err
+	}
+	tagger, err := LoadIPContextTagger(args)
+	if err != nil {
+		return nil, err
+	}
+
+	enricher := &Enricher{extractor: extractor, tagger: tagger}
+	enrichers.Store(cfg, enricher)
+	return enricher, nil
+}
+
+// Enrich runs enrichment stages for a validated alert.
+func (x *Enricher) Enrich(src *deepalert.Alert) {
+	if x.extractor != nil {
+		if n := x.extractor.Enrich(src); n > 0 {
+			logger.With("alert_id", src.AlertID()).With("count", n).Debug("Extracted IOC attributes")
+		}
+	}
+
+	// Tagging runs after IOC extraction to tag extracted IP addresses
+	if x.tagger != nil {
+		x.tagger.TagAlert(src)
+	}
+}
@@ -0,0 +1,40 @@
+package usecase_test
+
+import (
+	"testing"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/cookpad/deepalert/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadEnricher(t *testing.T) {
+	t.Run("enricher is built once for the configuration", func(t *testing.T) {
+		args := &handler.Arguments{EnvVars: handler.EnvVars{
+			IOCExtraction: `{"allowlist":["example.com"]}`,
+			OrgCIDRs:      "10.0.0.0/8",
+		}}
+		e1, err := usecase.LoadEnricher(args)
+		require.NoError(t, err)
+		e2, err := usecase.LoadEnricher(args)
+		require.NoError(t, err)
+		assert.Same(t, e1, e2)
+
+		other, err := usecase.LoadEnricher(&handler.Arguments{EnvVars: handler.EnvVars{OrgCIDRs: "192.168.0.0/16"}})
+		require.NoError(t, err)
+		assert.NotSame(t, e1, other)
+
+		alert := &deepalert.Alert{Detector: "blue", RuleID: "r1", Description: "Access to 198.51.100.1"}
+		e1.Enrich(alert)
+		assert.Equal(t, 1, len(alert.FindAttributes("ioc:description")))
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		_, err := usecase.LoadEnricher(&handler.Arguments{EnvVars: handler.EnvVars{IOCExtraction: `{"unknown":1}`}})
+		assert.Error(t, err)
+		_, err = usecase.LoadEnricher(&handler.Arguments{EnvVars: handler.EnvVars{OrgCIDRs: "10.0.0.0/33"}})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/alert"
+	"github.com/cookpad/deepalert/alert/syslog"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/m-mizutani/golambda"
+)
+
+// LineParser converts a raw record to alerts.
+type LineParser func(line string) ([]*deepalert.Alert, error)
+
+// ParseSyslogLine is LineParser for a syslog line with CEF or LEEF record.
+func ParseSyslogLine(line string) ([]*deepalert.Alert, error) {
+	a, err := syslog.ParseAlert(line)
+	if err != nil {
+		return nil, golambda.WrapError(ErrMalformedMessage, "Fail to parse syslog line:", err)
+	}
+	return []*deepalert.Alert{a}, nil
+}
+
+// NewLineParser returns LineParser that converts a JSON record by mapper (see alert.Mapper.Decode)
+// and other record as syslog line. mapper can be nil.
+func NewLineParser(mapper *alert.Mapper) LineParser {
+	return func(line string) ([]*deepalert.Alert, error) {
+		if !strings.HasPrefix(strings.TrimSpace(line), "{") {
+			return ParseSyslogLine(line)
+		}
+
+		alerts, mapping, err := mapper.Decode([]byte(line))
+		if err != nil {
+			return nil, golambda.WrapError(ErrMalformedMessage, "Fail to decode alert:", err).With("mapping", mapping)
+		}
+		return alerts, nil
+	}
+}
+
+// LoadAlertMapper creates alert.Mapper from AlertMappings. It returns nil if AlertMappings is not configured.
+func LoadAlertMapper(args *handler.Arguments) (*alert.Mapper, error) {
+	if args.AlertMappings == "" {
+		return nil, nil
+	}
+
+	mapper, err := alert.LoadMapper([]byte(args.AlertMappings))
+	if err != nil {
+		return nil, golambda.WrapError(err, "Invalid ALERT_MAPPINGS")
+	}
+	return mapper, nil
+}
+
+// maxLineSize is limit of one line. It's larger than max size of alert because a raw record has other fields.
+const maxLineSize = 1024 * 1024
+
+// IngestError is failure of one record that will never succeed by retry.
+type IngestError struct {
+	Record string `json:"record"`
+	Err    string `json:"error"`
+}
+
+// IngestResult is summary of ingestion.
+type IngestResult struct {
+	Accepted   int            `json:"accepted"`
+	Skipped    int            `json:"skipped"`
+	Duplicated int            `json:"duplicated"`
+	Failed     []*IngestError `json:"failed,omitempty"`
+	ReportIDs  []string       `json:"report_ids,omitempty"`
+}
+
+// Ingester handles raw records from a stream or a file with the same validation, idempotency and error reporting.
+type Ingester struct {
+	args   *handler.Arguments
+	parse  LineParser
+	now    time.Time
+	seen   map[deepalert.ReportID]bool
+	Result IngestResult
+}
+
+// NewIngester is constructor of Ingester
+func NewIngester(args *handler.Arguments, parse LineParser, now time.Time) *Ingester {
+	return &Ingester{
+		args:  args,
+		parse: parse,
+		now:   now,
+		seen:  map[deepalert.ReportID]bool{},
+	}
+}
+
+// Ingest parses a record and handles the alerts by HandleAlerts. recordKey must be unique for the record
+// (e.g. Kinesis event ID) and a record that has been ingested with the key is skipped. A record that can not
+// be parsed or has invalid alert is moved to DeadLetterQueue and recorded in Result.Failed. Ingest returns
+// error only if the record should be retried.
+func (x *Ingester) Ingest(recordKey, source, data string) error {
+	if strings.TrimSpace(data) == "" {
+		x.Result.Skipped++
+		return nil
+	}
+
+	err := x.ingest(recordKey, data)
+	if err == nil || !IsPermanentError(err) {
+		return err
+	}
+
+	logger.With("record", recordKey).With("err", err).Warn("Fail to ingest record")
+	x.Result.Failed = append(x.Result.Failed, &IngestError{Record: recordKey, Err: err.Error()})
+
+	if x.args.DeadLetterQueue == "" {
+		golambda.EmitError(err)
+		return nil
+	}
+	return putDeadLetter(x.args, data, source, err)
+}
+
+func (x *Ingester) ingest(recordKey, data string) error {
+	repo, err := x.args.Repository()
+	if err != nil {
+		return err
+	}
+
+	if reportID, err := repo.GetIngestedReportID(recordKey, x.now); err != nil {
+		return err
+	} else if reportID != deepalert.NullReportID {
+		logger.With("record", recordKey).With("reportID", reportID).Debug("Skip ingested record")
+		x.Result.Duplicated++
+		return nil
+	}
+
+	alerts, err := x.parse(data)
+	if err != nil {
+		return err
+	}
+
+	// Alerts of a record are recorded one by one, so alerts handled before failure are not handled again by retry
+	reportIDs, err := HandleAlerts(x.args, recordKey, alerts, x.now)
+	if err != nil {
+		return golambda.WrapError(err, "Fail to handle alerts").With("record", recordKey)
+	}
+
+	reportID := deepalert.NullReportID
+	for _, id := range reportIDs {
+		if !x.seen[id] {
+			x.seen[id] = true
+			x.Result.ReportIDs = append(x.Result.ReportIDs, string(id))
+		}
+		if reportID == deepalert.NullReportID {
+			reportID = id
+		}
+	}
+
+	if err := repo.PutIngestedRecord(recordKey, reportID, x.now); err != nil {
+		return err
+	}
+	x.Result.Accepted++
+
+	return nil
+}
+
+// IngestLines ingests each line of src. Key of a line is "{keyPrefix}#{line number}" and keyPrefix should
+// identify the source (e.g. S3 bucket, key and version) for idempotency.
+func IngestLines(args *handler.Arguments, src io.Reader, keyPrefix string, parse LineParser, now time.Time) (*IngestResult, error) {
+	ingester := NewIngester(args, parse, now)
+
+	scanner := bufio.NewScanner(src)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		key := fmt.Sprintf("%s#%d", keyPrefix, lineNo)
+		if err := ingester.Ingest(key, key, scanner.Text()); err != nil {
+			return &ingester.Result, err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return &ingester.Result, golambda.WrapError(ErrMalformedMessage, "Fail to read lines:", err).With("source", keyPrefix)
+	}
+
+	return &ingester.Result, nil
+}
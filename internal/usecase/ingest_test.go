@@ -0,0 +1,164 @@
+package usecase_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/internal/adaptor"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/cookpad/deepalert/internal/mock"
+	"github.com/cookpad/deepalert/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingSFnClient fails StartExecution after limit executions
+type failingSFnClient struct {
+	limit int
+	Input []*sfn.StartExecutionInput
+}
+
+func (x *failingSFnClient) StartExecution(input *sfn.StartExecutionInput) (*sfn.StartExecutionOutput, error) {
+	if len(x.Input) >= x.limit {
+		return nil, errors.New("temporary failure")
+	}
+	x.Input = append(x.Input, input)
+	return &sfn.StartExecutionOutput{}, nil
+}
+
+func TestIngestLines(t *testing.T) {
+	dlqURL := "https://sqs.us-east-1.amazonaws.com/111122223333/dlq"
+
+	setup := func(sfnErr error) (*handler.Arguments, *mock.SFnClient, *mock.SQSClient) {
+		dummySFn, _ := mock.NewSFnClient("")
+		dummyRepo := mock.NewRepository("", "")
+		sqsClient, newSQS := mock.NewMockSQSClientSet()
+		args := &handler.Arguments{
+			NewRepository: func(string, string) adaptor.Repository { return dummyRepo },
+			NewSFn: func(string) (adaptor.SFnClient, error) {
+				if sfnErr != nil {
+					return nil, sfnErr
+				}
+				return dummySFn, nil
+			},
+			NewSQS: newSQS,
+			EnvVars: handler.EnvVars{
+				InspectorMachine: "arn:aws:states:us-east-1:111122223333:stateMachine:blue",
+				ReviewMachine:    "arn:aws:states:us-east-1:111122223333:stateMachine:orange",
+				DeadLetterQueue:  dlqURL,
+			},
+		}
+		return args, dummySFn.(*mock.SFnClient), sqsClient
+	}
+
+	lines := strings.Join([]string{
+		`<134>Jan 02 03:04:05 ids01 CEF:0|Security|IDS|1.0|100|port scan|5|src=192.0.2.1 dst=10.0.0.1`,
+		``,
+		`<134>Jan 02 03:04:05 ids01 sshd[123]: not a CEF record`,
+		`<134>Jan 02 03:04:05 ids01 CEF:0|Security|IDS|1.0|100|port scan|5|src=192.0.2.1 dst=10.0.0.2`,
+		`<134>Jan 02 03:04:05 ids01 CEF:0|Security|IDS|1.0||no signature|5|src=192.0.2.1`,
+		`{"detector":"blue","rule_id":"r1","alert_key":"k1"}`,
+		`{"detector":"blue"}`,
+	}, "\n")
+
+	t.Run("lines are ingested and failures are moved to dead-letter queue", func(t *testing.T) {
+		args, sfn, sqsClient := setup(nil)
+		parse := usecase.NewLineParser(nil)
+		result, err := usecase.IngestLines(args, strings.NewReader(lines), "s3://bucket/a.log", parse, time.Now())
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, result.Accepted)
+		assert.Equal(t, 1, result.Skipped)
+		require.Equal(t, 3, len(result.Failed))
+		assert.Equal(t, "s3://bucket/a.log#3", result.Failed[0].Record)
+		assert.Equal(t, "s3://bucket/a.log#5", result.Failed[1].Record)
+		assert.Equal(t, "s3://bucket/a.log#7", result.Failed[2].Record)
+
+		// Alerts from the same source with the same signature are aggregated into one report
+		assert.Equal(t, 2, len(result.ReportIDs))
+		assert.Equal(t, 5, len(sfn.Input)) // 2 reviews for new reports and 3 inspections
+
+		require.Equal(t, 3, len(sqsClient.Input))
+		assert.Equal(t, dlqURL, aws.StringValue(sqsClient.Input[0].QueueUrl))
+		assert.Contains(t, aws.StringValue(sqsClient.Input[0].MessageBody), "not a CEF record")
+		assert.Equal(t, "s3://bucket/a.log#3",
+			aws.StringValue(sqsClient.Input[0].MessageAttributes[usecase.DeadLetterAttrSource].StringValue))
+
+		t.Run("ingested lines are skipped", func(t *testing.T) {
+			result, err := usecase.IngestLines(args, strings.NewReader(lines), "s3://bucket/a.log", parse, time.Now())
+			require.NoError(t, err)
+			assert.Equal(t, 0, result.Accepted)
+			assert.Equal(t, 3, result.Duplicated)
+			assert.Equal(t, 5, len(sfn.Input))
+		})
+
+		t.Run("lines with other key are ingested", func(t *testing.T) {
+			result, err := usecase.IngestLines(args, strings.NewReader(lines), "s3://bucket/b.log", parse, time.Now())
+			require.NoError(t, err)
+			assert.Equal(t, 3, result.Accepted)
+		})
+	})
+
+	t.Run("temporary error stops ingestion", func(t *testing.T) {
+		args, _, sqsClient := setup(errors.New("sfn is unavailable"))
+		result, err := usecase.IngestLines(args, strings.NewReader(lines), "s3://bucket/a.log", usecase.ParseSyslogLine, time.Now())
+		require.Error(t, err)
+		assert.False(t, usecase.IsPermanentError(err))
+		assert.Equal(t, 0, result.Accepted)
+		assert.Equal(t, 0, len(sqsClient.Input))
+	})
+
+	t.Run("permanent error is retryable if dead-letter queue is unavailable", func(t *testing.T) {
+		args, _, _ := setup(nil)
+		args.DeadLetterQueue = "invalid-url"
+		_, err := usecase.IngestLines(args, strings.NewReader("not a record"), "src", usecase.ParseSyslogLine, time.Now())
+		require.Error(t, err)
+	})
+
+	t.Run("custom parser", func(t *testing.T) {
+		args, _, _ := setup(nil)
+		parse := func(line string) ([]*deepalert.Alert, error) {
+			return []*deepalert.Alert{
+				{Detector: "custom", RuleID: line},
+				{Detector: "custom", RuleID: line + "-2"},
+			}, nil
+		}
+		result, err := usecase.IngestLines(args, strings.NewReader("a\nb\n"), "src", parse, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Accepted)
+		assert.Equal(t, 4, len(result.ReportIDs))
+	})
+
+	t.Run("retry of record handles only alerts that failed", func(t *testing.T) {
+		args, _, _ := setup(nil)
+		// The first alert starts inspection and review of new report, and the second one fails
+		client := &failingSFnClient{limit: 2}
+		args.NewSFn = func(string) (adaptor.SFnClient, error) { return client, nil }
+		parse := func(line string) ([]*deepalert.Alert, error) {
+			return []*deepalert.Alert{
+				{Detector: "custom", RuleID: "first"},
+				{Detector: "custom", RuleID: "second"},
+			}, nil
+		}
+
+		_, err := usecase.IngestLines(args, strings.NewReader("a"), "src", parse, time.Now())
+		require.Error(t, err)
+		require.Equal(t, 2, len(client.Input))
+
+		client.limit = 10
+		result, err := usecase.IngestLines(args, strings.NewReader("a"), "src", parse, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Accepted)
+		assert.Equal(t, 2, len(result.ReportIDs))
+
+		// Only the second alert is handled again
+		require.Equal(t, 3, len(client.Input))
+		assert.Contains(t, aws.StringValue(client.Input[2].Input), `"rule_id":"second"`)
+		assert.NotContains(t, aws.StringValue(client.Input[2].Input), `"rule_id":"first"`)
+	})
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/m-mizutani/golambda"
+)
+
+// ErrMalformedMessage means the message can not be parsed and will never be processed by retry.
+var ErrMalformedMessage = golambda.NewError("Malformed message")
+
+// Message attribute names that are set to a message moved to dead-letter queue.
+const (
+	DeadLetterAttrFunction = "DeepAlertFunction"
+	DeadLetterAttrSource   = "DeepAlertSource"
+	DeadLetterAttrReason   = "DeepAlertReason"
+)
+
+// MessageHandler is callback to process body of one SQS message.
+type MessageHandler func(body []byte) error
+
+// IsPermanentError returns true if the error is caused by content of the message itself.
+// Such message will fail again even if retried.
+func IsPermanentError(err error) bool {
+	return errors.Is(err, ErrMalformedMessage) || errors.Is(err, deepalert.ErrInvalidAlert)
+}
+
+// HandleSQSEvent calls handle for each SQS message in the event. A message failed with permanent error is
+// moved to DeadLetterQueue with the reason, and a message failed with other error is reported in
+// BatchItemFailures to be retried.
+func HandleSQSEvent(args *handler.Arguments, event golambda.Event, handle MessageHandler) (*events.SQSEventResponse, error) {
+	var sqsEvent events.SQSEvent
+	if err := event.Bind(&sqsEvent); err != nil {
+		return nil, err
+	}
+	if len(sqsEvent.Records) == 0 {
+		return nil, golambda.NewError("No SQS event records")
+	}
+
+	var resp events.SQSEventResponse
+	for _, msg := range sqsEvent.Records {
+		err := handle([]byte(msg.Body))
+		if err == nil {
+			continue
+		}
+
+		if IsPermanentError(err) {
+			dlqErr := sendDeadLetter(args, msg, err)
+			if dlqErr == nil {
+				logger.With("err", err).With("messageID", msg.MessageId).Warn("Moved message to dead-letter queue")
+				continue
+			}
+			golambda.EmitError(dlqErr)
+		}
+
+		golambda.EmitError(err)
+		resp.BatchItemFailures = append(resp.BatchItemFailures, events.SQSBatchItemFailure{
+			ItemIdentifier: msg.MessageId,
+		})
+	}
+
+	return &resp, nil
+}
+
+func sendDeadLetter(args *handler.Arguments, msg events.SQSMessage, cause error) error {
+	if err := putDeadLetter(args, msg.Body, msg.EventSourceARN, cause); err != nil {
+		return golambda.WrapError(err, "Fail to move SQS message").With("messageID", msg.MessageId)
+	}
+	return nil
+}
+
+// putDeadLetter sends body to DeadLetterQueue with source and reason of failure as message attributes.
+func putDeadLetter(args *handler.Arguments, body, source string, cause error) error {
+	if args.DeadLetterQueue == "" {
+		return golambda.NewError("DeadLetterQueue is not configured").With("source", source)
+	}
+
+	attrs := map[string]string{
+		DeadLetterAttrFunction: args.FunctionName,
+		DeadLetterAttrSource:   source,
+		DeadLetterAttrReason:   cause.Error(),
+	}
+
+	if err := args.SQSService().SendMessage(args.DeadLetterQueue, body, attrs); err != nil {
+		return golambda.WrapError(err, "Fail to send message to dead-letter queue").With("source", source)
+	}
+
+	return nil
+}
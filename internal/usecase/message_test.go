@@ -0,0 +1,75 @@
+package usecase_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/cookpad/deepalert/internal/mock"
+	"github.com/cookpad/deepalert/internal/usecase"
+	"github.com/m-mizutani/golambda"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleSQSEvent(t *testing.T) {
+	dlqURL := "https://sqs.ap-northeast-1.amazonaws.com/111122223333/dlq"
+	event := golambda.Event{
+		Origin: events.SQSEvent{
+			Records: []events.SQSMessage{
+				{MessageId: "m1", Body: "ok", EventSourceARN: "arn:aws:sqs:ap-northeast-1:111122223333:alert"},
+				{MessageId: "m2", Body: "malformed", EventSourceARN: "arn:aws:sqs:ap-northeast-1:111122223333:alert"},
+				{MessageId: "m3", Body: "invalid", EventSourceARN: "arn:aws:sqs:ap-northeast-1:111122223333:alert"},
+				{MessageId: "m4", Body: "retry", EventSourceARN: "arn:aws:sqs:ap-northeast-1:111122223333:alert"},
+			},
+		},
+	}
+	handle := func(body []byte) error {
+		switch string(body) {
+		case "malformed":
+			return golambda.WrapError(usecase.ErrMalformedMessage, "broken")
+		case "invalid":
+			return golambda.WrapError(deepalert.ErrInvalidAlert, "no detector")
+		case "retry":
+			return errors.New("temporary")
+		}
+		return nil
+	}
+
+	t.Run("Permanent errors go to dead-letter queue and others are retried", func(t *testing.T) {
+		dummySQS, newSQS := mock.NewMockSQSClientSet()
+		args := &handler.Arguments{
+			NewSQS: newSQS,
+			EnvVars: handler.EnvVars{
+				DeadLetterQueue: dlqURL,
+				FunctionName:    "tester",
+			},
+		}
+
+		resp, err := usecase.HandleSQSEvent(args, event, handle)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(resp.BatchItemFailures))
+		assert.Equal(t, "m4", resp.BatchItemFailures[0].ItemIdentifier)
+
+		assert.Equal(t, "ap-northeast-1", dummySQS.Region)
+		require.Equal(t, 2, len(dummySQS.Input))
+		assert.Equal(t, "malformed", *dummySQS.Input[0].MessageBody)
+		assert.Equal(t, "invalid", *dummySQS.Input[1].MessageBody)
+		attrs := dummySQS.Input[0].MessageAttributes
+		assert.Equal(t, "tester", *attrs[usecase.DeadLetterAttrFunction].StringValue)
+		assert.Equal(t, "arn:aws:sqs:ap-northeast-1:111122223333:alert", *attrs[usecase.DeadLetterAttrSource].StringValue)
+		assert.Contains(t, *attrs[usecase.DeadLetterAttrReason].StringValue, "broken")
+	})
+
+	t.Run("Permanent errors are retried if dead-letter queue is not configured", func(t *testing.T) {
+		dummySQS, newSQS := mock.NewMockSQSClientSet()
+		args := &handler.Arguments{NewSQS: newSQS}
+
+		resp, err := usecase.HandleSQSEvent(args, event, handle)
+		require.NoError(t, err)
+		require.Equal(t, 3, len(resp.BatchItemFailures))
+		assert.Equal(t, 0, len(dummySQS.Input))
+	})
+}
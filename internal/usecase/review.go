@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/m-mizutani/golambda"
+)
+
+// LoadReviewPolicy returns ReviewPolicy of ReviewPolicy env var. Default is deepalert.PolicyMaxSeverity.
+func LoadReviewPolicy(args *handler.Arguments) (deepalert.ReviewPolicy, error) {
+	policy, err := deepalert.ParseReviewPolicy(args.ReviewPolicy)
+	if err != nil {
+		return "", golambda.WrapError(err, "Invalid REVIEW_POLICY")
+	}
+	return policy, nil
+}
+
+// FinalizeResult sets final Result of the report before submitting. Results of multiple reviewers are combined
+// by policy. Default risk score and level are set only if the reviewer returned neither severity nor level, so
+// that severity of the result is always consistent with its level.
+func FinalizeResult(report *deepalert.Report, policy deepalert.ReviewPolicy) {
+	if len(report.Reviews) > 0 {
+		report.Result = deepalert.CombineReviews(policy, report.Reviews)
+	}
+
+	result := &report.Result
+	if result.Severity == "" && result.Level == "" && report.Risk != nil {
+		result.Score, result.Level = report.Risk.Score, report.Risk.Level
+	}
+	if result.Severity == "" && result.Level != "" {
+		result.Severity = result.Level.Severity()
+	}
+	if result.Severity == "" {
+		result.Severity = deepalert.SevUnclassified
+	}
+}
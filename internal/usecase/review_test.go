@@ -0,0 +1,69 @@
+package usecase_test
+
+import (
+	"testing"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/cookpad/deepalert/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadReviewPolicy(t *testing.T) {
+	policy, err := usecase.LoadReviewPolicy(&handler.Arguments{})
+	require.NoError(t, err)
+	assert.Equal(t, deepalert.PolicyMaxSeverity, policy)
+
+	_, err = usecase.LoadReviewPolicy(&handler.Arguments{EnvVars: handler.EnvVars{ReviewPolicy: "random"}})
+	assert.Error(t, err)
+}
+
+func TestFinalizeResult(t *testing.T) {
+	t.Run("combine reviews", func(t *testing.T) {
+		report := &deepalert.Report{
+			Reviews: []*deepalert.ReviewResult{
+				{Reviewer: "cloud", Result: &deepalert.ReportResult{Severity: deepalert.SevSafe}},
+				{Reviewer: "endpoint", Result: &deepalert.ReportResult{Severity: deepalert.SevUrgent}},
+			},
+		}
+		usecase.FinalizeResult(report, deepalert.PolicyFirstDecisive)
+		assert.Equal(t, deepalert.SevSafe, report.Result.Severity)
+		assert.Equal(t, 2, len(report.Reviews))
+	})
+
+	t.Run("default risk is set if reviewer returns neither severity nor level", func(t *testing.T) {
+		report := &deepalert.Report{
+			Risk: deepalert.NewRiskAssessment(&deepalert.RiskFactor{Name: "x", Score: 85}),
+		}
+		usecase.FinalizeResult(report, deepalert.PolicyMaxSeverity)
+		assert.Equal(t, deepalert.SevUrgent, report.Result.Severity)
+		assert.Equal(t, deepalert.RiskCritical, report.Result.Level)
+		assert.Equal(t, 85, report.Result.Score)
+	})
+
+	t.Run("default risk is not set if reviewer returns severity", func(t *testing.T) {
+		report := &deepalert.Report{
+			Result: deepalert.ReportResult{Severity: deepalert.SevSafe},
+			Risk:   deepalert.NewRiskAssessment(&deepalert.RiskFactor{Name: "x", Score: 85}),
+		}
+		usecase.FinalizeResult(report, deepalert.PolicyMaxSeverity)
+		assert.Equal(t, deepalert.SevSafe, report.Result.Severity)
+		assert.Equal(t, deepalert.RiskLevel(""), report.Result.Level)
+		assert.Equal(t, 0, report.Result.Score)
+		// Computed risk is still available in Report.Risk
+		assert.Equal(t, deepalert.RiskCritical, report.Risk.Level)
+	})
+
+	t.Run("severity is mapped from level", func(t *testing.T) {
+		report := &deepalert.Report{Result: deepalert.ReportResult{Level: deepalert.RiskCritical}}
+		usecase.FinalizeResult(report, deepalert.PolicyMaxSeverity)
+		assert.Equal(t, deepalert.SevUrgent, report.Result.Severity)
+	})
+
+	t.Run("unclassified by default", func(t *testing.T) {
+		report := &deepalert.Report{}
+		usecase.FinalizeResult(report, deepalert.PolicyMaxSeverity)
+		assert.Equal(t, deepalert.SevUnclassified, report.Result.Severity)
+	})
+}
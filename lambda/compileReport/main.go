@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/cookpad/deepalert"
 	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/cookpad/deepalert/risk"
 	"github.com/m-mizutani/golambda"
 )
 
@@ -32,6 +33,9 @@ func handleRequest(args *handler.Arguments, event golambda.Event) (interface{},
 	if err != nil {
 		return nil, err
 	}
+	if compiledReport != nil {
+		compiledReport.Risk = risk.NewEngine().Assess(compiledReport)
+	}
 	golambda.Logger.With("report", compiledReport).Info("Compiled report")
 
 	return compiledReport, nil
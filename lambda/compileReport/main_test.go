@@ -61,6 +61,11 @@ func TestHandleReport(t *testing.T) {
 	updatedReport := resp.(*deepalert.Report)
 	require.NotNil(t, updatedReport)
 	assert.Equal(t, len(updatedReport.Sections), 1)
+	require.NotNil(t, updatedReport.Sections[0].Summary)
+	assert.Equal(t, []string{"h1"}, updatedReport.Sections[0].Summary.HostNames.Strings())
+	assert.Equal(t, []string{"tester"}, updatedReport.Sections[0].Summary.HostNames.Values[0].Authors)
 	assert.Equal(t, len(updatedReport.Alerts), 1)
+	require.NotNil(t, updatedReport.Risk)
+	assert.Equal(t, deepalert.RiskInfo, updatedReport.Risk.Level)
 	assert.Equal(t, len(updatedReport.Attributes), 1)
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/cookpad/deepalert"
 	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/cookpad/deepalert/internal/usecase"
 	"github.com/m-mizutani/golambda"
 )
 
@@ -30,9 +31,20 @@ func handleRequest(args *handler.Arguments, event golambda.Event) (interface{},
 	if err != nil {
 		return nil, err
 	}
+	deriver, err := usecase.LoadDeriver(args)
+	if err != nil {
+		return nil, err
+	}
+
 	snsSvc := args.SNSService()
 	for _, alert := range report.Alerts {
+		var attrs []deepalert.Attribute
 		for _, attr := range alert.Attributes {
+			attrs = append(attrs, attr)
+			attrs = append(attrs, deriver.Derive(attr)...)
+		}
+
+		for _, attr := range attrs {
 			sendable, err := repo.PutAttributeCache(report.ID, attr, now)
 			if err != nil {
 				return nil, golambda.WrapError(err, "Fail to manage attribute cache").With("attr", attr)
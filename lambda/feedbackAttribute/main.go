@@ -8,6 +8,7 @@ import (
 
 	"github.com/cookpad/deepalert"
 	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/cookpad/deepalert/internal/usecase"
 )
 
 var logger = golambda.Logger
@@ -32,23 +33,19 @@ func handleRequest(args *handler.Arguments, event golambda.Event) (interface{},
 
 	now := time.Now()
 
-	sqsMessages, err := event.DecapSQSBody()
-	if err != nil {
-		return nil, err
-	}
-
-	for _, msg := range sqsMessages {
+	return usecase.HandleSQSEvent(args, event, func(body []byte) error {
 		var reportedAttr deepalert.ReportAttribute
-		if err := json.Unmarshal(msg, &reportedAttr); err != nil {
-			return nil, golambda.WrapError(err, "Unmarshal ReportAttribute").With("msg", string(msg))
+		if err := json.Unmarshal(body, &reportedAttr); err != nil {
+			return golambda.WrapError(usecase.ErrMalformedMessage, "Unmarshal ReportAttribute:", err).With("msg", string(body))
 		}
 
 		logger.With("reportedAttr", reportedAttr).Info("unmarshaled reported attribute")
 
-		for _, attr := range reportedAttr.Attributes {
-			sendable, err := repo.PutAttributeCache(reportedAttr.ReportID, *attr, now)
+		for _, reported := range reportedAttr.Attributes {
+			attr := reported.Normalize()
+			sendable, err := repo.PutAttributeCache(reportedAttr.ReportID, attr, now)
 			if err != nil {
-				return nil, golambda.WrapError(err, "Fail to manage attribute cache").With("attr", attr)
+				return golambda.WrapError(err, "Fail to manage attribute cache").With("attr", attr)
 			}
 
 			logger.With("sendable", sendable).With("attr", attr).Info("attribute")
@@ -58,14 +55,14 @@ func handleRequest(args *handler.Arguments, event golambda.Event) (interface{},
 
 			task := deepalert.Task{
 				ReportID:  reportedAttr.ReportID,
-				Attribute: attr,
+				Attribute: &attr,
 			}
 
 			if err := snsSvc.Publish(args.TaskTopic, &task); err != nil {
-				return nil, err
+				return err
 			}
 		}
-	}
 
-	return nil, nil
+		return nil
+	})
 }
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"github.com/cookpad/deepalert/internal/api"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/m-mizutani/golambda"
+)
+
+func main() {
+	golambda.Start(func(event golambda.Event) (interface{}, error) {
+		args := handler.NewArguments()
+		if err := args.BindEnvVars(); err != nil {
+			return nil, err
+		}
+
+		return HandleRequest(args, event)
+	})
+}
+
+// HandleRequest serves HTTP ingestion API via API Gateway or Lambda function URL.
+func HandleRequest(args *handler.Arguments, event golambda.Event) (interface{}, error) {
+	return api.HandleGatewayEvent(api.NewHandler(args), event)
+}
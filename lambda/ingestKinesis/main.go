@@ -0,0 +1,58 @@
+package main
+
+import (
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/cookpad/deepalert/internal/usecase"
+	"github.com/m-mizutani/golambda"
+)
+
+var logger = golambda.Logger
+
+func main() {
+	golambda.Start(func(event golambda.Event) (interface{}, error) {
+		args := handler.NewArguments()
+		if err := args.BindEnvVars(); err != nil {
+			return nil, err
+		}
+
+		return HandleRequest(args, event)
+	})
+}
+
+// HandleRequest ingests alerts in records of Kinesis Data Streams. A record is JSON (alert, finding or data
+// converted by ALERT_MAPPINGS) or syslog line with CEF or LEEF record. Processing stops at the first record
+// that should be retried, and the record is reported as batch item failure.
+func HandleRequest(args *handler.Arguments, event golambda.Event) (interface{}, error) {
+	var kinesisEvent events.KinesisEvent
+	if err := event.Bind(&kinesisEvent); err != nil {
+		return nil, err
+	}
+
+	mapper, err := usecase.LoadAlertMapper(args)
+	if err != nil {
+		return nil, err
+	}
+	// Validate enrichment configuration before handling any message
+	if _, err := usecase.LoadEnricher(args); err != nil {
+		return nil, err
+	}
+
+	ingester := usecase.NewIngester(args, usecase.NewLineParser(mapper), time.Now().UTC())
+	var resp events.KinesisEventResponse
+	for _, record := range kinesisEvent.Records {
+		if err := ingester.Ingest(record.EventID, record.EventSourceArn, string(record.Kinesis.Data)); err != nil {
+			golambda.EmitError(err)
+			// Records after the failed one will be retried in order by Lambda
+			resp.BatchItemFailures = append(resp.BatchItemFailures, events.KinesisBatchItemFailure{
+				ItemIdentifier: record.Kinesis.SequenceNumber,
+			})
+			break
+		}
+	}
+
+	logger.With("result", ingester.Result).Info("Ingested Kinesis records")
+	return &resp, nil
+}
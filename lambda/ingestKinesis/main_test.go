@@ -0,0 +1,83 @@
+package main_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/cookpad/deepalert/internal/adaptor"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/cookpad/deepalert/internal/mock"
+	"github.com/m-mizutani/golambda"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	main "github.com/cookpad/deepalert/lambda/ingestKinesis"
+)
+
+func TestIngestKinesis(t *testing.T) {
+	newEvent := func(data ...string) golambda.Event {
+		var kinesisEvent events.KinesisEvent
+		for i, d := range data {
+			var record events.KinesisEventRecord
+			record.EventID = "shardId-000000000000:" + string(rune('0'+i))
+			record.EventSourceArn = "arn:aws:kinesis:us-east-1:111122223333:stream/alerts"
+			record.Kinesis.SequenceNumber = string(rune('0' + i))
+			record.Kinesis.Data = []byte(d)
+			kinesisEvent.Records = append(kinesisEvent.Records, record)
+		}
+		return golambda.Event{Origin: kinesisEvent}
+	}
+
+	setup := func(sfnErr error) (*handler.Arguments, *mock.SFnClient) {
+		dummySFn, _ := mock.NewSFnClient("")
+		dummyRepo := mock.NewRepository("", "")
+		args := &handler.Arguments{
+			NewRepository: func(string, string) adaptor.Repository { return dummyRepo },
+			NewSFn: func(string) (adaptor.SFnClient, error) {
+				if sfnErr != nil {
+					return nil, sfnErr
+				}
+				return dummySFn, nil
+			},
+			EnvVars: handler.EnvVars{
+				InspectorMachine: "arn:aws:states:us-east-1:111122223333:stateMachine:blue",
+				ReviewMachine:    "arn:aws:states:us-east-1:111122223333:stateMachine:orange",
+			},
+		}
+		return args, dummySFn.(*mock.SFnClient)
+	}
+
+	t.Run("records are ingested", func(t *testing.T) {
+		args, sfn := setup(nil)
+		event := newEvent(
+			`{"detector":"blue","rule_id":"r1","alert_key":"k1"}`,
+			`CEF:0|Security|IDS|1.0|100|port scan|5|src=192.0.2.1`,
+			`not an alert`,
+		)
+
+		resp, err := main.HandleRequest(args, event)
+		require.NoError(t, err)
+		require.IsType(t, &events.KinesisEventResponse{}, resp)
+		assert.Equal(t, 0, len(resp.(*events.KinesisEventResponse).BatchItemFailures))
+		assert.Equal(t, 4, len(sfn.Input))
+
+		// Retried records are not handled again
+		_, err = main.HandleRequest(args, event)
+		require.NoError(t, err)
+		assert.Equal(t, 4, len(sfn.Input))
+	})
+
+	t.Run("temporary failure is reported as batch item failure", func(t *testing.T) {
+		args, _ := setup(errors.New("sfn is unavailable"))
+		resp, err := main.HandleRequest(args, newEvent(
+			`{"detector":"blue","rule_id":"r1","alert_key":"k1"}`,
+			`{"detector":"blue","rule_id":"r1","alert_key":"k2"}`,
+		))
+		require.NoError(t, err)
+		require.IsType(t, &events.KinesisEventResponse{}, resp)
+		failures := resp.(*events.KinesisEventResponse).BatchItemFailures
+		require.Equal(t, 1, len(failures))
+		assert.Equal(t, "0", failures[0].ItemIdentifier)
+	})
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/cookpad/deepalert/internal/usecase"
+	"github.com/m-mizutani/golambda"
+)
+
+var logger = golambda.Logger
+
+func main() {
+	golambda.Start(func(event golambda.Event) (interface{}, error) {
+		args := handler.NewArguments()
+		if err := args.BindEnvVars(); err != nil {
+			return nil, err
+		}
+
+		return HandleRequest(args, event)
+	})
+}
+
+// HandleRequest ingests alerts in S3 objects notified by object created events. An object is NDJSON or
+// lines of syslog and can be compressed by gzip. Error is returned to retry the event if some line should
+// be retried, and lines that have been ingested are skipped in the retry.
+func HandleRequest(args *handler.Arguments, event golambda.Event) (interface{}, error) {
+	var s3Event events.S3Event
+	if err := event.Bind(&s3Event); err != nil {
+		return nil, err
+	}
+
+	mapper, err := usecase.LoadAlertMapper(args)
+	if err != nil {
+		return nil, err
+	}
+	// Validate enrichment configuration before handling any message
+	if _, err := usecase.LoadEnricher(args); err != nil {
+		return nil, err
+	}
+	parse := usecase.NewLineParser(mapper)
+	now := time.Now().UTC()
+
+	var results []*usecase.IngestResult
+	for _, record := range s3Event.Records {
+		bucket := record.S3.Bucket.Name
+		key, err := url.QueryUnescape(record.S3.Object.Key)
+		if err != nil {
+			return nil, golambda.WrapError(err, "Invalid S3 object key").With("key", record.S3.Object.Key)
+		}
+
+		// Version (or ETag) distinguishes overwritten object from retry of the same object
+		version := record.S3.Object.VersionID
+		if version == "" {
+			version = record.S3.Object.ETag
+		}
+		source := fmt.Sprintf("s3://%s/%s?version=%s", bucket, key, version)
+
+		obj, err := args.S3Service().GetObject(record.AWSRegion, bucket, key)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := usecase.IngestLines(args, obj, source, parse, now)
+		obj.Close()
+		logger.With("source", source).With("result", result).Info("Ingested S3 object")
+		if err != nil {
+			// An object that can not be read as lines will never succeed by retry
+			if !usecase.IsPermanentError(err) {
+				return nil, err
+			}
+			golambda.EmitError(err)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,88 @@
+package main_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/cookpad/deepalert/internal/adaptor"
+	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/cookpad/deepalert/internal/mock"
+	"github.com/cookpad/deepalert/internal/usecase"
+	"github.com/m-mizutani/golambda"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	main "github.com/cookpad/deepalert/lambda/ingestS3"
+)
+
+func TestIngestS3(t *testing.T) {
+	ndjson := `{"detector":"blue","rule_id":"r1","alert_key":"k1"}
+{"detector":"blue","rule_id":"r1","alert_key":"k2"}
+{"detector":"blue"}
+`
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	_, err := w.Write([]byte(ndjson))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	s3Client, newS3 := mock.NewMockS3ClientSet()
+	s3Client.PutObject("my-bucket", "alerts/a b.json", []byte(ndjson))
+	s3Client.PutObject("my-bucket", "alerts/c.json.gz", gz.Bytes())
+
+	dummySFn, _ := mock.NewSFnClient("")
+	dummyRepo := mock.NewRepository("", "")
+	args := &handler.Arguments{
+		NewRepository: func(string, string) adaptor.Repository { return dummyRepo },
+		NewSFn:        func(string) (adaptor.SFnClient, error) { return dummySFn, nil },
+		NewS3:         newS3,
+		EnvVars: handler.EnvVars{
+			InspectorMachine: "arn:aws:states:us-east-1:111122223333:stateMachine:blue",
+			ReviewMachine:    "arn:aws:states:us-east-1:111122223333:stateMachine:orange",
+		},
+	}
+
+	newRecord := func(key, etag string) events.S3EventRecord {
+		var record events.S3EventRecord
+		record.AWSRegion = "ap-northeast-1"
+		record.S3.Bucket.Name = "my-bucket"
+		record.S3.Object.Key = key
+		record.S3.Object.ETag = etag
+		return record
+	}
+
+	t.Run("NDJSON and gzip NDJSON objects are ingested", func(t *testing.T) {
+		event := golambda.Event{Origin: events.S3Event{Records: []events.S3EventRecord{
+			newRecord("alerts/a+b.json", "etag-a"),
+			newRecord("alerts/c.json.gz", "etag-c"),
+		}}}
+
+		resp, err := main.HandleRequest(args, event)
+		require.NoError(t, err)
+		results, ok := resp.([]*usecase.IngestResult)
+		require.True(t, ok)
+		require.Equal(t, 2, len(results))
+		assert.Equal(t, 2, results[0].Accepted)
+		assert.Equal(t, 1, len(results[0].Failed))
+		assert.Equal(t, 2, results[1].Accepted)
+		assert.Equal(t, "ap-northeast-1", s3Client.Region)
+		assert.Equal(t, "alerts/a b.json", *s3Client.Input[0].Key)
+
+		// Same object is not ingested again
+		resp, err = main.HandleRequest(args, event)
+		require.NoError(t, err)
+		results = resp.([]*usecase.IngestResult)
+		assert.Equal(t, 0, results[0].Accepted)
+		assert.Equal(t, 2, results[0].Duplicated)
+	})
+
+	t.Run("missing object is error", func(t *testing.T) {
+		event := golambda.Event{Origin: events.S3Event{Records: []events.S3EventRecord{
+			newRecord("alerts/none.json", "etag-x"),
+		}}}
+		_, err := main.HandleRequest(args, event)
+		require.Error(t, err)
+	})
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"crypto/sha256"
 	"encoding/json"
+	"fmt"
 	"time"
 
-	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/alert"
 	"github.com/cookpad/deepalert/internal/handler"
 	"github.com/cookpad/deepalert/internal/usecase"
 	"github.com/m-mizutani/golambda"
@@ -25,37 +27,44 @@ func main() {
 
 // HandleRequest is main logic of ReceptAlert
 func HandleRequest(args *handler.Arguments, event golambda.Event) (interface{}, error) {
-	messages, err := event.DecapSQSBody()
+	now := time.Now().UTC()
+
+	mapper, err := usecase.LoadAlertMapper(args)
 	if err != nil {
 		return nil, err
 	}
+	// Validate enrichment configuration before handling any message
+	if _, err := usecase.LoadEnricher(args); err != nil {
+		return nil, err
+	}
 
-	now := time.Now().UTC()
-
-	for _, msg := range messages {
-		var snsWrapper struct {
-			Message string `json:"Message"`
-		}
+	return usecase.HandleSQSEvent(args, event, func(body []byte) error {
+		return handleMessage(args, mapper, body, now)
+	})
+}
 
-		var data []byte
-		if err := msg.Bind(&snsWrapper); err == nil && snsWrapper.Message != "" {
-			data = []byte(snsWrapper.Message)
-		} else {
-			data = msg
-		}
+func handleMessage(args *handler.Arguments, mapper *alert.Mapper, body []byte, now time.Time) error {
+	var snsWrapper struct {
+		Message string `json:"Message"`
+	}
 
-		logger.With("data", string(data)).Debug("Start handle alert")
+	var data []byte
+	if err := json.Unmarshal(body, &snsWrapper); err == nil && snsWrapper.Message != "" {
+		data = []byte(snsWrapper.Message)
+	} else {
+		data = body
+	}
 
-		var alert deepalert.Alert
-		if err := json.Unmarshal(data, &alert); err != nil {
-			return nil, golambda.WrapError(err, "Fail to unmarshal alert").With("alert", string(msg))
-		}
+	logger.With("data", string(data)).Debug("Start handle alert")
 
-		_, err = usecase.HandleAlert(args, &alert, now)
-		if err != nil {
-			return nil, err
-		}
+	alerts, mapping, err := mapper.Decode(data)
+	if err != nil {
+		return golambda.WrapError(usecase.ErrMalformedMessage, "Fail to decode alert:", err).
+			With("alert", string(body)).With("mapping", mapping)
 	}
 
-	return nil, nil
+	// SQS retries the same body, and key of the body skips alerts that have been handled in the previous try
+	messageKey := fmt.Sprintf("recept/%x", sha256.Sum256(data))
+	_, err = usecase.HandleAlerts(args, messageKey, alerts, now)
+	return err
 }
@@ -2,13 +2,16 @@ package main_test
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/service/sfn"
 	"github.com/cookpad/deepalert"
 	"github.com/cookpad/deepalert/internal/adaptor"
 	"github.com/cookpad/deepalert/internal/handler"
 	"github.com/cookpad/deepalert/internal/mock"
+	"github.com/cookpad/deepalert/internal/usecase"
 	"github.com/google/uuid"
 	"github.com/m-mizutani/golambda"
 	"github.com/stretchr/testify/assert"
@@ -17,6 +20,24 @@ import (
 	main "github.com/cookpad/deepalert/lambda/receptAlert"
 )
 
+// flakySFnClient fails StartExecution while fail is true
+type flakySFnClient struct {
+	fail  bool
+	Input []*sfn.StartExecutionInput
+}
+
+func (x *flakySFnClient) StartExecution(input *sfn.StartExecutionInput) (*sfn.StartExecutionOutput, error) {
+	if x.fail {
+		return nil, errors.New("temporary failure")
+	}
+	x.Input = append(x.Input, input)
+	if len(x.Input) == 2 {
+		// Fail after the first alert is handled
+		x.fail = true
+	}
+	return &sfn.StartExecutionOutput{}, nil
+}
+
 func TestReceptAlert(t *testing.T) {
 	t.Run("Recept single alert via SQS", func(tt *testing.T) {
 		alert := &deepalert.Alert{
@@ -42,7 +63,8 @@ func TestReceptAlert(t *testing.T) {
 
 		resp, err := main.HandleRequest(args, event)
 		require.NoError(tt, err)
-		assert.Nil(tt, resp)
+		require.IsType(tt, &events.SQSEventResponse{}, resp)
+		assert.Equal(tt, 0, len(resp.(*events.SQSEventResponse).BatchItemFailures))
 
 		// Check only execution of StepFunctions. More detailed test are in internal/usecase
 		sfn, ok := dummySFn.(*mock.SFnClient)
@@ -78,7 +100,8 @@ func TestReceptAlert(t *testing.T) {
 
 		resp, err := main.HandleRequest(args, event)
 		require.NoError(tt, err)
-		assert.Nil(tt, resp)
+		require.IsType(tt, &events.SQSEventResponse{}, resp)
+		assert.Equal(tt, 0, len(resp.(*events.SQSEventResponse).BatchItemFailures))
 
 		// Check only execution of StepFunctions. More detailed test are in internal/usecase
 		sfn, ok := dummySFn.(*mock.SFnClient)
@@ -86,4 +109,246 @@ func TestReceptAlert(t *testing.T) {
 		require.Equal(tt, 2, len(sfn.Input))
 	})
 
+	t.Run("Recept Security Hub findings in EventBridge event", func(tt *testing.T) {
+		finding := func(id string) map[string]interface{} {
+			return map[string]interface{}{
+				"SchemaVersion": "2018-10-08",
+				"Id":            id,
+				"ProductArn":    "arn:aws:securityhub:us-east-1::product/aws/guardduty",
+				"GeneratorId":   "arn:aws:guardduty:us-east-1:111122223333:detector/xxx",
+				"Title":         "EC2 instance is communicating with a known C2 server",
+				"Network": map[string]interface{}{
+					"Direction":       "OUT",
+					"DestinationIpV4": "192.0.2.1",
+				},
+			}
+		}
+		eventBridge := map[string]interface{}{
+			"version":     "0",
+			"detail-type": "Security Hub Findings - Imported",
+			"source":      "aws.securityhub",
+			"detail": map[string]interface{}{
+				"findings": []interface{}{finding(uuid.New().String()), finding(uuid.New().String())},
+			},
+		}
+
+		var event golambda.Event
+		require.NoError(t, event.EncapSQS(eventBridge))
+
+		dummySFn, _ := mock.NewSFnClient("")
+		dummyRepo := mock.NewRepository("", "")
+		args := &handler.Arguments{
+			NewRepository: func(string, string) adaptor.Repository { return dummyRepo },
+			NewSFn:        func(string) (adaptor.SFnClient, error) { return dummySFn, nil },
+			EnvVars: handler.EnvVars{
+				InspectorMachine: "arn:aws:states:us-east-1:111122223333:stateMachine:blue",
+				ReviewMachine:    "arn:aws:states:us-east-1:111122223333:stateMachine:orange",
+			},
+		}
+
+		resp, err := main.HandleRequest(args, event)
+		require.NoError(tt, err)
+		require.IsType(tt, &events.SQSEventResponse{}, resp)
+		assert.Equal(tt, 0, len(resp.(*events.SQSEventResponse).BatchItemFailures))
+
+		// Two new reports for two findings
+		sfn, ok := dummySFn.(*mock.SFnClient)
+		require.True(tt, ok)
+		require.Equal(tt, 4, len(sfn.Input))
+	})
+
+	t.Run("Recept arbitrary JSON data by alert mapping", func(tt *testing.T) {
+		var event golambda.Event
+		require.NoError(t, event.EncapSQS(map[string]interface{}{
+			"source": "syscall",
+			"rule":   "Terminal shell in container",
+			"pod":    uuid.New().String(),
+		}))
+
+		dummySFn, _ := mock.NewSFnClient("")
+		dummyRepo := mock.NewRepository("", "")
+		args := &handler.Arguments{
+			NewRepository: func(string, string) adaptor.Repository { return dummyRepo },
+			NewSFn:        func(string) (adaptor.SFnClient, error) { return dummySFn, nil },
+			EnvVars: handler.EnvVars{
+				InspectorMachine: "arn:aws:states:us-east-1:111122223333:stateMachine:blue",
+				ReviewMachine:    "arn:aws:states:us-east-1:111122223333:stateMachine:orange",
+				AlertMappings: `{"mappings":[{"name":"falco","route":{"path":"$.source","value":"syscall"},
+					"detector":"falco","rule_id":"{{ $.rule }}","alert_key":"{{ $.pod }}"}]}`,
+			},
+		}
+
+		resp, err := main.HandleRequest(args, event)
+		require.NoError(tt, err)
+		require.IsType(tt, &events.SQSEventResponse{}, resp)
+		assert.Equal(tt, 0, len(resp.(*events.SQSEventResponse).BatchItemFailures))
+
+		sfn, ok := dummySFn.(*mock.SFnClient)
+		require.True(tt, ok)
+		require.Equal(tt, 2, len(sfn.Input))
+		assert.Contains(tt, *sfn.Input[0].Input, `"detector":"falco"`)
+	})
+
+	t.Run("Malformed and invalid alerts are moved to dead-letter queue", func(tt *testing.T) {
+		valid := &deepalert.Alert{
+			AlertKey: uuid.New().String(),
+			RuleID:   "five",
+			RuleName: "fifth",
+			Detector: "ao",
+		}
+		invalid := &deepalert.Alert{
+			AlertKey: uuid.New().String(),
+			RuleName: "no rule ID",
+			Detector: "ao",
+		}
+
+		var event golambda.Event
+		require.NoError(tt, event.EncapSQS([]interface{}{valid, "not an alert", invalid}))
+
+		dummySFn, _ := mock.NewSFnClient("")
+		dummyRepo := mock.NewRepository("", "")
+		dummySQS, newSQS := mock.NewMockSQSClientSet()
+		args := &handler.Arguments{
+			NewRepository: func(string, string) adaptor.Repository { return dummyRepo },
+			NewSFn:        func(string) (adaptor.SFnClient, error) { return dummySFn, nil },
+			NewSQS:        newSQS,
+			EnvVars: handler.EnvVars{
+				InspectorMachine: "arn:aws:states:us-east-1:111122223333:stateMachine:blue",
+				ReviewMachine:    "arn:aws:states:us-east-1:111122223333:stateMachine:orange",
+				DeadLetterQueue:  "https://sqs.us-east-1.amazonaws.com/111122223333/dlq",
+				FunctionName:     "receptAlert",
+			},
+		}
+
+		resp, err := main.HandleRequest(args, event)
+		require.NoError(tt, err)
+		require.IsType(tt, &events.SQSEventResponse{}, resp)
+		assert.Equal(tt, 0, len(resp.(*events.SQSEventResponse).BatchItemFailures))
+
+		sfn, ok := dummySFn.(*mock.SFnClient)
+		require.True(tt, ok)
+		require.Equal(tt, 2, len(sfn.Input))
+
+		require.Equal(tt, 2, len(dummySQS.Input))
+		for _, input := range dummySQS.Input {
+			assert.Equal(tt, "https://sqs.us-east-1.amazonaws.com/111122223333/dlq", *input.QueueUrl)
+			assert.Equal(tt, "receptAlert", *input.MessageAttributes[usecase.DeadLetterAttrFunction].StringValue)
+			assert.NotEmpty(tt, *input.MessageAttributes[usecase.DeadLetterAttrReason].StringValue)
+		}
+	})
+
+	t.Run("Alert failed by temporary error is reported as batch item failure", func(tt *testing.T) {
+		alert := &deepalert.Alert{
+			AlertKey: uuid.New().String(),
+			RuleID:   "five",
+			RuleName: "fifth",
+			Detector: "ao",
+		}
+
+		var event golambda.Event
+		require.NoError(tt, event.EncapSQS(alert))
+		msgID := event.Origin.(events.SQSEvent).Records[0].MessageId
+
+		dummyRepo := mock.NewRepository("", "")
+		dummySQS, newSQS := mock.NewMockSQSClientSet()
+		args := &handler.Arguments{
+			NewRepository: func(string, string) adaptor.Repository { return dummyRepo },
+			NewSFn: func(string) (adaptor.SFnClient, error) {
+				return nil, errors.New("temporary failure")
+			},
+			NewSQS: newSQS,
+			EnvVars: handler.EnvVars{
+				InspectorMachine: "arn:aws:states:us-east-1:111122223333:stateMachine:blue",
+				ReviewMachine:    "arn:aws:states:us-east-1:111122223333:stateMachine:orange",
+				DeadLetterQueue:  "https://sqs.us-east-1.amazonaws.com/111122223333/dlq",
+			},
+		}
+
+		resp, err := main.HandleRequest(args, event)
+		require.NoError(tt, err)
+		require.IsType(tt, &events.SQSEventResponse{}, resp)
+		failures := resp.(*events.SQSEventResponse).BatchItemFailures
+		require.Equal(tt, 1, len(failures))
+		assert.Equal(tt, msgID, failures[0].ItemIdentifier)
+		assert.Equal(tt, 0, len(dummySQS.Input))
+	})
+
+	t.Run("Retry of multiple alerts handles only failed alerts", func(tt *testing.T) {
+		finding := func(id string) map[string]interface{} {
+			return map[string]interface{}{
+				"SchemaVersion": "2018-10-08",
+				"Id":            id,
+				"ProductArn":    "arn:aws:securityhub:us-east-1::product/aws/guardduty",
+				"GeneratorId":   "arn:aws:guardduty:us-east-1:111122223333:detector/xxx",
+				"Title":         "EC2 instance is communicating with a known C2 server",
+			}
+		}
+
+		var event golambda.Event
+		require.NoError(tt, event.EncapSQS(map[string]interface{}{
+			"Findings": []interface{}{finding(uuid.New().String()), finding(uuid.New().String())},
+		}))
+
+		client := &flakySFnClient{}
+		dummyRepo := mock.NewRepository("", "")
+		args := &handler.Arguments{
+			NewRepository: func(string, string) adaptor.Repository { return dummyRepo },
+			NewSFn:        func(string) (adaptor.SFnClient, error) { return client, nil },
+			EnvVars: handler.EnvVars{
+				InspectorMachine: "arn:aws:states:us-east-1:111122223333:stateMachine:blue",
+				ReviewMachine:    "arn:aws:states:us-east-1:111122223333:stateMachine:orange",
+			},
+		}
+
+		resp, err := main.HandleRequest(args, event)
+		require.NoError(tt, err)
+		require.Equal(tt, 1, len(resp.(*events.SQSEventResponse).BatchItemFailures))
+		require.Equal(tt, 2, len(client.Input))
+
+		// Retry of the same message
+		client.fail = false
+		resp, err = main.HandleRequest(args, event)
+		require.NoError(tt, err)
+		assert.Equal(tt, 0, len(resp.(*events.SQSEventResponse).BatchItemFailures))
+		// Only the second alert is handled again. Its report was taken in the first try, so only
+		// InspectorDelayMachine is executed.
+		require.Equal(tt, 3, len(client.Input))
+		assert.Equal(tt, args.InspectorMachine, *client.Input[2].StateMachineArn)
+		assert.NotEqual(tt, *client.Input[0].Input, *client.Input[2].Input)
+
+		// Retry after success handles nothing
+		_, err = main.HandleRequest(args, event)
+		require.NoError(tt, err)
+		assert.Equal(tt, 3, len(client.Input))
+	})
+
+	t.Run("Invalid enrichment configuration fails invocation before handling messages", func(tt *testing.T) {
+		alert := &deepalert.Alert{
+			AlertKey: uuid.New().String(),
+			RuleID:   "five",
+			RuleName: "fifth",
+			Detector: "ao",
+		}
+		var event golambda.Event
+		require.NoError(tt, event.EncapSQS(alert))
+
+		dummySFn, _ := mock.NewSFnClient("")
+		dummySQS, newSQS := mock.NewMockSQSClientSet()
+		args := &handler.Arguments{
+			NewRepository: func(string, string) adaptor.Repository { return mock.NewRepository("", "") },
+			NewSFn:        func(string) (adaptor.SFnClient, error) { return dummySFn, nil },
+			NewSQS:        newSQS,
+			EnvVars: handler.EnvVars{
+				InspectorMachine: "arn:aws:states:us-east-1:111122223333:stateMachine:blue",
+				ReviewMachine:    "arn:aws:states:us-east-1:111122223333:stateMachine:orange",
+				DeadLetterQueue:  "https://sqs.us-east-1.amazonaws.com/111122223333/dlq",
+				OrgCIDRs:         "10.0.0.0/33",
+			},
+		}
+
+		_, err := main.HandleRequest(args, event)
+		require.Error(tt, err)
+		assert.Equal(tt, 0, len(dummySFn.(*mock.SFnClient).Input))
+		assert.Equal(tt, 0, len(dummySQS.Input))
+	})
 }
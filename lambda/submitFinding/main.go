@@ -6,6 +6,7 @@ import (
 
 	"github.com/cookpad/deepalert"
 	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/cookpad/deepalert/internal/usecase"
 	"github.com/m-mizutani/golambda"
 )
 
@@ -18,29 +19,21 @@ func main() {
 			return nil, err
 		}
 
-		if err := handleRequest(args, event); err != nil {
-			return nil, err
-		}
-		return nil, nil
+		return handleRequest(args, event)
 	})
 }
 
-func handleRequest(args *handler.Arguments, event golambda.Event) error {
-	messages, err := event.DecapSQSBody()
-	if err != nil {
-		return err
-	}
-
+func handleRequest(args *handler.Arguments, event golambda.Event) (interface{}, error) {
 	repo, err := args.Repository()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	now := time.Now()
 
-	for _, msg := range messages {
+	return usecase.HandleSQSEvent(args, event, func(body []byte) error {
 		var ir deepalert.Finding
-		if err := json.Unmarshal(msg, &ir); err != nil {
-			return golambda.WrapError(err, "Fail to unmarshal Finding from SubmitNotification").With("msg", string(msg))
+		if err := json.Unmarshal(body, &ir); err != nil {
+			return golambda.WrapError(usecase.ErrMalformedMessage, "Fail to unmarshal Finding from SubmitNotification:", err).With("msg", string(body))
 		}
 		logger.With("inspectReport", ir).Debug("Handling inspect report")
 
@@ -48,7 +41,7 @@ func handleRequest(args *handler.Arguments, event golambda.Event) error {
 			return golambda.WrapError(err, "Fail to save Finding").With("report", ir)
 		}
 		logger.With("section", ir).Info("Saved content")
-	}
 
-	return nil
+		return nil
+	})
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/cookpad/deepalert"
 	"github.com/cookpad/deepalert/internal/handler"
+	"github.com/cookpad/deepalert/internal/usecase"
 	"github.com/m-mizutani/golambda"
 )
 
@@ -28,11 +29,14 @@ func handleRequest(args *handler.Arguments, event golambda.Event) error {
 		return err
 	}
 
-	report.Status = deepalert.StatusPublished
-	if report.Result.Severity == "" {
-		report.Result.Severity = deepalert.SevUnclassified
+	policy, err := usecase.LoadReviewPolicy(args)
+	if err != nil {
+		return err
 	}
 
+	report.Status = deepalert.StatusPublished
+	usecase.FinalizeResult(&report, policy)
+
 	repo, err := args.Repository()
 	if err != nil {
 		return err
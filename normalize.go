@@ -0,0 +1,160 @@
+package deepalert
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// HashMode specifies fields of Attribute used by HashWith.
+type HashMode int
+
+const (
+	// HashModeRaw uses all fields of Attribute as is, including Timestamp.
+	HashModeRaw HashMode = iota
+	// HashModeIdentity uses Type, Key, normalized Value and Context. Attributes that point to the same entity
+	// (e.g. "EXAMPLE.com" and "example.com.") have the same hash regardless of observed time.
+	HashModeIdentity
+)
+
+var (
+	refangScheme   = regexp.MustCompile(`(?i)^h(xx|\*\*)p(s?)://`)
+	refangReplacer = strings.NewReplacer("[.]", ".", "(.)", ".", "{.}", ".", "[dot]", ".", "(dot)", ".",
+		"[://]", "://", "[:]", ":", "[@]", "@", "[at]", "@", "(at)", "@", "[/]", "/")
+)
+
+// Refang restores defanged indicator, e.g. "hxxps://example[.]com" to "https://example.com".
+func Refang(value string) string {
+	value = refangReplacer.Replace(value)
+	return refangScheme.ReplaceAllStringFunc(value, func(s string) string {
+		return "http" + strings.ToLower(refangScheme.FindStringSubmatch(s)[2]) + "://"
+	})
+}
+
+// NormalizeAttrValue returns canonical form of value for the type. It lowercases names and domain of email address,
+// strips trailing dot, canonicalizes IP address, CIDR, MAC address and URL, and refangs network indicators. A value
+// that can not be parsed is returned only with surrounding spaces trimmed.
+func NormalizeAttrValue(attrType AttrType, value string) string {
+	value = strings.TrimSpace(value)
+
+	switch attrType {
+	case TypeIPAddr:
+		return normalizeIPAddr(Refang(value))
+
+	case TypeDomainName, TypeHostName:
+		return strings.TrimSuffix(strings.ToLower(Refang(value)), ".")
+
+	case TypeEmailAddress:
+		return normalizeEmailAddr(Refang(value))
+
+	case TypeURL:
+		return normalizeURL(Refang(value))
+
+	case TypeCIDR:
+		if _, ipnet, err := net.ParseCIDR(Refang(value)); err == nil {
+			return ipnet.String()
+		}
+		return value
+
+	case TypeMACAddr:
+		if mac, err := net.ParseMAC(value); err == nil {
+			return mac.String()
+		}
+		return value
+
+	case TypeFileHashValue:
+		return strings.ToLower(value)
+
+	case TypeCertFingerprint:
+		return strings.ToLower(strings.ReplaceAll(value, ":", ""))
+
+	case TypePort:
+		return strings.ToLower(value)
+	}
+
+	return value
+}
+
+func normalizeIPAddr(value string) string {
+	zone := ""
+	if i := strings.IndexByte(value, '%'); i > 0 {
+		value, zone = value[:i], value[i:]
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return value + zone
+	}
+	// IPv4-mapped IPv6 address such as ::ffff:1.2.3.4 is converted to IPv4
+	if v4 := ip.To4(); v4 != nil {
+		return v4.String()
+	}
+	return ip.String() + zone
+}
+
+// normalizeEmailAddr lowercases only domain part because local part is case-sensitive by RFC 5321.
+func normalizeEmailAddr(value string) string {
+	value = strings.TrimSuffix(value, ".")
+	i := strings.LastIndexByte(value, '@')
+	if i < 0 {
+		return value
+	}
+	return value[:i+1] + strings.ToLower(value[i+1:])
+}
+
+func normalizeURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" {
+		return value
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host, port := strings.TrimSuffix(strings.ToLower(u.Hostname()), "."), u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+	return u.String()
+}
+
+// Normalize returns a copy of the attribute with normalized Value and sorted and deduplicated Context.
+func (x Attribute) Normalize() Attribute {
+	x.Value = NormalizeAttrValue(x.Type, x.Value)
+
+	if len(x.Context) > 0 {
+		contexts := make(AttrContexts, 0, len(x.Context))
+		for _, ctx := range x.Context {
+			if !contexts.Have(ctx) {
+				contexts = append(contexts, ctx)
+			}
+		}
+		sort.Slice(contexts, func(i, j int) bool { return contexts[i] < contexts[j] })
+		x.Context = contexts
+	} else {
+		x.Context = nil
+	}
+	return x
+}
+
+// HashWith provides an unique value for the Attribute by mode.
+func (x Attribute) HashWith(mode HashMode) string {
+	if mode != HashModeIdentity {
+		return x.Hash()
+	}
+
+	normalized := x.Normalize()
+	identity := Attribute{
+		Type:    normalized.Type,
+		Key:     normalized.Key,
+		Value:   normalized.Value,
+		Context: normalized.Context,
+	}
+	return identity.Hash()
+}
@@ -0,0 +1,80 @@
+package deepalert_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	da "github.com/cookpad/deepalert"
+)
+
+func TestNormalizeAttrValue(t *testing.T) {
+	testCases := []struct {
+		attrType da.AttrType
+		input    string
+		expected string
+	}{
+		{da.TypeIPAddr, "::ffff:1.2.3.4", "1.2.3.4"},
+		{da.TypeIPAddr, " 10[.]0[.]0[.]1 ", "10.0.0.1"},
+		{da.TypeIPAddr, "2001:DB8:0:0::1", "2001:db8::1"},
+		{da.TypeIPAddr, "fe80::1%eth0", "fe80::1%eth0"},
+		{da.TypeIPAddr, "not-an-ip", "not-an-ip"},
+		{da.TypeDomainName, "EXAMPLE.com.", "example.com"},
+		{da.TypeDomainName, "www.example[.]com", "www.example.com"},
+		{da.TypeHostName, "WEB-01.", "web-01"},
+		{da.TypeEmailAddress, "Alice[@]Example[.]com", "Alice@example.com"},
+		{da.TypeEmailAddress, "bob@EXAMPLE.com.", "bob@example.com"},
+		{da.TypeURL, "hxxps://Example[.]com/Path?q=A", "https://example.com/Path?q=A"},
+		{da.TypeURL, "hXXp[://]example.com:80/", "http://example.com/"},
+		{da.TypeURL, "HTTPS://EXAMPLE.COM.:8443/a", "https://example.com:8443/a"},
+		{da.TypeURL, "https://[2001:DB8::1]:443/", "https://[2001:db8::1]/"},
+		{da.TypeCIDR, "10.1.2.3/8", "10.0.0.0/8"},
+		{da.TypeMACAddr, "00-1A-2B-3C-4D-5E", "00:1a:2b:3c:4d:5e"},
+		{da.TypeFileHashValue, "D41D8CD98F00B204E9800998ECF8427E", "d41d8cd98f00b204e9800998ecf8427e"},
+		{da.TypeCertFingerprint, "DA:39:A3:EE", "da39a3ee"},
+		{da.TypePort, "53/UDP", "53/udp"},
+		{da.TypeUserName, " Alice ", "Alice"},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, da.NormalizeAttrValue(tc.attrType, tc.input), "%s: %q", tc.attrType, tc.input)
+	}
+}
+
+func TestAttributeHashWith(t *testing.T) {
+	ts1 := time.Now()
+	ts2 := ts1.Add(time.Hour)
+
+	a1 := da.Attribute{
+		Type:      da.TypeIPAddr,
+		Key:       "src",
+		Value:     "::ffff:1.2.3.4",
+		Context:   da.AttrContexts{da.CtxRemote, da.CtxClient, da.CtxRemote},
+		Timestamp: &ts1,
+	}
+	a2 := da.Attribute{
+		Type:      da.TypeIPAddr,
+		Key:       "src",
+		Value:     "1.2.3.4",
+		Context:   da.AttrContexts{da.CtxClient, da.CtxRemote},
+		Timestamp: &ts2,
+	}
+	a3 := a2
+	a3.Key = "dst"
+
+	assert.NotEqual(t, a1.HashWith(da.HashModeRaw), a2.HashWith(da.HashModeRaw))
+	assert.Equal(t, a1.Hash(), a1.HashWith(da.HashModeRaw))
+	assert.Equal(t, a1.HashWith(da.HashModeIdentity), a2.HashWith(da.HashModeIdentity))
+	assert.NotEqual(t, a2.HashWith(da.HashModeIdentity), a3.HashWith(da.HashModeIdentity))
+
+	empty := da.Attribute{Type: da.TypeUserName, Key: "user", Value: "alice"}
+	withEmptyCtx := empty
+	withEmptyCtx.Context = da.AttrContexts{}
+	assert.Equal(t, empty.HashWith(da.HashModeIdentity), withEmptyCtx.HashWith(da.HashModeIdentity))
+
+	normalized := a1.Normalize()
+	assert.Equal(t, "1.2.3.4", normalized.Value)
+	assert.Equal(t, da.AttrContexts{da.CtxClient, da.CtxRemote}, normalized.Context)
+	assert.Equal(t, "::ffff:1.2.3.4", a1.Value)
+}
@@ -0,0 +1,84 @@
+package deepalert
+
+import "time"
+
+// Provenance is metadata of Finding to show when, by which version of inspector and how confidently the
+// finding was produced, and where analysts can verify it.
+type Provenance struct {
+	ProducedAt       *time.Time `json:"produced_at,omitempty"`
+	InspectorVersion string     `json:"inspector_version,omitempty"`
+	// Confidence is 0 to 100. 0 means not specified.
+	Confidence int `json:"confidence,omitempty"`
+	// EvidenceURLs are links to evidence or console of data source.
+	EvidenceURLs []string `json:"evidence_urls,omitempty"`
+	// ExpiresAt is optional. The finding should not be trusted after ExpiresAt.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// IsExpired returns true if ExpiresAt is set and before now.
+func (x *Provenance) IsExpired(now time.Time) bool {
+	return x.ExpiresAt != nil && x.ExpiresAt.Before(now)
+}
+
+// IsEmpty returns true if no field is set.
+func (x *Provenance) IsEmpty() bool {
+	return x.ProducedAt == nil && x.InspectorVersion == "" && x.Confidence == 0 &&
+		len(x.EvidenceURLs) == 0 && x.ExpiresAt == nil
+}
+
+// Merge returns Provenance that has fields of x and fields of base for fields that are not set in x.
+func (x Provenance) Merge(base Provenance) Provenance {
+	if x.ProducedAt == nil {
+		x.ProducedAt = base.ProducedAt
+	}
+	if x.InspectorVersion == "" {
+		x.InspectorVersion = base.InspectorVersion
+	}
+	if x.Confidence == 0 {
+		x.Confidence = base.Confidence
+	}
+	if len(x.EvidenceURLs) == 0 {
+		x.EvidenceURLs = base.EvidenceURLs
+	}
+	if x.ExpiresAt == nil {
+		x.ExpiresAt = base.ExpiresAt
+	}
+	return x
+}
+
+// ContentWithProvenance attaches Provenance to one content in TaskResult. Provenance of the content takes
+// precedence over TaskResult.Provenance.
+type ContentWithProvenance struct {
+	Content    ReportContent
+	Provenance Provenance
+}
+
+// Type of ContentWithProvenance returns type of Content
+func (x *ContentWithProvenance) Type() ReportContentType {
+	return x.Content.Type()
+}
+
+// WithProvenance wraps content to return it with own Provenance from inspector.
+func WithProvenance(content ReportContent, provenance Provenance) ReportContent {
+	return &ContentWithProvenance{Content: content, Provenance: provenance}
+}
+
+// ContentProvenance is Provenance of a content in Section. The content is specified by Type and Index, index
+// of the field of Section for Type (e.g. Hosts for ContentTypeHost, Custom for custom types).
+type ContentProvenance struct {
+	Type   ReportContentType `json:"type"`
+	Index  int               `json:"index"`
+	Author string            `json:"author"`
+	Provenance
+}
+
+// ProvenanceOf returns Provenance of the content specified by contentType and index. It returns nil if the
+// content has no Provenance.
+func (x *Section) ProvenanceOf(contentType ReportContentType, index int) *ContentProvenance {
+	for _, p := range x.Provenances {
+		if p.Type == contentType && p.Index == index {
+			return p
+		}
+	}
+	return nil
+}
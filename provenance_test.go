@@ -0,0 +1,38 @@
+package deepalert_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	da "github.com/cookpad/deepalert"
+)
+
+func TestProvenance(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	t.Run("merge fills unset fields by base", func(t *testing.T) {
+		p := da.Provenance{Confidence: 80}.Merge(da.Provenance{
+			Confidence:       10,
+			InspectorVersion: "v1",
+			ExpiresAt:        &future,
+		})
+		assert.Equal(t, 80, p.Confidence)
+		assert.Equal(t, "v1", p.InspectorVersion)
+		assert.Equal(t, &future, p.ExpiresAt)
+		assert.Nil(t, p.ProducedAt)
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		assert.False(t, (&da.Provenance{}).IsExpired(now))
+		assert.False(t, (&da.Provenance{ExpiresAt: &future}).IsExpired(now))
+		assert.True(t, (&da.Provenance{ExpiresAt: &past}).IsExpired(now))
+	})
+
+	t.Run("content with provenance has type of original content", func(t *testing.T) {
+		content := da.WithProvenance(&da.ContentHost{}, da.Provenance{Confidence: 1})
+		assert.Equal(t, da.ContentTypeHost, content.Type())
+	})
+}
@@ -0,0 +1,329 @@
+// Package render formats deepalert.Report for humans as Markdown, standalone HTML and Slack Block Kit JSON.
+//
+// Each format is rendered by templates in templates directory. A template can be overridden by giving
+// template text that has {{define "NAME"}} of the same name in Arguments, e.g. {{define "section"}} to
+// change how a section of an attribute is rendered.
+package render
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"unicode/utf8"
+
+	"github.com/cookpad/deepalert"
+	"github.com/m-mizutani/golambda"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+const (
+	// SlackMaxBlocks is max number of blocks in a Slack message
+	SlackMaxBlocks = 50
+	// SlackMaxTextLength is max length of text in a section block
+	SlackMaxTextLength = 3000
+	// SlackMaxHeaderLength is max length of text in a header block
+	SlackMaxHeaderLength = 150
+
+	truncatedSuffix = "…"
+)
+
+// Arguments is parameters of New.
+type Arguments struct {
+	// MarkdownTemplate, HTMLTemplate and SlackTemplate override default templates with the same name. (Optional)
+	MarkdownTemplate string
+	HTMLTemplate     string
+	SlackTemplate    string
+
+	// MaxLength is max bytes of Markdown and HTML output. Sections and alerts are omitted from the last one to
+	// fit it, and Markdown is truncated if it's still too long. 0 means no limit. (Optional)
+	MaxLength int
+
+	// SlackMaxBlocks and SlackMaxTextLength override Slack limits. 0 means default limit. (Optional)
+	SlackMaxBlocks     int
+	SlackMaxTextLength int
+}
+
+// Renderer renders deepalert.Report.
+type Renderer struct {
+	args     Arguments
+	markdown *texttemplate.Template
+	html     *htmltemplate.Template
+	slack    *texttemplate.Template
+}
+
+// markdownReplacer escapes characters of Markdown syntax and folds line breaks so that a value from report
+// can not inject links, emphasis, tables or new blocks.
+var markdownReplacer = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "~", `\~`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "|", `\|`, "&", `\&`, "\r\n", " ", "\r", " ", "\n", " ",
+)
+
+func escapeMarkdown(s string) string {
+	return markdownReplacer.Replace(s)
+}
+
+// codeSpan returns s as Markdown code span. Fence is longer than backticks in s because backslash escape does
+// not work in code span.
+func codeSpan(s string) string {
+	s = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(s)
+
+	longest, run := 0, 0
+	for _, c := range s {
+		if c == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+
+	fence := strings.Repeat("`", longest+1)
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+var funcMap = map[string]interface{}{
+	"join": strings.Join,
+	// md escapes a value in Markdown text, including table cells
+	"md": escapeMarkdown,
+	// cell is the same as md and kept for templates written for table cells
+	"cell": escapeMarkdown,
+	// code puts a value in Markdown code span
+	"code": codeSpan,
+	// e escapes control characters of Slack mrkdwn
+	"e": func(s string) string {
+		return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+	},
+}
+
+func parseText(name, override string) (*texttemplate.Template, error) {
+	tmpl, err := texttemplate.New(name).Funcs(funcMap).ParseFS(templateFS, "templates/"+name)
+	if err != nil {
+		return nil, golambda.WrapError(err, "Fail to parse default template").With("name", name)
+	}
+	if override != "" {
+		if tmpl, err = tmpl.Parse(override); err != nil {
+			return nil, golambda.WrapError(err, "Fail to parse template").With("name", name)
+		}
+	}
+	return tmpl, nil
+}
+
+// New returns Renderer with default templates overridden by Arguments.
+func New(args Arguments) (*Renderer, error) {
+	if args.SlackMaxBlocks <= 0 || args.SlackMaxBlocks > SlackMaxBlocks {
+		args.SlackMaxBlocks = SlackMaxBlocks
+	}
+	if args.SlackMaxTextLength <= 0 || args.SlackMaxTextLength > SlackMaxTextLength {
+		args.SlackMaxTextLength = SlackMaxTextLength
+	}
+
+	markdown, err := parseText("report.md.tmpl", args.MarkdownTemplate)
+	if err != nil {
+		return nil, err
+	}
+	slack, err := parseText("slack.tmpl", args.SlackTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	html, err := htmltemplate.New("report.html.tmpl").Funcs(funcMap).ParseFS(templateFS, "templates/report.html.tmpl")
+	if err != nil {
+		return nil, golambda.WrapError(err, "Fail to parse default template").With("name", "report.html.tmpl")
+	}
+	if args.HTMLTemplate != "" {
+		if html, err = html.Parse(args.HTMLTemplate); err != nil {
+			return nil, golambda.WrapError(err, "Fail to parse template").With("name", "report.html.tmpl")
+		}
+	}
+
+	return &Renderer{
+		args:     args,
+		markdown: markdown,
+		html:     html,
+		slack:    slack,
+	}, nil
+}
+
+type executor interface {
+	ExecuteTemplate(w *bytes.Buffer, name string, data interface{}) error
+}
+
+type textExecutor struct{ *texttemplate.Template }
+
+func (x textExecutor) ExecuteTemplate(w *bytes.Buffer, name string, data interface{}) error {
+	return x.Template.ExecuteTemplate(w, name, data)
+}
+
+type htmlExecutor struct{ *htmltemplate.Template }
+
+func (x htmlExecutor) ExecuteTemplate(w *bytes.Buffer, name string, data interface{}) error {
+	return x.Template.ExecuteTemplate(w, name, data)
+}
+
+// renderDocument renders "report" template and omits sections and alerts until it fits MaxLength.
+func (x *Renderer) renderDocument(tmpl executor, report *deepalert.Report) (string, bool, error) {
+	view := NewView(report)
+	for {
+		var buf bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&buf, "report", view); err != nil {
+			return "", false, golambda.WrapError(err, "Fail to render report").With("report.ID", report.ID)
+		}
+		if x.args.MaxLength <= 0 || buf.Len() <= x.args.MaxLength {
+			return buf.String(), true, nil
+		}
+		if !view.dropLast() {
+			return buf.String(), false, nil
+		}
+	}
+}
+
+// Markdown renders report as Markdown.
+func (x *Renderer) Markdown(report *deepalert.Report) (string, error) {
+	out, fit, err := x.renderDocument(textExecutor{x.markdown}, report)
+	if err != nil {
+		return "", err
+	}
+	if !fit {
+		out = truncate(out, x.args.MaxLength)
+	}
+	return out, nil
+}
+
+// HTML renders report as a standalone HTML document. HTML is not truncated to keep the document valid even if
+// it exceeds MaxLength after omitting all sections and alerts.
+func (x *Renderer) HTML(report *deepalert.Report) (string, error) {
+	out, _, err := x.renderDocument(htmlExecutor{x.html}, report)
+	return out, err
+}
+
+// SlackText is text object of Slack Block Kit.
+type SlackText struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Emoji bool   `json:"emoji,omitempty"`
+}
+
+// SlackBlock is a layout block of Slack Block Kit.
+type SlackBlock struct {
+	Type     string       `json:"type"`
+	Text     *SlackText   `json:"text,omitempty"`
+	Elements []*SlackText `json:"elements,omitempty"`
+}
+
+// SlackMessage is payload of Slack message with blocks. Text is fallback for notifications.
+type SlackMessage struct {
+	Text   string        `json:"text"`
+	Blocks []*SlackBlock `json:"blocks"`
+}
+
+func (x *Renderer) slackText(name string, data interface{}, limit int) (string, error) {
+	var buf bytes.Buffer
+	if err := x.slack.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", golambda.WrapError(err, "Fail to render slack template").With("name", name)
+	}
+	return truncate(strings.TrimSpace(buf.String()), limit), nil
+}
+
+// SlackMessage renders report as Slack Block Kit message. Texts are truncated to Slack limits and sections
+// exceeding max blocks are omitted.
+func (x *Renderer) SlackMessage(report *deepalert.Report) (*SlackMessage, error) {
+	view := NewView(report)
+	limit := x.args.SlackMaxTextLength
+
+	header, err := x.slackText("header", view, SlackMaxHeaderLength)
+	if err != nil {
+		return nil, err
+	}
+	msg := &SlackMessage{
+		Text:   header,
+		Blocks: []*SlackBlock{{Type: "header", Text: &SlackText{Type: "plain_text", Text: header, Emoji: true}}},
+	}
+
+	addSection := func(name string, data interface{}) error {
+		text, err := x.slackText(name, data, limit)
+		if err != nil {
+			return err
+		}
+		if text != "" {
+			msg.Blocks = append(msg.Blocks, &SlackBlock{Type: "section", Text: &SlackText{Type: "mrkdwn", Text: text}})
+		}
+		return nil
+	}
+
+	for _, name := range []string{"verdict", "summary"} {
+		if err := addSection(name, view); err != nil {
+			return nil, err
+		}
+	}
+	if len(view.Alerts) > 0 {
+		if err := addSection("alerts", view); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(view.Sections) > 0 {
+		msg.Blocks = append(msg.Blocks, &SlackBlock{Type: "divider"})
+	}
+	// Keep the last block for notice of omitted sections
+	for i, section := range view.Sections {
+		if len(msg.Blocks) >= x.args.SlackMaxBlocks-1 {
+			view.OmittedSections = len(view.Sections) - i
+			break
+		}
+		if err := addSection("section", section); err != nil {
+			return nil, err
+		}
+	}
+
+	if view.OmittedSections > 0 {
+		text, err := x.slackText("omitted", view, limit)
+		if err != nil {
+			return nil, err
+		}
+		msg.Blocks = append(msg.Blocks, &SlackBlock{Type: "context", Elements: []*SlackText{{Type: "mrkdwn", Text: text}}})
+	}
+
+	return msg, nil
+}
+
+// Slack renders report as JSON of Slack Block Kit message.
+func (x *Renderer) Slack(report *deepalert.Report) ([]byte, error) {
+	msg, err := x.SlackMessage(report)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(msg); err != nil {
+		return nil, golambda.WrapError(err, "Fail to marshal slack message")
+	}
+	return buf.Bytes(), nil
+}
+
+// truncate cuts s to limit bytes at a rune boundary and appends "…" if s is longer than limit.
+func truncate(s string, limit int) string {
+	if limit <= 0 || len(s) <= limit {
+		return s
+	}
+
+	cut := limit - len(truncatedSuffix)
+	if cut < 0 {
+		cut = 0
+	}
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + truncatedSuffix
+}
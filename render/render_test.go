@@ -0,0 +1,229 @@
+package render_test
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/render"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func golden(t *testing.T, name, actual string) {
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.WriteFile(path, []byte(actual), 0644))
+	}
+	expected, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), actual)
+}
+
+func sampleReport() *deepalert.Report {
+	ts := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	return &deepalert.Report{
+		ID:        "f5c2c2a1-7a8f-4a0a-9b1e-3c2a1e9d0b11",
+		Status:    deepalert.StatusPublished,
+		CreatedAt: ts,
+		Result: deepalert.ReportResult{
+			Severity: deepalert.SevUrgent,
+			Level:    deepalert.RiskHigh,
+			Score:    65,
+			Reason:   "Known C2 server | confirmed by <endpoint>",
+		},
+		Reviews: []*deepalert.ReviewResult{
+			{Reviewer: "cloud", Result: &deepalert.ReportResult{Severity: deepalert.SevUnclassified, Reason: "no policy"}},
+			{Reviewer: "endpoint", Result: &deepalert.ReportResult{Severity: deepalert.SevUrgent, Reason: "malware"}},
+			{Reviewer: "identity"},
+		},
+		Alerts: []*deepalert.Alert{
+			{Detector: "guardduty", RuleID: "Backdoor:EC2/C&CActivity.B", RuleName: "C&C activity", Timestamp: ts},
+		},
+		Sections: []*deepalert.Section{
+			{
+				Attr: deepalert.Attribute{
+					Type:    deepalert.TypeIPAddr,
+					Key:     "remote address",
+					Value:   "198.51.100.1",
+					Context: deepalert.AttrContexts{deepalert.CtxRemote},
+				},
+				Hosts:        []*deepalert.ContentHost{{Country: []string{"JP"}}},
+				Geolocations: []*deepalert.ContentGeolocation{{Country: "US"}},
+				ThreatIntel:  []*deepalert.ContentThreatIntel{{Feed: "f1", Verdict: deepalert.VerdictMalicious}},
+				Summary: &deepalert.SectionSummary{
+					Countries: deepalert.SummaryField{
+						Values: []*deepalert.SummaryValue{
+							{Value: "JP", Authors: []string{"whois"}},
+							{Value: "US", Authors: []string{"geoip"}},
+						},
+						Conflict: true,
+					},
+				},
+				Inspections: []*deepalert.InspectionStatus{
+					{Author: "geoip", Status: deepalert.FindingFound},
+					{Author: "passiveDNS", Status: deepalert.FindingFailed, Error: "timeout"},
+				},
+			},
+			{
+				Attr: deepalert.Attribute{
+					Type:  deepalert.TypeHostName,
+					Key:   "instance",
+					Value: "web-1",
+				},
+			},
+		},
+	}
+}
+
+func TestGolden(t *testing.T) {
+	renderer, err := render.New(render.Arguments{})
+	require.NoError(t, err)
+
+	t.Run("markdown", func(t *testing.T) {
+		out, err := renderer.Markdown(sampleReport())
+		require.NoError(t, err)
+		golden(t, "report.md.golden", out)
+	})
+
+	t.Run("html", func(t *testing.T) {
+		out, err := renderer.HTML(sampleReport())
+		require.NoError(t, err)
+		golden(t, "report.html.golden", out)
+	})
+
+	t.Run("slack", func(t *testing.T) {
+		out, err := renderer.Slack(sampleReport())
+		require.NoError(t, err)
+		golden(t, "report.slack.golden", string(out))
+	})
+}
+
+func TestOverrideTemplate(t *testing.T) {
+	renderer, err := render.New(render.Arguments{
+		MarkdownTemplate: `{{define "section"}}- {{.Type}} {{.Value}}{{"\n"}}{{end}}`,
+		HTMLTemplate:     `{{define "alerts"}}<p>{{len .Alerts}} alert(s)</p>{{end}}`,
+		SlackTemplate:    `{{define "header"}}Alert {{.ID}}{{end}}`,
+	})
+	require.NoError(t, err)
+	report := sampleReport()
+
+	md, err := renderer.Markdown(report)
+	require.NoError(t, err)
+	assert.Contains(t, md, "- ipaddr 198.51.100.1\n")
+	assert.NotContains(t, md, "### ipaddr")
+
+	html, err := renderer.HTML(report)
+	require.NoError(t, err)
+	assert.Contains(t, html, "<p>1 alert(s)</p>")
+
+	msg, err := renderer.SlackMessage(report)
+	require.NoError(t, err)
+	assert.Equal(t, "Alert "+string(report.ID), msg.Blocks[0].Text.Text)
+
+	_, err = render.New(render.Arguments{MarkdownTemplate: `{{define "section"}}{{.Broken`})
+	assert.Error(t, err)
+}
+
+func TestMarkdownEscape(t *testing.T) {
+	renderer, err := render.New(render.Arguments{})
+	require.NoError(t, err)
+
+	report := sampleReport()
+	report.ID = "id](https://evil.example.com)"
+	report.Result.Level = "*high*\n# Not a level"
+	report.Result.Reason = "see [details](https://evil.example.com)\n# Not a heading"
+	report.Reviews[0].Result.Reason = "*approved* by <admin>"
+	report.Alerts[0].RuleName = "![x](https://evil.example.com/x.png)"
+	report.Sections[0].Attr.Key = "key_`with`_code"
+	report.Sections[0].Attr.Value = "198.51.100.1`](https://evil.example.com)"
+
+	md, err := renderer.Markdown(report)
+	require.NoError(t, err)
+	assert.NotContains(t, md, " [details](")
+	assert.NotContains(t, md, "| ![x](")
+	assert.NotContains(t, md, "\n# Not a heading")
+	assert.Contains(t, md, `see \[details\](https://evil.example.com) # Not a heading`)
+	assert.Contains(t, md, `- **cloud**: unclassified - \*approved\* by \<admin\>`)
+	assert.Contains(t, md, `| !\[x\](https://evil.example.com/x.png) |`)
+	assert.Contains(t, md, "- Key: key\\_\\`with\\`\\_code\n")
+	assert.Contains(t, md, "### ipaddr: ``198.51.100.1`](https://evil.example.com)``\n")
+	assert.NotContains(t, md, "\n# Not a level")
+	assert.Contains(t, md, "# DeepAlert Report id\\](https://evil.example.com)\n")
+	assert.Contains(t, md, `| Risk | \*high\* # Not a level (65) |`)
+}
+
+func manySections(n int) *deepalert.Report {
+	report := sampleReport()
+	for i := 0; i < n; i++ {
+		report.Sections = append(report.Sections, &deepalert.Section{
+			Attr: deepalert.Attribute{
+				Type:  deepalert.TypeIPAddr,
+				Key:   "dst",
+				Value: fmt.Sprintf("10.0.%d.%d", i/256, i%256),
+			},
+			Hosts: []*deepalert.ContentHost{{}},
+		})
+	}
+	return report
+}
+
+func TestTruncation(t *testing.T) {
+	t.Run("markdown omits sections to fit max length", func(t *testing.T) {
+		renderer, err := render.New(render.Arguments{MaxLength: 2000})
+		require.NoError(t, err)
+		out, err := renderer.Markdown(manySections(100))
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(out), 2000)
+		assert.Contains(t, out, "section(s) are omitted")
+		assert.Contains(t, out, "## Verdict")
+	})
+
+	t.Run("markdown is truncated if it does not fit without sections", func(t *testing.T) {
+		renderer, err := render.New(render.Arguments{MaxLength: 100})
+		require.NoError(t, err)
+		out, err := renderer.Markdown(sampleReport())
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(out), 100)
+		assert.True(t, strings.HasSuffix(out, "…"))
+	})
+
+	t.Run("html omits sections to fit max length", func(t *testing.T) {
+		renderer, err := render.New(render.Arguments{MaxLength: 3000})
+		require.NoError(t, err)
+		out, err := renderer.HTML(manySections(100))
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(out), 3000)
+		assert.True(t, strings.HasSuffix(out, "</html>\n"))
+	})
+
+	t.Run("slack blocks and texts are limited", func(t *testing.T) {
+		renderer, err := render.New(render.Arguments{})
+		require.NoError(t, err)
+		report := manySections(100)
+		report.Result.Reason = strings.Repeat("あ", 2000)
+
+		raw, err := renderer.Slack(report)
+		require.NoError(t, err)
+		var msg render.SlackMessage
+		require.NoError(t, json.Unmarshal(raw, &msg))
+
+		assert.Equal(t, render.SlackMaxBlocks, len(msg.Blocks))
+		last := msg.Blocks[len(msg.Blocks)-1]
+		assert.Equal(t, "context", last.Type)
+		assert.Contains(t, last.Elements[0].Text, "section(s) are omitted")
+		for _, block := range msg.Blocks {
+			if block.Text != nil {
+				assert.LessOrEqual(t, len(block.Text.Text), render.SlackMaxTextLength)
+			}
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cookpad/deepalert"
+)
+
+// View is data given to templates. It's built from deepalert.Report and trimmed to fit size limits.
+type View struct {
+	ID        string
+	Status    string
+	CreatedAt string
+	Verdict   Verdict
+	Reviews   []*ReviewView
+	Summary   FindingsSummary
+	Alerts    []*AlertView
+	Sections  []*SectionView
+
+	// OmittedAlerts and OmittedSections are numbers of alerts and sections dropped by truncation
+	OmittedAlerts   int
+	OmittedSections int
+}
+
+// Verdict is final result of the report.
+type Verdict struct {
+	Severity string
+	Level    string
+	Score    int
+	Reason   string
+}
+
+// ReviewView is a result of one of multiple reviewers.
+type ReviewView struct {
+	Reviewer string
+	Severity string
+	Reason   string
+}
+
+// FindingsSummary is numbers of findings in the report.
+type FindingsSummary struct {
+	Counts            []*Count
+	FailedInspections []string
+}
+
+// Count is number of contents of a type.
+type Count struct {
+	Name string
+	N    int
+}
+
+// AlertView is an alert in the report.
+type AlertView struct {
+	Detector    string
+	RuleID      string
+	RuleName    string
+	Description string
+	Timestamp   string
+}
+
+// SectionView is inspection results of an attribute.
+type SectionView struct {
+	Type     string
+	Key      string
+	Value    string
+	Contexts string
+	Counts   []*Count
+	// Facts are merged values of Section.Summary, e.g. "Countries: JP (geoip)"
+	Facts  []*Fact
+	Failed []string
+}
+
+// Fact is a merged value with its sources.
+type Fact struct {
+	Name     string
+	Values   string
+	Conflict bool
+}
+
+const timeFormat = time.RFC3339
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(timeFormat)
+}
+
+// NewView builds View from report.
+func NewView(report *deepalert.Report) *View {
+	view := &View{
+		ID:        string(report.ID),
+		Status:    string(report.Status),
+		CreatedAt: formatTime(report.CreatedAt),
+		Verdict: Verdict{
+			Severity: string(report.Result.Severity),
+			Level:    string(report.Result.Level),
+			Score:    report.Result.Score,
+			Reason:   report.Result.Reason,
+		},
+	}
+	if view.Verdict.Severity == "" {
+		view.Verdict.Severity = string(deepalert.SevUnclassified)
+	}
+
+	for _, review := range report.Reviews {
+		rv := &ReviewView{Reviewer: review.Reviewer, Severity: "-"}
+		if review.Result != nil {
+			rv.Severity, rv.Reason = string(review.Result.Severity), review.Result.Reason
+		}
+		view.Reviews = append(view.Reviews, rv)
+	}
+
+	for _, alert := range report.Alerts {
+		view.Alerts = append(view.Alerts, &AlertView{
+			Detector:    alert.Detector,
+			RuleID:      alert.RuleID,
+			RuleName:    alert.RuleName,
+			Description: alert.Description,
+			Timestamp:   formatTime(alert.Timestamp),
+		})
+	}
+
+	total := map[string]int{}
+	for _, section := range report.Sections {
+		sv := newSectionView(section)
+		for _, c := range sv.Counts {
+			total[c.Name] += c.N
+		}
+		for _, author := range sv.Failed {
+			view.Summary.FailedInspections = append(view.Summary.FailedInspections,
+				fmt.Sprintf("%s (%s)", author, sv.Value))
+		}
+		view.Sections = append(view.Sections, sv)
+	}
+	for _, name := range contentNames {
+		if total[name] > 0 {
+			view.Summary.Counts = append(view.Summary.Counts, &Count{Name: name, N: total[name]})
+		}
+	}
+
+	return view
+}
+
+var contentNames = []string{
+	"users", "hosts", "binaries", "cloud_resources", "vulnerabilities",
+	"threat_intel", "process_trees", "geolocations", "custom",
+}
+
+func newSectionView(section *deepalert.Section) *SectionView {
+	var contexts []string
+	for _, ctx := range section.Attr.Context {
+		contexts = append(contexts, string(ctx))
+	}
+
+	sv := &SectionView{
+		Type:     string(section.Attr.Type),
+		Key:      section.Attr.Key,
+		Value:    section.Attr.Value,
+		Contexts: strings.Join(contexts, ", "),
+	}
+
+	counts := map[string]int{
+		"users":           len(section.Users),
+		"hosts":           len(section.Hosts),
+		"binaries":        len(section.Binaries),
+		"cloud_resources": len(section.CloudResources),
+		"vulnerabilities": len(section.Vulnerabilities),
+		"threat_intel":    len(section.ThreatIntel),
+		"process_trees":   len(section.ProcessTrees),
+		"geolocations":    len(section.Geolocations),
+		"custom":          len(section.Custom),
+	}
+	for _, name := range contentNames {
+		if counts[name] > 0 {
+			sv.Counts = append(sv.Counts, &Count{Name: name, N: counts[name]})
+		}
+	}
+
+	if s := section.Summary; s != nil {
+		sv.addFact("Countries", &s.Countries)
+		sv.addFact("Owners", &s.Owners)
+		sv.addFact("Hostnames", &s.HostNames)
+		sv.addFact("Malware", &s.Malware)
+	}
+
+	for _, inspection := range section.Inspections {
+		if inspection.Status == deepalert.FindingFailed {
+			sv.Failed = append(sv.Failed, inspection.Author)
+		}
+	}
+	sort.Strings(sv.Failed)
+
+	return sv
+}
+
+func (x *SectionView) addFact(name string, field *deepalert.SummaryField) {
+	if len(field.Values) == 0 {
+		return
+	}
+
+	values := make([]string, len(field.Values))
+	for i, v := range field.Values {
+		values[i] = fmt.Sprintf("%s (%s)", v.Value, strings.Join(v.Authors, ", "))
+	}
+	x.Facts = append(x.Facts, &Fact{Name: name, Values: strings.Join(values, ", "), Conflict: field.Conflict})
+}
+
+// dropLast removes the last section, or the last alert if no section remains. It returns false if nothing
+// can be dropped.
+func (x *View) dropLast() bool {
+	switch {
+	case len(x.Sections) > 0:
+		x.Sections = x.Sections[:len(x.Sections)-1]
+		x.OmittedSections++
+	case len(x.Alerts) > 0:
+		x.Alerts = x.Alerts[:len(x.Alerts)-1]
+		x.OmittedAlerts++
+	default:
+		return false
+	}
+	return true
+}
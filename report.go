@@ -28,11 +28,11 @@ const (
 )
 
 // ReportSeverity has three statuses: "safe", "unclassified", "urgent".
-// - "safe": Reviewer determined the alert has no or minimal risk.
-//           E.g. Win32 malware is detected in a host, but the host's OS is MacOS.
-// - "unclassified": Reviewer has no suitable policy or can not determine risk.
-// - "urgent": The alert has a big impact and a security operator must
-//             respond it immediately.
+//   - "safe": Reviewer determined the alert has no or minimal risk.
+//     E.g. Win32 malware is detected in a host, but the host's OS is MacOS.
+//   - "unclassified": Reviewer has no suitable policy or can not determine risk.
+//   - "urgent": The alert has a big impact and a security operator must
+//     respond it immediately.
 type ReportSeverity string
 
 const (
@@ -45,8 +45,8 @@ const (
 	SevUrgent ReportSeverity = "urgent"
 )
 
-// ReportContentType shows "user", "host" or "binary". It helps to parse
-// Content field in ReportContnet.
+// ReportContentType shows "user", "host", "binary" or a custom type registered by RegisterContentType.
+// It helps to parse Content field in ReportContnet.
 type ReportContentType string
 
 // Report is a container to deliver contents and inspection results of the alert.
@@ -56,16 +56,32 @@ type Report struct {
 	Attributes []*Attribute `json:"attributes"`
 	Sections   []*Section   `json:"sections"`
 	Result     ReportResult `json:"result"`
-	Status     ReportStatus `json:"status"`
-	CreatedAt  time.Time    `json:"created_at"`
+	// Reviews are individual results of multiple reviewers. Result is combined from them by ReviewPolicy.
+	Reviews []*ReviewResult `json:"reviews,omitempty"`
+	// Risk is default risk assessment computed from findings by compileReport.
+	Risk      *RiskAssessment `json:"risk,omitempty"`
+	Status    ReportStatus    `json:"status"`
+	CreatedAt time.Time       `json:"created_at"`
 }
 
-// Section is set of Report content (user, host and binary)
+// Section is set of Report content of built-in types. Contents of custom and unknown types are in Custom.
 type Section struct {
-	Attr     Attribute        `json:"attr"`
-	Users    []*ContentUser   `json:"users,omitempty"`
-	Hosts    []*ContentHost   `json:"hosts,omitempty"`
-	Binaries []*ContentBinary `json:"binaries,omitempty"`
+	Attr            Attribute               `json:"attr"`
+	Users           []*ContentUser          `json:"users,omitempty"`
+	Hosts           []*ContentHost          `json:"hosts,omitempty"`
+	Binaries        []*ContentBinary        `json:"binaries,omitempty"`
+	CloudResources  []*ContentCloudResource `json:"cloud_resources,omitempty"`
+	Vulnerabilities []*ContentVulnerability `json:"vulnerabilities,omitempty"`
+	ThreatIntel     []*ContentThreatIntel   `json:"threat_intel,omitempty"`
+	ProcessTrees    []*ContentProcessTree   `json:"process_trees,omitempty"`
+	Geolocations    []*ContentGeolocation   `json:"geolocations,omitempty"`
+	Custom          []*SectionContent       `json:"custom,omitempty"`
+	// Summary is merged view of contents above. It's set when the report is compiled.
+	Summary *SectionSummary `json:"summary,omitempty"`
+	// Provenances has Provenance of each content above that was given by inspector.
+	Provenances []*ContentProvenance `json:"provenances,omitempty"`
+	// Inspections has status of each inspector for the attribute, including inspectors that found nothing or failed.
+	Inspections []*InspectionStatus `json:"inspections,omitempty"`
 }
 
 // Finding is a result of inspector. a Finding has one Content and metadata.
@@ -75,6 +91,12 @@ type Finding struct {
 	Attribute Attribute         `json:"attribute"`
 	Type      ReportContentType `json:"type"`
 	Content   interface{}       `json:"content"`
+	// Status is empty or FindingFound if Content is set. FindingNotFound and FindingFailed have no Content.
+	Status FindingStatus `json:"status,omitempty"`
+	// Error is reason of FindingFailed
+	Error string `json:"error,omitempty"`
+	// Provenance is nil if the inspector gave no provenance of the finding.
+	Provenance *Provenance `json:"provenance,omitempty"`
 }
 
 const (
@@ -84,12 +106,25 @@ const (
 	ContentTypeHost ReportContentType = "host"
 	// ContentTypeBinary means Content field is ContentBinary.
 	ContentTypeBinary ReportContentType = "binary"
+	// ContentTypeCloudResource means Content field is ContentCloudResource.
+	ContentTypeCloudResource ReportContentType = "cloud_resource"
+	// ContentTypeVulnerability means Content field is ContentVulnerability.
+	ContentTypeVulnerability ReportContentType = "vulnerability"
+	// ContentTypeThreatIntel means Content field is ContentThreatIntel.
+	ContentTypeThreatIntel ReportContentType = "threat_intel"
+	// ContentTypeProcessTree means Content field is ContentProcessTree.
+	ContentTypeProcessTree ReportContentType = "process_tree"
+	// ContentTypeGeolocation means Content field is ContentGeolocation.
+	ContentTypeGeolocation ReportContentType = "geolocation"
 )
 
 // ReportResult shows output of Reviewer invoked to evaluate risk of the alert.
 type ReportResult struct {
 	Severity ReportSeverity `json:"severity"`
 	Reason   string         `json:"reason"`
+	// Score and Level are optional. If only Level is set, Severity is mapped from Level.
+	Score int       `json:"score,omitempty"`
+	Level RiskLevel `json:"level,omitempty"`
 }
 
 // IsNew returns status of the report
@@ -157,6 +192,110 @@ func (x *ContentHost) Type() ReportContentType {
 	return ContentTypeHost
 }
 
+// ContentCloudResource describes a cloud resource such as an instance, a bucket or an IAM role.
+type ContentCloudResource struct {
+	Provider     string            `json:"provider,omitempty"`
+	AccountID    string            `json:"account_id,omitempty"`
+	Region       string            `json:"region,omitempty"`
+	ResourceType string            `json:"resource_type,omitempty"`
+	ResourceID   string            `json:"resource_id,omitempty"`
+	Name         string            `json:"name,omitempty"`
+	Tags         map[string]string `json:"tags,omitempty"`
+	OwnerTeam    string            `json:"owner_team,omitempty"`
+}
+
+// Type of ContentCloudResource returns ContentTypeCloudResource always
+func (x *ContentCloudResource) Type() ReportContentType {
+	return ContentTypeCloudResource
+}
+
+// ContentVulnerability describes a vulnerability found in the entity.
+type ContentVulnerability struct {
+	CVE         string         `json:"cve,omitempty"`
+	Title       string         `json:"title,omitempty"`
+	CVSS        *EntityCVSS    `json:"cvss,omitempty"`
+	Package     *EntityPackage `json:"package,omitempty"`
+	References  []string       `json:"references,omitempty"`
+	Exploited   bool           `json:"exploited,omitempty"`
+	PublishedAt *time.Time     `json:"published_at,omitempty"`
+}
+
+// Type of ContentVulnerability returns ContentTypeVulnerability always
+func (x *ContentVulnerability) Type() ReportContentType {
+	return ContentTypeVulnerability
+}
+
+// ThreatVerdict is a verdict of threat intelligence.
+type ThreatVerdict string
+
+const (
+	// VerdictMalicious means the entity is known as malicious
+	VerdictMalicious ThreatVerdict = "malicious"
+	// VerdictSuspicious means the entity is possibly malicious
+	VerdictSuspicious ThreatVerdict = "suspicious"
+	// VerdictBenign means the entity is known as benign
+	VerdictBenign ThreatVerdict = "benign"
+	// VerdictUnknown means the feed has no knowledge of the entity
+	VerdictUnknown ThreatVerdict = "unknown"
+)
+
+// ContentThreatIntel describes a verdict of a threat intelligence feed.
+type ContentThreatIntel struct {
+	Feed    string        `json:"feed"`
+	Verdict ThreatVerdict `json:"verdict"`
+	// Confidence is 0 to 100
+	Confidence int        `json:"confidence,omitempty"`
+	FirstSeen  *time.Time `json:"first_seen,omitempty"`
+	LastSeen   *time.Time `json:"last_seen,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	Reference  string     `json:"reference,omitempty"`
+}
+
+// Type of ContentThreatIntel returns ContentTypeThreatIntel always
+func (x *ContentThreatIntel) Type() ReportContentType {
+	return ContentTypeThreatIntel
+}
+
+// ContentProcessTree describes processes on a host. Parent-child relation is given by PID and ParentPID.
+type ContentProcessTree struct {
+	HostName  string          `json:"hostname,omitempty"`
+	Processes []EntityProcess `json:"processes"`
+}
+
+// Type of ContentProcessTree returns ContentTypeProcessTree always
+func (x *ContentProcessTree) Type() ReportContentType {
+	return ContentTypeProcessTree
+}
+
+// Children returns child processes of pid.
+func (x *ContentProcessTree) Children(pid int) []EntityProcess {
+	var children []EntityProcess
+	for _, p := range x.Processes {
+		if p.ParentPID == pid && p.PID != pid {
+			children = append(children, p)
+		}
+	}
+	return children
+}
+
+// ContentGeolocation describes geolocation of the entity, mainly IP address.
+type ContentGeolocation struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	// AccuracyRadius is in kilometers
+	AccuracyRadius float64 `json:"accuracy_radius,omitempty"`
+	Country        string  `json:"country,omitempty"`
+	Region         string  `json:"region,omitempty"`
+	City           string  `json:"city,omitempty"`
+	ASN            string  `json:"asn,omitempty"`
+	Source         string  `json:"source,omitempty"`
+}
+
+// Type of ContentGeolocation returns ContentTypeGeolocation always
+func (x *ContentGeolocation) Type() ReportContentType {
+	return ContentTypeGeolocation
+}
+
 // -----------------------------------------------
 // Entity Objects
 
@@ -208,6 +347,33 @@ type EntitySoftware struct {
 	LastSeen time.Time `json:"last_seen"`
 }
 
+// EntityCVSS shows CVSS score of a vulnerability.
+type EntityCVSS struct {
+	Version string  `json:"version,omitempty"`
+	Score   float64 `json:"score"`
+	Vector  string  `json:"vector,omitempty"`
+}
+
+// EntityPackage shows a software package affected by a vulnerability.
+type EntityPackage struct {
+	Name         string `json:"name"`
+	Version      string `json:"version,omitempty"`
+	FixedVersion string `json:"fixed_version,omitempty"`
+	Ecosystem    string `json:"ecosystem,omitempty"`
+}
+
+// EntityProcess shows a process in a process tree.
+type EntityProcess struct {
+	PID         int        `json:"pid"`
+	ParentPID   int        `json:"parent_pid,omitempty"`
+	Name        string     `json:"name"`
+	CommandLine string     `json:"command_line,omitempty"`
+	Path        string     `json:"path,omitempty"`
+	User        string     `json:"user,omitempty"`
+	SHA256      string     `json:"sha256,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+}
+
 // ReportAttribute has attribute(S) that are found newly by inspector.
 type ReportAttribute struct {
 	ReportID   ReportID     `json:"report_id"`
@@ -0,0 +1,141 @@
+package deepalert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/m-mizutani/golambda"
+)
+
+// ReviewResult is a result of one of multiple reviewers. Result is nil if the reviewer returned no result.
+type ReviewResult struct {
+	Reviewer string        `json:"reviewer"`
+	Result   *ReportResult `json:"result"`
+}
+
+// ReviewPolicy is policy to combine results of multiple reviewers into one ReportResult.
+type ReviewPolicy string
+
+const (
+	// PolicyMaxSeverity adopts the most severe result (urgent > unclassified > safe). It's default policy.
+	PolicyMaxSeverity ReviewPolicy = "max_severity"
+	// PolicyFirstDecisive adopts the first result of safe or urgent in order of reviewers.
+	PolicyFirstDecisive ReviewPolicy = "first_decisive"
+	// PolicyMajority adopts severity that most reviewers returned. Tie is broken by more severe one.
+	PolicyMajority ReviewPolicy = "majority"
+)
+
+// ErrInvalidReviewPolicy means the policy is not one of PolicyMaxSeverity, PolicyFirstDecisive and PolicyMajority.
+var ErrInvalidReviewPolicy = golambda.NewError("Invalid review policy")
+
+// ParseReviewPolicy returns ReviewPolicy of s. Empty s means PolicyMaxSeverity.
+func ParseReviewPolicy(s string) (ReviewPolicy, error) {
+	switch policy := ReviewPolicy(s); policy {
+	case "":
+		return PolicyMaxSeverity, nil
+	case PolicyMaxSeverity, PolicyFirstDecisive, PolicyMajority:
+		return policy, nil
+	default:
+		return "", golambda.WrapError(ErrInvalidReviewPolicy, s)
+	}
+}
+
+func severityRank(sev ReportSeverity) int {
+	switch sev {
+	case SevSafe:
+		return 0
+	case SevUrgent:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// normalizedResult returns copy of result with Severity mapped from Level if Severity is not set.
+func normalizedResult(review *ReviewResult) *ReportResult {
+	if review.Result == nil {
+		return nil
+	}
+	result := *review.Result
+	if result.Severity == "" {
+		result.Severity = result.Level.Severity()
+	}
+	return &result
+}
+
+// CombineReviews combines results of reviewers by policy. Reviewers that returned no result are ignored, and
+// SevUnclassified is returned if no reviewer returned result.
+func CombineReviews(policy ReviewPolicy, reviews []*ReviewResult) ReportResult {
+	type vote struct {
+		reviewer string
+		result   *ReportResult
+	}
+	var votes []vote
+	for _, review := range reviews {
+		if result := normalizedResult(review); result != nil {
+			votes = append(votes, vote{reviewer: review.Reviewer, result: result})
+		}
+	}
+
+	if len(votes) == 0 {
+		return ReportResult{Severity: SevUnclassified, Reason: "No reviewer returned result"}
+	}
+
+	var adopted *vote
+	reason := func(v *vote) string {
+		return fmt.Sprintf("%s: %s", v.reviewer, v.result.Reason)
+	}
+
+	switch policy {
+	case PolicyFirstDecisive:
+		for i := range votes {
+			if sev := votes[i].result.Severity; sev == SevSafe || sev == SevUrgent {
+				adopted = &votes[i]
+				break
+			}
+		}
+		if adopted == nil {
+			return ReportResult{Severity: SevUnclassified, Reason: "No reviewer was decisive"}
+		}
+
+	case PolicyMajority:
+		counts := map[ReportSeverity]int{}
+		for _, v := range votes {
+			counts[v.result.Severity]++
+		}
+		// Iterate votes, not counts, so that tie of severities with the same rank is broken by order of reviewers
+		var winner ReportSeverity
+		for _, v := range votes {
+			sev, n := v.result.Severity, counts[v.result.Severity]
+			if winner == "" || n > counts[winner] || (n == counts[winner] && severityRank(sev) > severityRank(winner)) {
+				winner = sev
+			}
+		}
+		var voters []string
+		for i := range votes {
+			if votes[i].result.Severity == winner {
+				if adopted == nil {
+					adopted = &votes[i]
+				}
+				voters = append(voters, votes[i].reviewer)
+			}
+		}
+		result := *adopted.result
+		result.Reason = fmt.Sprintf("%d of %d reviewers (%s) voted %s. %s",
+			len(voters), len(votes), strings.Join(voters, ", "), winner, reason(adopted))
+		return result
+
+	default: // PolicyMaxSeverity
+		for i := range votes {
+			v := votes[i].result
+			if adopted == nil || severityRank(v.Severity) > severityRank(adopted.result.Severity) ||
+				(v.Severity == adopted.result.Severity && v.Score > adopted.result.Score) {
+				adopted = &votes[i]
+			}
+		}
+	}
+
+	result := *adopted.result
+	result.Reason = reason(adopted)
+	return result
+}
@@ -0,0 +1,90 @@
+package deepalert_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	da "github.com/cookpad/deepalert"
+)
+
+func review(name string, sev da.ReportSeverity, reason string) *da.ReviewResult {
+	return &da.ReviewResult{Reviewer: name, Result: &da.ReportResult{Severity: sev, Reason: reason}}
+}
+
+func TestParseReviewPolicy(t *testing.T) {
+	policy, err := da.ParseReviewPolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, da.PolicyMaxSeverity, policy)
+
+	policy, err = da.ParseReviewPolicy("majority")
+	require.NoError(t, err)
+	assert.Equal(t, da.PolicyMajority, policy)
+
+	_, err = da.ParseReviewPolicy("minority")
+	assert.ErrorIs(t, err, da.ErrInvalidReviewPolicy)
+}
+
+func TestCombineReviews(t *testing.T) {
+	reviews := []*da.ReviewResult{
+		review("cloud", da.SevSafe, "known instance"),
+		{Reviewer: "nobody", Result: nil},
+		review("identity", da.SevUnclassified, "no policy"),
+		review("endpoint", da.SevUrgent, "malware"),
+		review("network", da.SevSafe, "internal traffic"),
+	}
+
+	t.Run("max severity", func(t *testing.T) {
+		result := da.CombineReviews(da.PolicyMaxSeverity, reviews)
+		assert.Equal(t, da.SevUrgent, result.Severity)
+		assert.Equal(t, "endpoint: malware", result.Reason)
+	})
+
+	t.Run("max severity prefers higher score in the same severity", func(t *testing.T) {
+		result := da.CombineReviews(da.PolicyMaxSeverity, []*da.ReviewResult{
+			{Reviewer: "a", Result: &da.ReportResult{Level: da.RiskHigh, Score: 60}},
+			{Reviewer: "b", Result: &da.ReportResult{Level: da.RiskCritical, Score: 90}},
+		})
+		assert.Equal(t, da.SevUrgent, result.Severity)
+		assert.Equal(t, 90, result.Score)
+	})
+
+	t.Run("first decisive", func(t *testing.T) {
+		result := da.CombineReviews(da.PolicyFirstDecisive, reviews)
+		assert.Equal(t, da.SevSafe, result.Severity)
+		assert.Equal(t, "cloud: known instance", result.Reason)
+
+		result = da.CombineReviews(da.PolicyFirstDecisive, reviews[1:3])
+		assert.Equal(t, da.SevUnclassified, result.Severity)
+	})
+
+	t.Run("majority", func(t *testing.T) {
+		result := da.CombineReviews(da.PolicyMajority, reviews)
+		assert.Equal(t, da.SevSafe, result.Severity)
+		assert.Contains(t, result.Reason, "2 of 4 reviewers (cloud, network)")
+	})
+
+	t.Run("majority tie is broken by severity", func(t *testing.T) {
+		result := da.CombineReviews(da.PolicyMajority, []*da.ReviewResult{
+			review("a", da.SevSafe, ""),
+			review("b", da.SevUrgent, ""),
+		})
+		assert.Equal(t, da.SevUrgent, result.Severity)
+	})
+
+	t.Run("majority tie of the same rank is broken by order of reviewers", func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			result := da.CombineReviews(da.PolicyMajority, []*da.ReviewResult{
+				review("a", "custom", ""),
+				review("b", da.SevUnclassified, ""),
+			})
+			require.Equal(t, da.ReportSeverity("custom"), result.Severity)
+		}
+	})
+
+	t.Run("no result", func(t *testing.T) {
+		result := da.CombineReviews(da.PolicyMajority, []*da.ReviewResult{{Reviewer: "a"}})
+		assert.Equal(t, da.SevUnclassified, result.Severity)
+	})
+}
@@ -0,0 +1,126 @@
+package deepalert
+
+// RiskLevel is finer-grained scale of risk than ReportSeverity. It's mapped to ReportSeverity by Severity().
+type RiskLevel string
+
+const (
+	// RiskInfo : score 0-19, mapped to SevSafe
+	RiskInfo RiskLevel = "info"
+	// RiskLow : score 20-39, mapped to SevUnclassified
+	RiskLow RiskLevel = "low"
+	// RiskMedium : score 40-59, mapped to SevUnclassified
+	RiskMedium RiskLevel = "medium"
+	// RiskHigh : score 60-79, mapped to SevUrgent
+	RiskHigh RiskLevel = "high"
+	// RiskCritical : score 80-100, mapped to SevUrgent
+	RiskCritical RiskLevel = "critical"
+)
+
+const (
+	// MinRiskScore is the lowest risk score
+	MinRiskScore = 0
+	// MaxRiskScore is the highest risk score
+	MaxRiskScore = 100
+)
+
+// RiskLevelOf returns RiskLevel of score. Score out of range is clamped.
+func RiskLevelOf(score int) RiskLevel {
+	switch score = clampRiskScore(score); {
+	case score >= 80:
+		return RiskCritical
+	case score >= 60:
+		return RiskHigh
+	case score >= 40:
+		return RiskMedium
+	case score >= 20:
+		return RiskLow
+	default:
+		return RiskInfo
+	}
+}
+
+// Severity returns ReportSeverity for compatibility. Unknown level is SevUnclassified.
+func (x RiskLevel) Severity() ReportSeverity {
+	switch x {
+	case RiskInfo:
+		return SevSafe
+	case RiskHigh, RiskCritical:
+		return SevUrgent
+	default:
+		return SevUnclassified
+	}
+}
+
+func clampRiskScore(score int) int {
+	if score < MinRiskScore {
+		return MinRiskScore
+	}
+	if score > MaxRiskScore {
+		return MaxRiskScore
+	}
+	return score
+}
+
+// RiskFactor is a reason of risk score. Score is added to total score of RiskAssessment.
+type RiskFactor struct {
+	Name   string `json:"name"`
+	Score  int    `json:"score"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// RiskAssessment is risk score computed from findings. compileReport sets a default assessment to Report.Risk
+// and Reviewer can adjust it.
+type RiskAssessment struct {
+	Score   int           `json:"score"`
+	Level   RiskLevel     `json:"level"`
+	Factors []*RiskFactor `json:"factors,omitempty"`
+}
+
+// NewRiskAssessment returns RiskAssessment with total score of factors.
+func NewRiskAssessment(factors ...*RiskFactor) *RiskAssessment {
+	assessment := &RiskAssessment{}
+	for _, factor := range factors {
+		assessment.Add(factor)
+	}
+	assessment.update()
+	return assessment
+}
+
+// RiskFactorAdjustment is name of RiskFactor added by Adjust.
+const RiskFactorAdjustment = "adjustment"
+
+// update clamps total score of factors from findings first and then applies adjustments, so that a reviewer can
+// lower a score that is saturated by many factors.
+func (x *RiskAssessment) update() {
+	base, adjustment := 0, 0
+	for _, factor := range x.Factors {
+		if factor.Name == RiskFactorAdjustment {
+			adjustment += factor.Score
+		} else {
+			base += factor.Score
+		}
+	}
+	x.Score = clampRiskScore(clampRiskScore(base) + adjustment)
+	x.Level = RiskLevelOf(x.Score)
+}
+
+// Add appends factor and updates Score and Level.
+func (x *RiskAssessment) Add(factor *RiskFactor) {
+	x.Factors = append(x.Factors, factor)
+	x.update()
+}
+
+// Adjust adds or subtracts score by Reviewer with reason.
+func (x *RiskAssessment) Adjust(delta int, reason string) {
+	x.Add(&RiskFactor{Name: RiskFactorAdjustment, Score: delta, Reason: reason})
+}
+
+// Result returns ReportResult with score, level and severity mapped from the level.
+func (x *RiskAssessment) Result(reason string) *ReportResult {
+	return &ReportResult{
+		Severity: x.Level.Severity(),
+		Score:    x.Score,
+		Level:    x.Level,
+		Reason:   reason,
+	}
+}
@@ -0,0 +1,174 @@
+// Package risk provides scoring engine to compute default risk score of a report from findings.
+package risk
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cookpad/deepalert"
+)
+
+// Rule computes risk factors from a report. Rule should return nil if the report has nothing to score.
+type Rule func(report *deepalert.Report) []*deepalert.RiskFactor
+
+// Engine computes RiskAssessment by rules.
+type Engine struct {
+	rules []Rule
+}
+
+// NewEngine returns Engine with rules. If no rule is given, DefaultRules are used.
+func NewEngine(rules ...Rule) *Engine {
+	if len(rules) == 0 {
+		rules = DefaultRules()
+	}
+	return &Engine{rules: rules}
+}
+
+// Assess returns RiskAssessment of the report. Total score is clamped to 0-100.
+func (x *Engine) Assess(report *deepalert.Report) *deepalert.RiskAssessment {
+	var factors []*deepalert.RiskFactor
+	for _, rule := range x.rules {
+		factors = append(factors, rule(report)...)
+	}
+	return deepalert.NewRiskAssessment(factors...)
+}
+
+// DefaultRules returns rules of malware scan, threat intel, vulnerability and asset criticality.
+func DefaultRules() []Rule {
+	return []Rule{
+		MalwareRule,
+		ThreatIntelRule,
+		VulnerabilityRule,
+		AssetCriticalityRule(DefaultCriticalityTag),
+	}
+}
+
+// MalwareRule scores 30 for a positive malware scan and 5 for each additional one, up to 50.
+func MalwareRule(report *deepalert.Report) []*deepalert.RiskFactor {
+	positives := 0
+	count := func(entities []deepalert.EntityMalware) {
+		for _, m := range entities {
+			for _, scan := range m.Scans {
+				if scan.Positive {
+					positives++
+				}
+			}
+		}
+	}
+
+	for _, section := range report.Sections {
+		for _, host := range section.Hosts {
+			count(host.RelatedMalware)
+		}
+		for _, binary := range section.Binaries {
+			count(binary.RelatedMalware)
+		}
+	}
+
+	if positives == 0 {
+		return nil
+	}
+
+	score := 30 + 5*(positives-1)
+	if score > 50 {
+		score = 50
+	}
+	return []*deepalert.RiskFactor{{
+		Name:   "malware",
+		Score:  score,
+		Reason: fmt.Sprintf("%d positive malware scan(s)", positives),
+	}}
+}
+
+// ThreatIntelRule scores half of confidence for malicious verdict and quarter for suspicious verdict.
+// Only the highest verdict is scored.
+func ThreatIntelRule(report *deepalert.Report) []*deepalert.RiskFactor {
+	var top *deepalert.ContentThreatIntel
+	topScore := 0
+	for _, section := range report.Sections {
+		for _, intel := range section.ThreatIntel {
+			score := 0
+			switch intel.Verdict {
+			case deepalert.VerdictMalicious:
+				score = intel.Confidence / 2
+			case deepalert.VerdictSuspicious:
+				score = intel.Confidence / 4
+			}
+			if score > topScore {
+				top, topScore = intel, score
+			}
+		}
+	}
+
+	if top == nil {
+		return nil
+	}
+	return []*deepalert.RiskFactor{{
+		Name:   "threat_intel",
+		Score:  topScore,
+		Reason: fmt.Sprintf("%s verdict by %s with confidence %d", top.Verdict, top.Feed, top.Confidence),
+	}}
+}
+
+// VulnerabilityRule scores 3 times of the highest CVSS score, and 10 more if it's known to be exploited.
+func VulnerabilityRule(report *deepalert.Report) []*deepalert.RiskFactor {
+	var top *deepalert.ContentVulnerability
+	topScore := 0
+	for _, section := range report.Sections {
+		for _, vuln := range section.Vulnerabilities {
+			score := 0
+			if vuln.CVSS != nil {
+				score = int(vuln.CVSS.Score * 3)
+			}
+			if vuln.Exploited {
+				score += 10
+			}
+			if score > topScore {
+				top, topScore = vuln, score
+			}
+		}
+	}
+
+	if top == nil {
+		return nil
+	}
+	return []*deepalert.RiskFactor{{
+		Name:   "vulnerability",
+		Score:  topScore,
+		Reason: fmt.Sprintf("%s is found", top.CVE),
+	}}
+}
+
+// DefaultCriticalityTag is tag key of cloud resources to show asset criticality.
+const DefaultCriticalityTag = "criticality"
+
+var criticalityScores = map[string]int{
+	"critical": 20,
+	"high":     10,
+	"medium":   5,
+}
+
+// AssetCriticalityRule scores by criticality tag (critical, high or medium) of cloud resources. Only the
+// highest criticality is scored.
+func AssetCriticalityRule(tagKey string) Rule {
+	return func(report *deepalert.Report) []*deepalert.RiskFactor {
+		topScore, topValue := 0, ""
+		for _, section := range report.Sections {
+			for _, resource := range section.CloudResources {
+				value := strings.ToLower(resource.Tags[tagKey])
+				if score := criticalityScores[value]; score > topScore {
+					topScore, topValue = score, value
+				}
+			}
+		}
+
+		if topScore == 0 {
+			return nil
+		}
+		return []*deepalert.RiskFactor{{
+			Name:   "asset_criticality",
+			Score:  topScore,
+			Reason: fmt.Sprintf("%s asset is involved", topValue),
+		}}
+	}
+}
@@ -0,0 +1,79 @@
+package risk_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cookpad/deepalert"
+	"github.com/cookpad/deepalert/risk"
+)
+
+func TestEngine(t *testing.T) {
+	t.Run("no finding is info", func(t *testing.T) {
+		assessment := risk.NewEngine().Assess(&deepalert.Report{})
+		assert.Equal(t, 0, assessment.Score)
+		assert.Equal(t, deepalert.RiskInfo, assessment.Level)
+		assert.Equal(t, 0, len(assessment.Factors))
+	})
+
+	t.Run("default rules", func(t *testing.T) {
+		report := &deepalert.Report{
+			Sections: []*deepalert.Section{
+				{
+					Binaries: []*deepalert.ContentBinary{{
+						RelatedMalware: []deepalert.EntityMalware{{Scans: []deepalert.EntityMalwareScan{
+							{Vendor: "v1", Positive: true},
+							{Vendor: "v2", Positive: true},
+							{Vendor: "v3", Positive: false},
+						}}},
+					}},
+					ThreatIntel: []*deepalert.ContentThreatIntel{
+						{Feed: "f1", Verdict: deepalert.VerdictSuspicious, Confidence: 100},
+						{Feed: "f2", Verdict: deepalert.VerdictMalicious, Confidence: 60},
+						{Feed: "f3", Verdict: deepalert.VerdictBenign, Confidence: 100},
+					},
+				},
+				{
+					Vulnerabilities: []*deepalert.ContentVulnerability{
+						{CVE: "CVE-1", CVSS: &deepalert.EntityCVSS{Score: 5.0}},
+					},
+					CloudResources: []*deepalert.ContentCloudResource{
+						{Tags: map[string]string{"criticality": "High"}},
+					},
+				},
+			},
+		}
+
+		assessment := risk.NewEngine().Assess(report)
+		factors := map[string]int{}
+		for _, f := range assessment.Factors {
+			factors[f.Name] = f.Score
+		}
+		assert.Equal(t, map[string]int{
+			"malware":           35,
+			"threat_intel":      30,
+			"vulnerability":     15,
+			"asset_criticality": 10,
+		}, factors)
+		assert.Equal(t, 90, assessment.Score)
+		assert.Equal(t, deepalert.RiskCritical, assessment.Level)
+	})
+
+	t.Run("custom rules", func(t *testing.T) {
+		engine := risk.NewEngine(func(report *deepalert.Report) []*deepalert.RiskFactor {
+			return []*deepalert.RiskFactor{{Name: "alerts", Score: 25 * len(report.Alerts)}}
+		}, risk.AssetCriticalityRule("tier"))
+
+		assessment := engine.Assess(&deepalert.Report{
+			Alerts: []*deepalert.Alert{{}, {}},
+			Sections: []*deepalert.Section{{
+				CloudResources: []*deepalert.ContentCloudResource{{Tags: map[string]string{"tier": "critical"}}},
+			}},
+		})
+		require.Equal(t, 2, len(assessment.Factors))
+		assert.Equal(t, 70, assessment.Score)
+		assert.Equal(t, deepalert.RiskHigh, assessment.Level)
+	})
+}
@@ -0,0 +1,71 @@
+package deepalert_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	da "github.com/cookpad/deepalert"
+)
+
+func TestRiskLevel(t *testing.T) {
+	testCases := []struct {
+		score    int
+		level    da.RiskLevel
+		severity da.ReportSeverity
+	}{
+		{-10, da.RiskInfo, da.SevSafe},
+		{0, da.RiskInfo, da.SevSafe},
+		{19, da.RiskInfo, da.SevSafe},
+		{20, da.RiskLow, da.SevUnclassified},
+		{40, da.RiskMedium, da.SevUnclassified},
+		{60, da.RiskHigh, da.SevUrgent},
+		{80, da.RiskCritical, da.SevUrgent},
+		{150, da.RiskCritical, da.SevUrgent},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.level, da.RiskLevelOf(tc.score), "score %d", tc.score)
+		assert.Equal(t, tc.severity, da.RiskLevelOf(tc.score).Severity(), "score %d", tc.score)
+	}
+	assert.Equal(t, da.SevUnclassified, da.RiskLevel("unknown").Severity())
+}
+
+func TestRiskAssessment(t *testing.T) {
+	assessment := da.NewRiskAssessment(
+		&da.RiskFactor{Name: "malware", Score: 30},
+		&da.RiskFactor{Name: "threat_intel", Score: 40},
+	)
+	assert.Equal(t, 70, assessment.Score)
+	assert.Equal(t, da.RiskHigh, assessment.Level)
+
+	assessment.Adjust(-60, "known test host")
+	assert.Equal(t, 10, assessment.Score)
+	assert.Equal(t, da.RiskInfo, assessment.Level)
+	assert.Equal(t, 3, len(assessment.Factors))
+
+	result := assessment.Result("test host")
+	assert.Equal(t, da.SevSafe, result.Severity)
+	assert.Equal(t, 10, result.Score)
+	assert.Equal(t, da.RiskInfo, result.Level)
+	assert.Equal(t, "test host", result.Reason)
+}
+
+func TestRiskAssessmentAdjustSaturatedScore(t *testing.T) {
+	assessment := da.NewRiskAssessment(
+		&da.RiskFactor{Name: "malware", Score: 60},
+		&da.RiskFactor{Name: "threat_intel", Score: 50},
+		&da.RiskFactor{Name: "vulnerability", Score: 30},
+	)
+	assert.Equal(t, 100, assessment.Score)
+	assert.Equal(t, da.RiskCritical, assessment.Level)
+
+	assessment.Adjust(-40, "false positive")
+	assert.Equal(t, 60, assessment.Score)
+	assert.Equal(t, da.RiskLevelOf(60), assessment.Level)
+	assert.NotEqual(t, da.RiskCritical, assessment.Level)
+
+	assessment.Adjust(-100, "test host")
+	assert.Equal(t, 0, assessment.Score)
+	assert.Equal(t, da.RiskInfo, assessment.Level)
+}
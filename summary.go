@@ -0,0 +1,140 @@
+package deepalert
+
+import (
+	"sort"
+	"strings"
+)
+
+// SectionSummary is merged view of findings about one attribute. It answers common questions such as
+// "what country is this IP in?" without looping over all contents of Section.
+type SectionSummary struct {
+	Countries SummaryField `json:"countries"`
+	Owners    SummaryField `json:"owners"`
+	HostNames SummaryField `json:"hostnames"`
+	Malware   SummaryField `json:"malware"`
+}
+
+// SummaryField is union of values reported by inspectors.
+type SummaryField struct {
+	Values []*SummaryValue `json:"values,omitempty"`
+	// Conflict is true if inspectors report different values for a field that should have only one value.
+	Conflict bool `json:"conflict,omitempty"`
+}
+
+// SummaryValue is a value and authors (inspectors) that reported the value.
+type SummaryValue struct {
+	Value   string   `json:"value"`
+	Authors []string `json:"authors"`
+}
+
+// Strings returns only values of the field.
+func (x *SummaryField) Strings() []string {
+	values := make([]string, len(x.Values))
+	for i, v := range x.Values {
+		values[i] = v.Value
+	}
+	return values
+}
+
+// IsEmpty returns true if the summary has no value.
+func (x *SectionSummary) IsEmpty() bool {
+	return len(x.Countries.Values) == 0 && len(x.Owners.Values) == 0 &&
+		len(x.HostNames.Values) == 0 && len(x.Malware.Values) == 0
+}
+
+type summaryBuilder struct {
+	values map[string]map[string]struct{}
+	keys   map[string]string
+}
+
+func newSummaryBuilder() *summaryBuilder {
+	return &summaryBuilder{
+		values: map[string]map[string]struct{}{},
+		keys:   map[string]string{},
+	}
+}
+
+// add ignores empty value and compares values case insensitively. The first notation is kept.
+func (x *summaryBuilder) add(value, author string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+
+	key := strings.ToLower(value)
+	if _, ok := x.keys[key]; !ok {
+		x.keys[key] = value
+		x.values[key] = map[string]struct{}{}
+	}
+	x.values[key][author] = struct{}{}
+}
+
+func (x *summaryBuilder) build(single bool) SummaryField {
+	var field SummaryField
+	for key, authorSet := range x.values {
+		authors := make([]string, 0, len(authorSet))
+		for author := range authorSet {
+			authors = append(authors, author)
+		}
+		sort.Strings(authors)
+		field.Values = append(field.Values, &SummaryValue{Value: x.keys[key], Authors: authors})
+	}
+	sort.Slice(field.Values, func(i, j int) bool {
+		return field.Values[i].Value < field.Values[j].Value
+	})
+
+	field.Conflict = single && len(field.Values) > 1
+	return field
+}
+
+// SummarizeFindings merges contents of findings into SectionSummary. Findings should be about the same
+// attribute. Country and owner are expected to be unique, then multiple values are flagged as conflict.
+func SummarizeFindings(findings []*Finding) *SectionSummary {
+	countries, owners, hostnames, malware := newSummaryBuilder(), newSummaryBuilder(), newSummaryBuilder(), newSummaryBuilder()
+
+	addMalware := func(entities []EntityMalware, author string) {
+		for _, m := range entities {
+			for _, scan := range m.Scans {
+				if scan.Positive {
+					malware.add(scan.Name, author)
+				}
+			}
+		}
+	}
+
+	for _, finding := range findings {
+		author := finding.Author
+
+		if c, ok := finding.AsHost(); ok {
+			for _, v := range c.Country {
+				countries.add(v, author)
+			}
+			for _, v := range c.Owner {
+				owners.add(v, author)
+			}
+			for _, v := range c.HostName {
+				hostnames.add(v, author)
+			}
+			addMalware(c.RelatedMalware, author)
+		}
+		if c, ok := finding.AsBinary(); ok {
+			addMalware(c.RelatedMalware, author)
+		}
+		if c, ok := finding.AsGeolocation(); ok {
+			countries.add(c.Country, author)
+		}
+		if c, ok := finding.AsCloudResource(); ok {
+			owners.add(c.OwnerTeam, author)
+		}
+		if c, ok := finding.AsProcessTree(); ok {
+			hostnames.add(c.HostName, author)
+		}
+	}
+
+	return &SectionSummary{
+		Countries: countries.build(true),
+		Owners:    owners.build(true),
+		HostNames: hostnames.build(false),
+		Malware:   malware.build(false),
+	}
+}
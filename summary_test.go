@@ -0,0 +1,66 @@
+package deepalert_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	da "github.com/cookpad/deepalert"
+)
+
+func TestSummarizeFindings(t *testing.T) {
+	t.Run("values are merged with authors", func(t *testing.T) {
+		summary := da.SummarizeFindings([]*da.Finding{
+			{Author: "geoip", Type: da.ContentTypeGeolocation, Content: &da.ContentGeolocation{Country: "JP"}},
+			{Author: "whois", Type: da.ContentTypeHost, Content: &da.ContentHost{
+				Country:  []string{"jp"},
+				HostName: []string{"h1", "h2"},
+				RelatedMalware: []da.EntityMalware{
+					{Scans: []da.EntityMalwareScan{
+						{Vendor: "v1", Name: "Trojan.X", Positive: true},
+						{Vendor: "v2", Name: "Clean", Positive: false},
+					}},
+				},
+			}},
+			{Author: "av", Type: da.ContentTypeBinary, Content: &da.ContentBinary{
+				RelatedMalware: []da.EntityMalware{
+					{Scans: []da.EntityMalwareScan{{Vendor: "v3", Name: "Trojan.X", Positive: true}}},
+				},
+			}},
+		})
+
+		require.Equal(t, 1, len(summary.Countries.Values))
+		assert.Equal(t, "JP", summary.Countries.Values[0].Value)
+		assert.Equal(t, []string{"geoip", "whois"}, summary.Countries.Values[0].Authors)
+		assert.False(t, summary.Countries.Conflict)
+
+		assert.Equal(t, []string{"h1", "h2"}, summary.HostNames.Strings())
+		assert.False(t, summary.HostNames.Conflict)
+
+		require.Equal(t, 1, len(summary.Malware.Values))
+		assert.Equal(t, "Trojan.X", summary.Malware.Values[0].Value)
+		assert.Equal(t, []string{"av", "whois"}, summary.Malware.Values[0].Authors)
+		assert.False(t, summary.IsEmpty())
+	})
+
+	t.Run("different country and owner are conflict", func(t *testing.T) {
+		summary := da.SummarizeFindings([]*da.Finding{
+			{Author: "geoip", Type: da.ContentTypeGeolocation, Content: &da.ContentGeolocation{Country: "JP"}},
+			{Author: "whois", Type: da.ContentTypeHost, Content: &da.ContentHost{Country: []string{"US"}, Owner: []string{"team-a"}}},
+			{Author: "inventory", Type: da.ContentTypeCloudResource, Content: &da.ContentCloudResource{OwnerTeam: "team-b"}},
+		})
+
+		assert.Equal(t, []string{"JP", "US"}, summary.Countries.Strings())
+		assert.True(t, summary.Countries.Conflict)
+		assert.Equal(t, []string{"team-a", "team-b"}, summary.Owners.Strings())
+		assert.True(t, summary.Owners.Conflict)
+	})
+
+	t.Run("no summarizable content", func(t *testing.T) {
+		summary := da.SummarizeFindings([]*da.Finding{
+			{Author: "a1", Type: da.ContentTypeUser, Content: &da.ContentUser{}},
+		})
+		assert.True(t, summary.IsEmpty())
+	})
+}
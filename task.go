@@ -11,4 +11,6 @@ type Task struct {
 type TaskResult struct {
 	Contents      []ReportContent
 	NewAttributes []*Attribute
+	// Provenance is applied to all Contents. Wrap a content by WithProvenance to set own Provenance.
+	Provenance Provenance
 }
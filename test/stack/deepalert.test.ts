@@ -3,8 +3,12 @@ import {
   haveResource,
   haveResourceLike,
   countResources,
+  SynthUtils,
 } from "@aws-cdk/assert";
 import * as cdk from "@aws-cdk/core";
+import * as kinesis from "@aws-cdk/aws-kinesis";
+import * as lambda from "@aws-cdk/aws-lambda";
+import * as s3 from "@aws-cdk/aws-s3";
 import * as fs from "fs";
 import * as os from "os";
 import * as path from "path";
@@ -21,12 +25,15 @@ const LAMBDA_FUNCTIONS = [
   "receptAlert",
 ];
 
+// Ingestion functions are created only if alertStream, alertBucket or enableHTTPIngestion is given
+const INGEST_FUNCTIONS = ["ingestKinesis", "ingestS3", "ingestHTTP"];
+
 // Create a temporary directory tree with stub bootstrap binaries so
 // lambda.Code.fromAsset has real directories to fingerprint.
 let assetsPath: string;
 beforeAll(() => {
   assetsPath = fs.mkdtempSync(path.join(os.tmpdir(), "deepalert-test-"));
-  for (const fn of LAMBDA_FUNCTIONS) {
+  for (const fn of [...LAMBDA_FUNCTIONS, ...INGEST_FUNCTIONS]) {
     const dir = path.join(assetsPath, fn);
     fs.mkdirSync(dir);
     fs.writeFileSync(path.join(dir, "bootstrap"), "");
@@ -76,6 +83,13 @@ describe("DeepAlertStack", () => {
       }));
     });
 
+    test("SQS event sources report batch item failures", () => {
+      expectCDK(stack).to(countResources("AWS::Lambda::EventSourceMapping", 4));
+      expectCDK(stack).to(haveResourceLike("AWS::Lambda::EventSourceMapping", {
+        FunctionResponseTypes: ["ReportBatchItemFailures"],
+      }));
+    });
+
     test("creates inspection and review Step Functions state machines", () => {
       expectCDK(stack).to(countResources("AWS::StepFunctions::StateMachine", 2));
       expectCDK(stack).to(haveResourceLike("AWS::StepFunctions::StateMachine", {
@@ -102,6 +116,118 @@ describe("DeepAlertStack", () => {
     });
   });
 
+  describe("stack with alertStream and alertBucket", () => {
+    test("creates ingestion functions", () => {
+      const app = new cdk.App();
+      const sources = new cdk.Stack(app, "SourceStack");
+      const stack = new Deepalert.DeepAlertStack(app, "TestStack", {
+        assetsPath,
+        alertStream: kinesis.Stream.fromStreamArn(sources, "alertStream",
+          "arn:aws:kinesis:us-east-1:123456789012:stream/alerts"),
+        alertBucket: s3.Bucket.fromBucketName(sources, "alertBucket", "alert-bucket"),
+      });
+
+      expectCDK(stack).to(countResources("AWS::Lambda::Function", LAMBDA_FUNCTIONS.length + 2));
+      expectCDK(stack).to(haveResourceLike("AWS::Lambda::EventSourceMapping", {
+        EventSourceArn: "arn:aws:kinesis:us-east-1:123456789012:stream/alerts",
+        FunctionResponseTypes: ["ReportBatchItemFailures"],
+        StartingPosition: "TRIM_HORIZON",
+      }));
+    });
+  });
+
+  describe("stack with enableHTTPIngestion", () => {
+    test("creates function URL of ingestHTTP", () => {
+      const stack = makeStack({ enableHTTPIngestion: true });
+
+      expectCDK(stack).to(countResources("AWS::Lambda::Function", LAMBDA_FUNCTIONS.length + 1));
+      expectCDK(stack).to(haveResourceLike("AWS::Lambda::Url", {
+        AuthType: "NONE",
+      }));
+    });
+  });
+
+  describe("stack with reviewers", () => {
+    test("invokes reviewers in parallel and passes review policy", () => {
+      const app = new cdk.App();
+      const reviewerStack = new cdk.Stack(app, "ReviewerStack");
+      const newReviewer = (name: string) => new lambda.Function(reviewerStack, name, {
+        runtime: lambda.Runtime.PROVIDED_AL2,
+        handler: "bootstrap",
+        code: lambda.Code.fromAsset(path.join(assetsPath, "dummyReviewer")),
+      });
+
+      const stack = new Deepalert.DeepAlertStack(app, "TestStack", {
+        assetsPath,
+        reviewers: [
+          { name: "cloud", func: newReviewer("cloudReviewer") },
+          { name: "identity", func: newReviewer("identityReviewer") },
+        ],
+        reviewPolicy: "majority",
+      });
+
+      expectCDK(stack).to(haveResourceLike("AWS::Lambda::Function", {
+        Environment: { Variables: { REVIEW_POLICY: "majority" } },
+      }));
+      expectCDK(stack).to(haveResourceLike("AWS::StepFunctions::StateMachine", {
+        StateMachineName: "TestStack-ReviewMachine",
+      }));
+    });
+
+    test("catches failure of each reviewer as no result", () => {
+      const app = new cdk.App();
+      const reviewerStack = new cdk.Stack(app, "ReviewerStack");
+      const func = new lambda.Function(reviewerStack, "reviewer", {
+        runtime: lambda.Runtime.PROVIDED_AL2,
+        handler: "bootstrap",
+        code: lambda.Code.fromAsset(path.join(assetsPath, "dummyReviewer")),
+      });
+
+      const stack = new Deepalert.DeepAlertStack(app, "TestStack", {
+        assetsPath,
+        reviewers: [{ name: "cloud", func }, { name: "identity", func }],
+      });
+
+      const template = SynthUtils.toCloudFormation(stack);
+      const machine = Object.values<any>(template.Resources).find((r) =>
+        r.Type === "AWS::StepFunctions::StateMachine" &&
+        r.Properties.StateMachineName === "TestStack-ReviewMachine");
+      // Tokens such as ARN of function are placed in strings of the definition
+      const definition = JSON.parse(machine.Properties.DefinitionString["Fn::Join"][1]
+        .map((part: any) => (typeof part === "string" ? part : "ref"))
+        .join(""));
+
+      const branches = definition.States.invokeReviewers.Branches;
+      expect(branches.length).toBe(2);
+      for (const name of ["cloud", "identity"]) {
+        const branch = branches.find((b: any) => b.StartAt === "invokeReviewer-" + name);
+        expect(branch.States["invokeReviewer-" + name].Catch).toEqual([
+          { ErrorEquals: ["States.ALL"], Next: "catchReviewer-" + name },
+        ]);
+        expect(branch.States["catchReviewer-" + name]).toEqual({
+          Type: "Pass",
+          Result: { reviewer: name, result: null },
+          End: true,
+        });
+      }
+    });
+
+    test("rejects duplicated reviewer names", () => {
+      const app = new cdk.App();
+      const reviewerStack = new cdk.Stack(app, "ReviewerStack");
+      const func = new lambda.Function(reviewerStack, "reviewer", {
+        runtime: lambda.Runtime.PROVIDED_AL2,
+        handler: "bootstrap",
+        code: lambda.Code.fromAsset(path.join(assetsPath, "dummyReviewer")),
+      });
+
+      expect(() => new Deepalert.DeepAlertStack(app, "TestStack", {
+        assetsPath,
+        reviewers: [{ name: "cloud", func }, { name: "cloud", func }],
+      })).toThrow(/Duplicated reviewer name/);
+    });
+  });
+
   describe("asset path validation", () => {
     test("throws a clear error when asset directory does not exist", () => {
       expect(() =>